@@ -0,0 +1,107 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"strings"
+	"unicode"
+)
+
+// DiffOp says how a run of characters compares between a typed and an expected answer
+type DiffOp int
+
+const (
+	DiffEqual   DiffOp = iota // Typed correctly
+	DiffExtra                 // Typed but not in the answer
+	DiffMissing               // In the answer but not typed
+)
+
+// DiffSegment is a run of characters with the same DiffOp
+type DiffSegment struct {
+	Op   DiffOp
+	Text string
+}
+
+// maxDiffLength caps the answer length compared character by character
+const maxDiffLength = 1000
+
+// DiffAnswer compares a typed answer with the expected one character by character, ignoring
+// case and surrounding whitespace. It also returns the similarity from 0 to 1
+func DiffAnswer(typed, expected string) ([]DiffSegment, float64) {
+	a := []rune(strings.TrimSpace(typed))
+	b := []rune(strings.TrimSpace(expected))
+	if len(a) > maxDiffLength {
+		a = a[:maxDiffLength]
+	}
+	if len(b) > maxDiffLength {
+		b = b[:maxDiffLength]
+	}
+	if len(a) == 0 && len(b) == 0 {
+		return nil, 1
+	}
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if sameRune(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var segments []DiffSegment
+	add := func(op DiffOp, r rune) {
+		if n := len(segments); n > 0 && segments[n-1].Op == op {
+			segments[n-1].Text += string(r)
+			return
+		}
+		segments = append(segments, DiffSegment{Op: op, Text: string(r)})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case sameRune(a[i], b[j]):
+			add(DiffEqual, b[j])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(DiffExtra, a[i])
+			i++
+		default:
+			add(DiffMissing, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(DiffExtra, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(DiffMissing, b[j])
+	}
+
+	similarity := 2 * float64(lcs[0][0]) / float64(len(a)+len(b))
+	return segments, similarity
+}
+
+// sameRune compares characters without regard to case
+func sameRune(a, b rune) bool {
+	return a == b || unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// SuggestRating proposes a rating from the similarity of a typed answer
+func SuggestRating(similarity float64) models.Rating {
+	switch {
+	case similarity >= 1:
+		return models.Good
+	case similarity >= 0.8:
+		return models.Hard
+	default:
+		return models.Again
+	}
+}
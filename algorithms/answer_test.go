@@ -0,0 +1,85 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffAnswer(t *testing.T) {
+	tests := []struct {
+		name           string
+		typed          string
+		expected       string
+		want           []DiffSegment
+		wantSimilarity float64
+	}{
+		{"exact", "dog", "dog", []DiffSegment{{DiffEqual, "dog"}}, 1},
+		{"case and whitespace ignored", "  Dog ", "dOG", []DiffSegment{{DiffEqual, "dOG"}}, 1},
+		{"both empty", "", "", nil, 1},
+		{"nothing typed", "", "cat", []DiffSegment{{DiffMissing, "cat"}}, 0},
+		{"nothing expected", "cat", "", []DiffSegment{{DiffExtra, "cat"}}, 0},
+		{
+			"extra letter", "colour", "color",
+			[]DiffSegment{{DiffEqual, "colo"}, {DiffExtra, "u"}, {DiffEqual, "r"}},
+			10.0 / 11,
+		},
+		{
+			"swapped letters", "dgo", "dog",
+			[]DiffSegment{{DiffEqual, "d"}, {DiffExtra, "g"}, {DiffEqual, "o"}, {DiffMissing, "g"}},
+			4.0 / 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, similarity := DiffAnswer(tt.typed, tt.expected)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DiffAnswer(%q, %q) = %v, want %v", tt.typed, tt.expected, got, tt.want)
+			}
+			if math.Abs(similarity-tt.wantSimilarity) > 1e-9 {
+				t.Errorf("similarity = %v, want %v", similarity, tt.wantSimilarity)
+			}
+		})
+	}
+}
+
+func TestDiffAnswerReassembles(t *testing.T) {
+	// The equal and missing segments spell the expected answer, the equal and extra ones
+	// what was typed
+	pairs := [][2]string{{"the quick fox", "a quick brown fox"}, {"recieve", "receive"}, {"xyz", "abc"}}
+	for _, pair := range pairs {
+		segments, _ := DiffAnswer(pair[0], pair[1])
+		var typed, expected strings.Builder
+		for _, segment := range segments {
+			if segment.Op != DiffMissing {
+				typed.WriteString(segment.Text)
+			}
+			if segment.Op != DiffExtra {
+				expected.WriteString(segment.Text)
+			}
+		}
+		if typed.String() != pair[0] || expected.String() != pair[1] {
+			t.Errorf("segments of %q and %q spell %q and %q", pair[0], pair[1], typed.String(), expected.String())
+		}
+	}
+}
+
+func TestSuggestRating(t *testing.T) {
+	tests := []struct {
+		similarity float64
+		want       models.Rating
+	}{
+		{1, models.Good},
+		{0.95, models.Hard},
+		{0.8, models.Hard},
+		{0.79, models.Again},
+		{0, models.Again},
+	}
+	for _, tt := range tests {
+		if got := SuggestRating(tt.similarity); got != tt.want {
+			t.Errorf("SuggestRating(%v) = %v, want %v", tt.similarity, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"math"
+	"time"
+)
+
+// DefaultFSRSWeights are the FSRS-5 default model parameters
+var DefaultFSRSWeights = []float64{
+	0.40255, 1.18385, 3.173, 15.69105, 7.1949, 0.5345, 1.4604, 0.0046, 1.54575, 0.1192,
+	1.01925, 1.9395, 0.11, 0.29605, 2.2698, 0.2315, 2.9898, 0.51655, 0.6621,
+}
+
+const (
+	// DefaultDesiredRetention is the recall probability FSRS aims for at review time
+	DefaultDesiredRetention = 0.9
+
+	// DefaultMaximumInterval caps FSRS intervals at roughly 100 years
+	DefaultMaximumInterval = 36500
+
+	fsrsDecay  = -0.5
+	fsrsFactor = 19.0 / 81.0 // 0.9^(1/decay) - 1, so that R = 90% when t = S
+)
+
+// FSRSScheduler schedules cards with the Free Spaced Repetition Scheduler (FSRS-5).
+// Each card carries a memory state of stability (days until recall probability
+// drops to 90%) and difficulty (1-10), and intervals are chosen so the predicted
+// retrievability at the next review equals the desired retention.
+type FSRSScheduler struct {
+	DesiredRetention float64
+	Weights          []float64
+	MaximumInterval  int
+}
+
+// NewFSRSScheduler creates an FSRS scheduler, substituting defaults for unset parameters
+func NewFSRSScheduler(desiredRetention float64, weights []float64, maximumInterval int) *FSRSScheduler {
+	if desiredRetention <= 0 || desiredRetention >= 1 {
+		desiredRetention = DefaultDesiredRetention
+	}
+	if len(weights) != len(DefaultFSRSWeights) {
+		weights = DefaultFSRSWeights
+	}
+	if maximumInterval <= 0 {
+		maximumInterval = DefaultMaximumInterval
+	}
+
+	return &FSRSScheduler{
+		DesiredRetention: desiredRetention,
+		Weights:          weights,
+		MaximumInterval:  maximumInterval,
+	}
+}
+
+// Name implements Scheduler
+func (f *FSRSScheduler) Name() string {
+	return SchedulerFSRS
+}
+
+// Preview implements Scheduler
+func (f *FSRSScheduler) Preview(card *models.Card, now time.Time) map[models.Rating]time.Duration {
+	return previewSchedule(f, card, now)
+}
+
+// Schedule implements Scheduler
+func (f *FSRSScheduler) Schedule(card *models.Card, rating models.Rating, now time.Time) {
+	countLapse(card, rating)
+	grade := float64(rating) + 1 // FSRS grades run from 1 (Again) to 4 (Easy)
+
+	if card.Stability <= 0 {
+		// First review - initialise the memory state from the rating alone
+		card.Stability = f.initialStability(grade)
+		card.Difficulty = f.initialDifficulty(grade)
+	} else {
+		elapsedDays := 0.0
+		if !card.LastReview.IsZero() {
+			elapsedDays = math.Max(0, now.Sub(card.LastReview).Hours()/24)
+		}
+
+		if elapsedDays < 1 {
+			// Same-day review
+			card.Stability = f.shortTermStability(card.Stability, grade)
+		} else {
+			retrievability := f.Retrievability(elapsedDays, card.Stability)
+			if rating == models.Again {
+				card.Stability = f.forgetStability(card.Difficulty, card.Stability, retrievability)
+			} else {
+				card.Stability = f.recallStability(card.Difficulty, card.Stability, retrievability, grade)
+			}
+		}
+		card.Difficulty = f.nextDifficulty(card.Difficulty, grade)
+	}
+
+	// Keep the repetition count consistent with the SM-2 scheduler
+	if rating == models.Again {
+		card.Repetition = 0
+	} else {
+		card.Repetition++
+	}
+
+	card.Interval = f.nextInterval(card.Stability)
+	card.LastReview = now
+	card.NextReview = now.AddDate(0, 0, card.Interval)
+	card.MarkModified()
+}
+
+// Retrievability returns the predicted probability of recall after elapsedDays
+func (f *FSRSScheduler) Retrievability(elapsedDays, stability float64) float64 {
+	return math.Pow(1+fsrsFactor*elapsedDays/stability, fsrsDecay)
+}
+
+// nextInterval returns the number of days until retrievability falls to the desired retention
+func (f *FSRSScheduler) nextInterval(stability float64) int {
+	interval := stability / fsrsFactor * (math.Pow(f.DesiredRetention, 1/fsrsDecay) - 1)
+	days := int(math.Round(interval))
+	if days < 1 {
+		days = 1
+	}
+	if days > f.MaximumInterval {
+		days = f.MaximumInterval
+	}
+	return days
+}
+
+func (f *FSRSScheduler) initialStability(grade float64) float64 {
+	return math.Max(f.Weights[int(grade)-1], 0.1)
+}
+
+func (f *FSRSScheduler) initialDifficulty(grade float64) float64 {
+	return clampDifficulty(f.Weights[4] - math.Exp(f.Weights[5]*(grade-1)) + 1)
+}
+
+func (f *FSRSScheduler) nextDifficulty(difficulty, grade float64) float64 {
+	delta := -f.Weights[6] * (grade - 3)
+	// Linear damping slows changes as difficulty approaches the maximum
+	next := difficulty + delta*(10-difficulty)/9
+	// Mean reversion towards the difficulty of an "Easy" first rating
+	next = f.Weights[7]*f.initialDifficulty(4) + (1-f.Weights[7])*next
+	return clampDifficulty(next)
+}
+
+func (f *FSRSScheduler) recallStability(difficulty, stability, retrievability, grade float64) float64 {
+	hardPenalty := 1.0
+	if grade == 2 {
+		hardPenalty = f.Weights[15]
+	}
+	easyBonus := 1.0
+	if grade == 4 {
+		easyBonus = f.Weights[16]
+	}
+
+	return stability * (1 + math.Exp(f.Weights[8])*
+		(11-difficulty)*
+		math.Pow(stability, -f.Weights[9])*
+		(math.Exp((1-retrievability)*f.Weights[10])-1)*
+		hardPenalty*easyBonus)
+}
+
+func (f *FSRSScheduler) forgetStability(difficulty, stability, retrievability float64) float64 {
+	next := f.Weights[11] *
+		math.Pow(difficulty, -f.Weights[12]) *
+		(math.Pow(stability+1, f.Weights[13]) - 1) *
+		math.Exp((1-retrievability)*f.Weights[14])
+
+	// Post-lapse stability can never exceed what a same-day "Again" would leave
+	return math.Min(next, stability/math.Exp(f.Weights[17]*f.Weights[18]))
+}
+
+func (f *FSRSScheduler) shortTermStability(stability, grade float64) float64 {
+	return stability * math.Exp(f.Weights[17]*(grade-3+f.Weights[18]))
+}
+
+func clampDifficulty(d float64) float64 {
+	return math.Min(math.Max(d, 1), 10)
+}
@@ -0,0 +1,189 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"math"
+	"testing"
+	"time"
+)
+
+// The expected values below follow the FSRS-5 formulas with the default weights
+
+// fsrsTolerance absorbs floating point noise in memory states
+const fsrsTolerance = 1e-9
+
+func TestFSRSRetrievability(t *testing.T) {
+	f := NewFSRSScheduler(0, nil, 0)
+	tests := []struct {
+		name      string
+		elapsed   float64
+		stability float64
+		want      float64
+	}{
+		{"just reviewed", 0, 5, 1},
+		{"at stability", 10, 10, 0.9},
+		{"half of stability", 5, 10, 0.946058996209746},
+		{"twice stability", 20, 10, 0.8250286473253902},
+		{"first good review due", 3, 3.173, 0.9046982108893272},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.Retrievability(tt.elapsed, tt.stability); math.Abs(got-tt.want) > fsrsTolerance {
+				t.Errorf("Retrievability(%v, %v) = %v, want %v", tt.elapsed, tt.stability, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSRSNextInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention float64
+		maximum   int
+		stability float64
+		want      int
+	}{
+		{"equals stability at 90%", 0.9, 0, 10, 10},
+		{"rounds up", 0.8, 0, 3.173, 8},       // 7.6089
+		{"rounds down", 0.95, 0, 15.69105, 7}, // 7.2267
+		{"at least a day", 0.9, 0, 0.1, 1},
+		{"capped at the maximum", 0.9, 365, 1000, 365},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFSRSScheduler(tt.retention, nil, tt.maximum)
+			if got := f.nextInterval(tt.stability); got != tt.want {
+				t.Errorf("nextInterval(%v) = %d, want %d", tt.stability, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSRSFirstReview(t *testing.T) {
+	tests := []struct {
+		rating         models.Rating
+		wantStability  float64
+		wantDifficulty float64
+		wantInterval   int
+	}{
+		{models.Again, 0.40255, 7.1949, 1},
+		{models.Hard, 1.18385, 6.488305268471453, 1},
+		{models.Good, 3.173, 5.282434422319005, 3},
+		{models.Easy, 15.69105, 3.2245015893713678, 16},
+	}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.rating.String(), func(t *testing.T) {
+			f := NewFSRSScheduler(0, nil, 0)
+			card := models.NewCard("front", "back")
+			f.Schedule(card, tt.rating, now)
+
+			if math.Abs(card.Stability-tt.wantStability) > fsrsTolerance {
+				t.Errorf("stability = %v, want %v", card.Stability, tt.wantStability)
+			}
+			if math.Abs(card.Difficulty-tt.wantDifficulty) > fsrsTolerance {
+				t.Errorf("difficulty = %v, want %v", card.Difficulty, tt.wantDifficulty)
+			}
+			if card.Interval != tt.wantInterval {
+				t.Errorf("interval = %d, want %d", card.Interval, tt.wantInterval)
+			}
+			if want := now.AddDate(0, 0, tt.wantInterval); !card.NextReview.Equal(want) {
+				t.Errorf("next review = %v, want %v", card.NextReview, want)
+			}
+		})
+	}
+}
+
+func TestFSRSReviewSequence(t *testing.T) {
+	// A card rated Good when new, then reviewed each time it falls due
+	steps := []struct {
+		rating         models.Rating
+		wantStability  float64
+		wantDifficulty float64
+		wantInterval   int
+		wantLapses     int
+	}{
+		{models.Good, 10.73892584613159, 5.272967931287446, 11, 0},
+		{models.Good, 34.57762350060534, 5.263544986114632, 35, 0},
+		{models.Again, 3.818174311547719, 6.784232087673549, 4, 1},
+		{models.Good, 11.019554788875238, 6.767857327381359, 11, 1},
+	}
+
+	f := NewFSRSScheduler(0, nil, 0)
+	card := models.NewCard("front", "back")
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.Schedule(card, models.Good, now)
+
+	for i, step := range steps {
+		now = card.NextReview
+		f.Schedule(card, step.rating, now)
+
+		if math.Abs(card.Stability-step.wantStability) > fsrsTolerance {
+			t.Errorf("review %d: stability = %v, want %v", i+1, card.Stability, step.wantStability)
+		}
+		if math.Abs(card.Difficulty-step.wantDifficulty) > fsrsTolerance {
+			t.Errorf("review %d: difficulty = %v, want %v", i+1, card.Difficulty, step.wantDifficulty)
+		}
+		if card.Interval != step.wantInterval {
+			t.Errorf("review %d: interval = %d, want %d", i+1, card.Interval, step.wantInterval)
+		}
+		if card.Lapses != step.wantLapses {
+			t.Errorf("review %d: lapses = %d, want %d", i+1, card.Lapses, step.wantLapses)
+		}
+	}
+}
+
+func TestFSRSSameDayReview(t *testing.T) {
+	tests := []struct {
+		rating         models.Rating
+		wantStability  float64
+		wantDifficulty float64
+	}{
+		{models.Again, 1.589763507266002, 6.796932579932991},
+		{models.Good, 4.466858064362218, 5.272967931287446},
+		{models.Easy, 7.487502277394533, 4.510985606964673},
+	}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.rating.String(), func(t *testing.T) {
+			f := NewFSRSScheduler(0, nil, 0)
+			card := models.NewCard("front", "back")
+			f.Schedule(card, models.Good, now)
+			f.Schedule(card, tt.rating, now.Add(10*time.Minute))
+
+			if math.Abs(card.Stability-tt.wantStability) > fsrsTolerance {
+				t.Errorf("stability = %v, want %v", card.Stability, tt.wantStability)
+			}
+			if math.Abs(card.Difficulty-tt.wantDifficulty) > fsrsTolerance {
+				t.Errorf("difficulty = %v, want %v", card.Difficulty, tt.wantDifficulty)
+			}
+		})
+	}
+}
+
+func TestNewFSRSSchedulerDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention float64
+		weights   []float64
+		maximum   int
+	}{
+		{"unset", 0, nil, 0},
+		{"retention out of range", 1, nil, 0},
+		{"wrong number of weights", 0.9, []float64{1, 2, 3}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFSRSScheduler(tt.retention, tt.weights, tt.maximum)
+			if f.DesiredRetention != DefaultDesiredRetention {
+				t.Errorf("desired retention = %v, want %v", f.DesiredRetention, DefaultDesiredRetention)
+			}
+			if len(f.Weights) != len(DefaultFSRSWeights) {
+				t.Errorf("got %d weights, want %d", len(f.Weights), len(DefaultFSRSWeights))
+			}
+			if f.MaximumInterval != DefaultMaximumInterval {
+				t.Errorf("maximum interval = %d, want %d", f.MaximumInterval, DefaultMaximumInterval)
+			}
+		})
+	}
+}
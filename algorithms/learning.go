@@ -0,0 +1,115 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LearningScheduler shows new and forgotten cards again after short learning steps
+// before handing them to the day-based scheduler it wraps
+type LearningScheduler struct {
+	Scheduler                       // Schedules graduated cards
+	LearningSteps   []time.Duration // Delays for new cards
+	RelearningSteps []time.Duration // Delays for forgotten review cards
+}
+
+// WithLearningSteps wraps a scheduler with learning steps; it is returned unchanged if both lists are empty
+func WithLearningSteps(scheduler Scheduler, learning, relearning []time.Duration) Scheduler {
+	if len(learning) == 0 && len(relearning) == 0 {
+		return scheduler
+	}
+	return &LearningScheduler{Scheduler: scheduler, LearningSteps: learning, RelearningSteps: relearning}
+}
+
+// Preview implements Scheduler
+func (s *LearningScheduler) Preview(card *models.Card, now time.Time) map[models.Rating]time.Duration {
+	return previewSchedule(s, card, now)
+}
+
+// Schedule implements Scheduler
+func (s *LearningScheduler) Schedule(card *models.Card, rating models.Rating, now time.Time) {
+	switch {
+	case card.Learning == models.Relearning:
+		s.step(card, rating, now, s.RelearningSteps)
+
+	case card.Learning == models.Learning || card.IsNew():
+		if card.IsNew() {
+			card.Learning = models.Learning
+			card.Step = 0
+		}
+		s.step(card, rating, now, s.LearningSteps)
+
+	default:
+		// Review card: the day-based scheduler applies the lapse, then relearning delays
+		// the day interval it chose
+		s.Scheduler.Schedule(card, rating, now)
+		if rating == models.Again && len(s.RelearningSteps) > 0 {
+			card.Learning = models.Relearning
+			card.Step = 0
+			card.NextReview = now.Add(s.RelearningSteps[0])
+		}
+	}
+}
+
+// step moves a card in learning through steps, graduating it past the last one
+func (s *LearningScheduler) step(card *models.Card, rating models.Rating, now time.Time, steps []time.Duration) {
+	switch rating {
+	case models.Again:
+		card.Step = 0
+	case models.Hard:
+		// Repeat the current step
+	case models.Good:
+		card.Step++
+	case models.Easy:
+		card.Step = len(steps)
+	}
+
+	if card.Step < len(steps) {
+		card.LastReview = now
+		card.NextReview = now.Add(steps[card.Step])
+		card.MarkModified()
+		return
+	}
+
+	// Graduate to day intervals
+	relearning := card.Learning == models.Relearning
+	card.Learning = models.NotLearning
+	card.Step = 0
+	if relearning {
+		// The interval was already reduced when the card lapsed
+		card.LastReview = now
+		card.NextReview = now.AddDate(0, 0, card.Interval)
+		card.MarkModified()
+		return
+	}
+	s.Scheduler.Schedule(card, rating, now)
+}
+
+// ParseSteps parses learning step durations such as "1m", "10m", "1h" or "1d"
+func ParseSteps(values []string) ([]time.Duration, error) {
+	steps := make([]time.Duration, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		var step time.Duration
+		if days, ok := strings.CutSuffix(value, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil {
+				return nil, fmt.Errorf("invalid learning step %q", value)
+			}
+			step = time.Duration(n) * 24 * time.Hour
+		} else {
+			var err error
+			if step, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid learning step %q", value)
+			}
+		}
+		if step <= 0 {
+			return nil, fmt.Errorf("invalid learning step %q", value)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
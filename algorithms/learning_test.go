@@ -0,0 +1,94 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLearningSchedulerSteps(t *testing.T) {
+	const day = 24 * time.Hour
+	reviewCard := func() *models.Card {
+		card := models.NewCard("front", "back")
+		card.Repetition = 3
+		card.Interval = 10
+		card.LastReview = time.Date(2025, 2, 19, 12, 0, 0, 0, time.UTC)
+		return card
+	}
+	tests := []struct {
+		name           string
+		card           func() *models.Card
+		ratings        []models.Rating
+		wantLearning   models.LearningState
+		wantStep       int
+		wantNext       time.Duration // From the last rating
+		wantRepetition int
+		wantLapses     int
+	}{
+		{"first step", nil, []models.Rating{models.Good}, models.Learning, 1, 10 * time.Minute, 0, 0},
+		{"hard repeats the step", nil, []models.Rating{models.Good, models.Hard}, models.Learning, 1, 10 * time.Minute, 0, 0},
+		{"again starts over", nil, []models.Rating{models.Good, models.Again}, models.Learning, 0, time.Minute, 0, 0},
+		{"graduates past the last step", nil, []models.Rating{models.Good, models.Good}, models.NotLearning, 0, day, 1, 0},
+		{"easy graduates at once", nil, []models.Rating{models.Easy}, models.NotLearning, 0, 4 * day, 1, 0},
+		{"lapse starts relearning", reviewCard, []models.Rating{models.Again}, models.Relearning, 0, 10 * time.Minute, 0, 1},
+		{"relearned card keeps its reduced interval", reviewCard, []models.Rating{models.Again, models.Good}, models.NotLearning, 0, day, 0, 1},
+		{"review card passes", reviewCard, []models.Rating{models.Good}, models.NotLearning, 0, 25 * day, 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := WithLearningSteps(&SM2Scheduler{}, []time.Duration{time.Minute, 10 * time.Minute}, []time.Duration{10 * time.Minute})
+			card := models.NewCard("front", "back")
+			if tt.card != nil {
+				card = tt.card()
+			}
+
+			now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			for i, rating := range tt.ratings {
+				if i > 0 {
+					now = card.NextReview
+				}
+				s.Schedule(card, rating, now)
+			}
+
+			if card.Learning != tt.wantLearning || card.Step != tt.wantStep {
+				t.Errorf("state = %q step %d, want %q step %d", card.Learning, card.Step, tt.wantLearning, tt.wantStep)
+			}
+			if next := card.NextReview.Sub(now); next != tt.wantNext {
+				t.Errorf("next review in %v, want %v", next, tt.wantNext)
+			}
+			if card.Repetition != tt.wantRepetition || card.Lapses != tt.wantLapses {
+				t.Errorf("repetition %d lapses %d, want %d and %d", card.Repetition, card.Lapses, tt.wantRepetition, tt.wantLapses)
+			}
+		})
+	}
+}
+
+func TestWithLearningStepsWithoutSteps(t *testing.T) {
+	scheduler := &SM2Scheduler{}
+	if got := WithLearningSteps(scheduler, nil, nil); got != Scheduler(scheduler) {
+		t.Errorf("WithLearningSteps without steps wrapped the scheduler")
+	}
+}
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{[]string{"1m", "10m"}, []time.Duration{time.Minute, 10 * time.Minute}, false},
+		{[]string{" 1h ", "2d"}, []time.Duration{time.Hour, 48 * time.Hour}, false},
+		{nil, []time.Duration{}, false},
+		{[]string{"0m"}, nil, true},
+		{[]string{"-5m"}, nil, true},
+		{[]string{"1.5d"}, nil, true},
+		{[]string{"soon"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSteps(tt.values)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseSteps(%q) = %v, %v, want %v, error %v", tt.values, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package algorithms
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"fmt"
+	"time"
+)
+
+// Scheduler names accepted in configuration
+const (
+	SchedulerSM2  = "sm2"
+	SchedulerFSRS = "fsrs"
+)
+
+// Scheduler decides when a card should be shown again after it has been rated
+type Scheduler interface {
+	// Name returns the identifier used to select the scheduler in config
+	Name() string
+
+	// Schedule applies the rating to the card as of the given review time
+	Schedule(card *models.Card, rating models.Rating, now time.Time)
+
+	// Preview returns the interval each rating would produce without modifying the card
+	Preview(card *models.Card, now time.Time) map[models.Rating]time.Duration
+}
+
+// NewScheduler creates the scheduler with the given name using the configured parameters,
+// wrapped with the configured learning steps
+func NewScheduler(name string, cfg config.SchedulerConfig) (Scheduler, error) {
+	var scheduler Scheduler
+	switch name {
+	case "", SchedulerSM2:
+		scheduler = &SM2Scheduler{}
+	case SchedulerFSRS:
+		scheduler = NewFSRSScheduler(cfg.DesiredRetention, cfg.Weights, cfg.MaximumInterval)
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q", name)
+	}
+
+	learning, err := ParseSteps(cfg.LearningSteps)
+	if err != nil {
+		return nil, err
+	}
+	relearning, err := ParseSteps(cfg.RelearningSteps)
+	if err != nil {
+		return nil, err
+	}
+	return WithLearningSteps(scheduler, learning, relearning), nil
+}
+
+// SchedulerForDeck returns the scheduler configured for a deck, falling back to SM-2
+// if the configured name is not recognised
+func SchedulerForDeck(cfg *config.Config, deckID string) Scheduler {
+	scheduler, err := NewScheduler(cfg.SchedulerFor(deckID), cfg.Scheduler)
+	if err != nil {
+		return &SM2Scheduler{}
+	}
+	return scheduler
+}
+
+// countLapse counts an Again rating on a card that had been learned as a lapse. Schedulers
+// call it before changing the card
+func countLapse(card *models.Card, rating models.Rating) {
+	if rating == models.Again && !card.IsNew() && !card.InLearning() {
+		card.Lapses++
+	}
+}
+
+// previewSchedule runs the scheduler on copies of the card to find the interval of each rating
+func previewSchedule(s Scheduler, card *models.Card, now time.Time) map[models.Rating]time.Duration {
+	previews := make(map[models.Rating]time.Duration, 4)
+	for _, rating := range []models.Rating{models.Again, models.Hard, models.Good, models.Easy} {
+		preview := *card
+		s.Schedule(&preview, rating, now)
+		previews[rating] = preview.NextReview.Sub(now)
+	}
+	return previews
+}
+
+// FormatInterval renders an interval compactly for display on rating buttons
+func FormatInterval(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+
+	days := d.Hours() / 24
+	switch {
+	case days < 30:
+		return fmt.Sprintf("%.0fd", days)
+	case days < 365:
+		return fmt.Sprintf("%.1fmo", days/30)
+	default:
+		return fmt.Sprintf("%.1fy", days/365)
+	}
+}
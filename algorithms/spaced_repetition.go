@@ -6,10 +6,28 @@ import (
 	"time"
 )
 
+// SM2Scheduler schedules cards with a variant of the SuperMemo 2 algorithm
+type SM2Scheduler struct{}
+
 // UpdateCardReview updates a card based on the user's rating using the SM-2 algorithm
-// This is based on the SuperMemo 2 algorithm for spaced repetition
 func UpdateCardReview(card *models.Card, rating models.Rating) {
-	now := time.Now()
+	(&SM2Scheduler{}).Schedule(card, rating, time.Now())
+}
+
+// Name implements Scheduler
+func (s *SM2Scheduler) Name() string {
+	return SchedulerSM2
+}
+
+// Preview implements Scheduler
+func (s *SM2Scheduler) Preview(card *models.Card, now time.Time) map[models.Rating]time.Duration {
+	return previewSchedule(s, card, now)
+}
+
+// Schedule updates a card based on the user's rating using the SM-2 algorithm
+// This is based on the SuperMemo 2 algorithm for spaced repetition
+func (s *SM2Scheduler) Schedule(card *models.Card, rating models.Rating, now time.Time) {
+	countLapse(card, rating)
 	card.LastReview = now
 	card.MarkModified()
 
@@ -102,7 +120,7 @@ func CalculateRetentionStats(cards []models.Card) (total, mature, young, new int
 	total = len(cards)
 
 	for _, card := range cards {
-		if card.Repetition == 0 {
+		if card.IsNew() {
 			new++
 		} else if card.Interval >= 21 { // Cards with interval >= 21 days are considered mature
 			mature++
@@ -0,0 +1,82 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"time"
+)
+
+// ReviewsPerDay counts reviews on each of the last days study days, oldest first, ending
+// with today
+func ReviewsPerDay(logs []*models.ReviewLog, now time.Time, days, rolloverHour int) []int {
+	counts := make([]int, days)
+	for _, log := range logs {
+		ago := models.StudyDaysBetween(log.Timestamp, now, rolloverHour)
+		if ago >= 0 && ago < days {
+			counts[days-1-ago]++
+		}
+	}
+	return counts
+}
+
+// TrueRetention returns how many scheduled reviews of learned cards were passed. Learning
+// steps and practice sessions are excluded so the rate reflects real recall
+func TrueRetention(logs []*models.ReviewLog) (passed, total int) {
+	for _, log := range logs {
+		if log.Type != models.ReviewTypeReview || log.Mode == models.PracticeMode {
+			continue
+		}
+		total++
+		if log.IsCorrect() {
+			passed++
+		}
+	}
+	return passed, total
+}
+
+// AnswerDistribution counts how often each rating button was pressed
+func AnswerDistribution(logs []*models.ReviewLog) [4]int {
+	var counts [4]int
+	for _, log := range logs {
+		if log.Rating >= models.Again && log.Rating <= models.Easy {
+			counts[log.Rating]++
+		}
+	}
+	return counts
+}
+
+// AverageEase returns the mean ease factor of cards that have been reviewed at least once
+func AverageEase(cards []models.Card) float64 {
+	var sum float64
+	var count int
+	for _, card := range cards {
+		if card.IsNew() {
+			continue
+		}
+		sum += card.EaseFactor
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// DueForecast counts learned cards falling due on each of the next days study days, the
+// first including cards that are already overdue. Suspended cards and cards lent to a
+// filtered deck are left out, as they are from the deck list's due counts
+func DueForecast(cards []models.Card, now time.Time, days, rolloverHour int) []int {
+	counts := make([]int, days)
+	for _, card := range cards {
+		if card.IsNew() || card.Suspended || card.IsBorrowed() {
+			continue
+		}
+		ahead := models.StudyDaysBetween(now, card.NextReview, rolloverHour)
+		if ahead < 0 {
+			ahead = 0
+		}
+		if ahead < days {
+			counts[ahead]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,45 @@
+package algorithms
+
+import (
+	"anktui/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLearnedCardStatistics(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	newCard := models.Card{ID: "new", EaseFactor: 2.5}
+	review := models.Card{ID: "review", Repetition: 4, Interval: 30, EaseFactor: 2.6, LastReview: now.AddDate(0, 0, -30), NextReview: now.AddDate(0, 0, 2)}
+	// A lapse resets the repetition count, but the card has been studied
+	lapsed := models.Card{ID: "lapsed", Repetition: 0, Interval: 1, Lapses: 1, EaseFactor: 2.2, LastReview: now.Add(-time.Hour), NextReview: now.Add(time.Hour)}
+	suspended := review
+	suspended.ID, suspended.Suspended = "suspended", true
+
+	tests := []struct {
+		name         string
+		cards        []models.Card
+		wantEase     float64
+		wantForecast []int
+		wantNew      int
+	}{
+		{"nothing studied", []models.Card{newCard}, 0, []int{0, 0, 0}, 1},
+		{"review card", []models.Card{newCard, review}, 2.6, []int{0, 0, 1}, 1},
+		{"lapsed card", []models.Card{newCard, lapsed}, 2.2, []int{1, 0, 0}, 1},
+		{"review and lapsed", []models.Card{review, lapsed}, 2.4, []int{1, 0, 1}, 0},
+		{"suspended card", []models.Card{review, suspended}, 2.6, []int{0, 0, 1}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ease := AverageEase(tt.cards); ease < tt.wantEase-1e-9 || ease > tt.wantEase+1e-9 {
+				t.Errorf("AverageEase() = %v, want %v", ease, tt.wantEase)
+			}
+			if forecast := DueForecast(tt.cards, now, 3, 4); !slices.Equal(forecast, tt.wantForecast) {
+				t.Errorf("DueForecast() = %v, want %v", forecast, tt.wantForecast)
+			}
+			if _, _, _, newCards := CalculateRetentionStats(tt.cards); newCards != tt.wantNew {
+				t.Errorf("CalculateRetentionStats() counts %d new cards, want %d", newCards, tt.wantNew)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot archives are named anktui-YYYYMMDD-HHMMSS.tar.gz
+const (
+	snapshotPrefix     = "anktui-"
+	snapshotSuffix     = ".tar.gz"
+	snapshotTimeFormat = "20060102-150405"
+)
+
+// Snapshot is a compressed archive of the data directory
+type Snapshot struct {
+	Name    string
+	Path    string
+	Created time.Time
+	Size    int64
+}
+
+// Create archives every file in dataDir into a new snapshot in backupDir
+func Create(dataDir, backupDir string, now time.Time) (*Snapshot, error) {
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := snapshotPrefix + now.Format(snapshotTimeFormat) + snapshotSuffix
+	path := filepath.Join(backupDir, name)
+	if _, err := os.Stat(path); err == nil {
+		// Two snapshots in the same second hold the same data
+		return snapshotFromPath(path)
+	}
+
+	// Write to a temporary name so a failed backup never looks like a complete one
+	tmpPath := path + ".partial"
+	if err := writeArchive(tmpPath, dataDir, backupDir); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to save backup: %w", err)
+	}
+
+	return snapshotFromPath(path)
+}
+
+// writeArchive writes a gzipped tar of dataDir to path, skipping backupDir if it is inside dataDir
+func writeArchive(path, dataDir, backupDir string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+
+	absBackupDir, _ := filepath.Abs(backupDir)
+	err = filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); abs == absBackupDir {
+			return filepath.SkipDir
+		}
+		if skipEntry(info.Name()) {
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(archive, src)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive data directory: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return file.Close()
+}
+
+// skipEntry reports whether a file in the data directory should be left out of snapshots
+// and left alone by restores: the instance lock and temporary files from interrupted writes
+func skipEntry(name string) bool {
+	return name == ".lock" || strings.Contains(name, ".tmp-") || strings.HasPrefix(name, ".restore-")
+}
+
+// List returns the snapshots in backupDir, newest first
+func List(backupDir string) ([]Snapshot, error) {
+	matches, err := filepath.Glob(filepath.Join(backupDir, snapshotPrefix+"*"+snapshotSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, match := range matches {
+		snapshot, err := snapshotFromPath(match)
+		if err != nil {
+			// Ignore files that only look like snapshots
+			continue
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// Find returns the snapshot in backupDir matching name, which may be a file name or a path
+func Find(backupDir, name string) (*Snapshot, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		return snapshotFromPath(name)
+	}
+	if !strings.HasSuffix(name, snapshotSuffix) {
+		name += snapshotSuffix
+	}
+	if !strings.HasPrefix(name, snapshotPrefix) {
+		name = snapshotPrefix + name
+	}
+	return snapshotFromPath(filepath.Join(backupDir, name))
+}
+
+// snapshotFromPath describes the snapshot at path, reading its creation time from the name
+func snapshotFromPath(path string) (*Snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("backup not found: %w", err)
+	}
+
+	name := filepath.Base(path)
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix)
+	created, err := time.ParseInLocation(snapshotTimeFormat, stamp, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an anktui backup", name)
+	}
+
+	return &Snapshot{Name: name, Path: path, Created: created, Size: info.Size()}, nil
+}
+
+// Prune deletes snapshots beyond the retention rules, keeping the newest snapshot from each
+// of the keepDaily most recent days and each of the keepWeekly most recent weeks that have
+// snapshots. The most recent snapshot is always kept
+func Prune(backupDir string, keepDaily, keepWeekly int) ([]Snapshot, error) {
+	snapshots, err := List(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool)
+	if len(snapshots) > 0 {
+		keep[snapshots[0].Path] = true
+	}
+
+	// Snapshots are newest first, so the first one seen in a period is the one kept
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for _, snapshot := range snapshots {
+		day := snapshot.Created.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[snapshot.Path] = true
+		}
+
+		year, week := snapshot.Created.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			weeks[weekKey] = true
+			keep[snapshot.Path] = true
+		}
+	}
+
+	var removed []Snapshot
+	for _, snapshot := range snapshots {
+		if keep[snapshot.Path] {
+			continue
+		}
+		if err := os.Remove(snapshot.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", snapshot.Name, err)
+		}
+		removed = append(removed, snapshot)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	// 10 March 2025 is the Monday starting ISO week 11
+	stamps := []string{
+		"20250310-180000",
+		"20250310-090000",
+		"20250309-200000", // Week 10
+		"20250308-200000", // Week 10
+		"20250302-120000", // Week 9
+		"20250220-120000", // Week 8
+	}
+	tests := []struct {
+		name       string
+		keepDaily  int
+		keepWeekly int
+		want       []string
+	}{
+		{"newest only", 0, 0, []string{"20250310-180000"}},
+		{"daily", 2, 0, []string{"20250310-180000", "20250309-200000"}},
+		{"one per day", 10, 0, []string{"20250310-180000", "20250309-200000", "20250308-200000", "20250302-120000", "20250220-120000"}},
+		{"weekly", 0, 3, []string{"20250310-180000", "20250309-200000", "20250302-120000"}},
+		{"daily and weekly", 1, 4, []string{"20250310-180000", "20250309-200000", "20250302-120000", "20250220-120000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupDir := t.TempDir()
+			for _, stamp := range stamps {
+				writeFile(t, filepath.Join(backupDir, snapshotPrefix+stamp+snapshotSuffix))
+			}
+			// Files that are not snapshots are never removed
+			writeFile(t, filepath.Join(backupDir, snapshotPrefix+"garbage"+snapshotSuffix))
+			writeFile(t, filepath.Join(backupDir, "notes.txt"))
+
+			removed, err := Prune(backupDir, tt.keepDaily, tt.keepWeekly)
+			if err != nil {
+				t.Fatalf("Prune failed: %v", err)
+			}
+			if len(removed) != len(stamps)-len(tt.want) {
+				t.Errorf("removed %d snapshots, want %d", len(removed), len(stamps)-len(tt.want))
+			}
+
+			snapshots, err := List(backupDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kept []string
+			for _, snapshot := range snapshots {
+				kept = append(kept, snapshot.Created.Format(snapshotTimeFormat))
+			}
+			if !slices.Equal(kept, tt.want) {
+				t.Errorf("kept %v, want %v", kept, tt.want)
+			}
+			for _, name := range []string{snapshotPrefix + "garbage" + snapshotSuffix, "notes.txt"} {
+				if _, err := os.Stat(filepath.Join(backupDir, name)); err != nil {
+					t.Errorf("%s was removed", name)
+				}
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	backupDir := t.TempDir()
+	name := snapshotPrefix + "20250310-180000" + snapshotSuffix
+	writeFile(t, filepath.Join(backupDir, name))
+
+	for _, query := range []string{name, "20250310-180000", snapshotPrefix + "20250310-180000", filepath.Join(backupDir, name)} {
+		snapshot, err := Find(backupDir, query)
+		if err != nil {
+			t.Errorf("Find(%q) failed: %v", query, err)
+			continue
+		}
+		if want := time.Date(2025, 3, 10, 18, 0, 0, 0, time.Local); !snapshot.Created.Equal(want) {
+			t.Errorf("Find(%q) created = %v, want %v", query, snapshot.Created, want)
+		}
+	}
+	if _, err := Find(backupDir, "20250311-180000"); err == nil {
+		t.Errorf("Find of a missing snapshot succeeded")
+	}
+}
+
+// writeFile creates an empty file
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
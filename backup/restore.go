@@ -0,0 +1,214 @@
+package backup
+
+import (
+	"anktui/models"
+	"anktui/storage"
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeckSummary describes a deck inside a snapshot
+type DeckSummary struct {
+	Name  string
+	Cards int
+}
+
+// Summary describes the contents of a snapshot
+type Summary struct {
+	Decks   []DeckSummary
+	Reviews int
+	Files   int
+}
+
+// TotalCards returns the number of cards across all decks in the snapshot
+func (s *Summary) TotalCards() int {
+	total := 0
+	for _, deck := range s.Decks {
+		total += deck.Cards
+	}
+	return total
+}
+
+// Inspect extracts a snapshot to a temporary directory and counts its decks, cards and reviews
+func Inspect(snapshotPath string) (*Summary, error) {
+	dir, err := os.MkdirTemp("", "anktui-inspect-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := extract(snapshotPath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{Files: files}
+	var decks []*models.Deck
+
+	// Decks come from the SQLite database when there is one, otherwise from JSON files
+	dbPath := filepath.Join(dir, "anktui.db")
+	if _, err := os.Stat(dbPath); err == nil {
+		store, err := storage.OpenSQLiteStorage(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		defer store.Close()
+
+		if decks, err = store.LoadAllDecks(); err != nil {
+			return nil, err
+		}
+		if reviews, err := store.GetReviewLogsInRange(time.Time{}, time.Now().AddDate(100, 0, 0)); err == nil {
+			summary.Reviews = len(reviews)
+		}
+	} else {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				continue
+			}
+			var deck models.Deck
+			if err := json.Unmarshal(data, &deck); err != nil {
+				continue
+			}
+			decks = append(decks, &deck)
+		}
+		summary.Reviews = countLines(filepath.Join(dir, "review_log.jsonl"))
+	}
+
+	for _, deck := range decks {
+		summary.Decks = append(summary.Decks, DeckSummary{Name: deck.Name, Cards: len(deck.Cards)})
+	}
+	sort.Slice(summary.Decks, func(i, j int) bool {
+		return summary.Decks[i].Name < summary.Decks[j].Name
+	})
+
+	return summary, nil
+}
+
+// countLines returns the number of non-empty lines in a file, or 0 if it cannot be read
+func countLines(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// Restore replaces the contents of dataDir with the snapshot. The snapshot is fully
+// extracted before anything is removed, so a damaged archive leaves the data untouched.
+// backupDir is left alone if it lives inside dataDir
+func Restore(snapshotPath, dataDir, backupDir string) error {
+	staging, err := os.MkdirTemp(dataDir, ".restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if _, err := extract(snapshotPath, staging); err != nil {
+		return err
+	}
+
+	// Remove the current data, keeping the lock, the staging area and the backups
+	absBackupDir, _ := filepath.Abs(backupDir)
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to read data directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dataDir, entry.Name())
+		if abs, _ := filepath.Abs(path); abs == absBackupDir || skipEntry(entry.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+		}
+	}
+
+	// Move the snapshot's files into place
+	staged, err := os.ReadDir(staging)
+	if err != nil {
+		return fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	for _, entry := range staged {
+		if err := os.Rename(filepath.Join(staging, entry.Name()), filepath.Join(dataDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// extract unpacks a snapshot into dir and returns the number of files written
+func extract(snapshotPath, dir string) (int, error) {
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer gz.Close()
+
+	files := 0
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return files, fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		// Refuse entries that would escape the target directory
+		name := filepath.FromSlash(header.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return files, fmt.Errorf("backup contains unsafe path %q", header.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return files, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return files, err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return files, err
+			}
+			if _, err := io.Copy(out, archive); err != nil {
+				out.Close()
+				return files, fmt.Errorf("failed to extract %s: %w", header.Name, err)
+			}
+			if err := out.Close(); err != nil {
+				return files, err
+			}
+			files++
+		}
+	}
+
+	return files, nil
+}
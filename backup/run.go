@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"anktui/config"
+	"time"
+)
+
+// Run snapshots the configured data directory and prunes old snapshots
+func Run(cfg *config.Config) (*Snapshot, error) {
+	dataDir, err := cfg.GetExpandedDataDir()
+	if err != nil {
+		return nil, err
+	}
+	backupDir, err := cfg.GetBackupDir()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := Create(dataDir, backupDir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Prune(backupDir, cfg.BackupKeepDaily, cfg.BackupKeepWeekly); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// RestoreSnapshot replaces the configured data directory with a snapshot. The current data
+// is snapshotted first so the restore itself can be undone
+func RestoreSnapshot(cfg *config.Config, snapshot *Snapshot) (*Snapshot, error) {
+	dataDir, err := cfg.GetExpandedDataDir()
+	if err != nil {
+		return nil, err
+	}
+	backupDir, err := cfg.GetBackupDir()
+	if err != nil {
+		return nil, err
+	}
+
+	safety, err := Create(dataDir, backupDir, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if safety.Path == snapshot.Path {
+		// Restoring the snapshot just taken would change nothing
+		return safety, nil
+	}
+
+	return safety, Restore(snapshot.Path, dataDir, backupDir)
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// runAdd adds a note to a deck, creating one card or more depending on its type
+func runAdd(cfg *config.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("add", out)
+	deckName := flags.String("deck", "", "deck name or ID (required)")
+	front := flags.String("front", "", "front of the card, or cloze text (required)")
+	back := flags.String("back", "", "back of the card (required unless --type cloze)")
+	noteType := flags.String("type", "basic", "card type: basic, reversed or cloze")
+	tags := flags.String("tags", "", "space or comma separated tags")
+	create := flags.Bool("create", false, "create the deck if it does not exist")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	note := models.NewNote(models.NoteType(strings.ToLower(*noteType)), strings.TrimSpace(*front), strings.TrimSpace(*back))
+	switch note.Type {
+	case models.BasicNote, models.ReversedNote:
+		if *deckName == "" || note.Front == "" || note.Back == "" {
+			return fmt.Errorf("--deck, --front and --back are required")
+		}
+	case models.ClozeNote:
+		if *deckName == "" || !models.HasCloze(note.Front) {
+			return fmt.Errorf("--deck and a --front with cloze deletions like {{c1::answer}} are required")
+		}
+	default:
+		return fmt.Errorf("unknown card type %q (use basic, reversed or cloze)", *noteType)
+	}
+
+	store, closeStore, err := openStorage(cfg, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	decks, err := store.LoadAllDecks()
+	if err != nil {
+		return err
+	}
+	deck, err := findDeck(decks, *deckName)
+	if err != nil {
+		if !*create {
+			return fmt.Errorf("%w (use --create to create it)", err)
+		}
+		deck = models.NewDeck(*deckName, "")
+	}
+
+	deck.AddNote(note, nil)
+	deck.SetNoteTags(note.ID, models.ParseTags(*tags))
+	var cardIDs []string
+	for _, card := range deck.Cards {
+		if card.NoteID == note.ID {
+			cardIDs = append(cardIDs, card.ID)
+		}
+	}
+
+	if err := store.SaveDeck(deck); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(out, map[string]any{"deck_id": deck.ID, "deck": deck.Name, "note_id": note.ID, "card_ids": cardIDs})
+	}
+	fmt.Fprintf(out, "Added %d cards to %s\n", len(cardIDs), deck.Name)
+	return nil
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"anktui/backup"
+	"anktui/config"
+	"anktui/storage"
+	"fmt"
+	"io"
+)
+
+// runBackup lists, creates and restores snapshots of the data directory
+func runBackup(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: anktui backup list|create|restore <name>")
+	}
+
+	backupDir, err := cfg.GetBackupDir()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		snapshots, err := backup.List(backupDir)
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Fprintf(out, "No backups in %s\n", backupDir)
+			return nil
+		}
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(out, "%s  %s  %d bytes\n", snapshot.Name, snapshot.Created.Format("2006-01-02 15:04:05"), snapshot.Size)
+		}
+		return nil
+
+	case "create":
+		snapshot, err := backup.Run(cfg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created %s\n", snapshot.Path)
+		return nil
+
+	case "restore":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: anktui backup restore <name>")
+		}
+		snapshot, err := backup.Find(backupDir, args[1])
+		if err != nil {
+			return err
+		}
+
+		// Opening the storage fails if the TUI is using the data directory
+		store, err := storage.New(cfg)
+		if err != nil {
+			return err
+		}
+		if closer, ok := store.(io.Closer); ok {
+			closer.Close()
+		}
+
+		safety, err := backup.RestoreSnapshot(cfg, snapshot)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Restored %s (previous data saved as %s)\n", snapshot.Name, safety.Name)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown backup command %q", args[0])
+	}
+}
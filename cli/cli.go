@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"anktui/config"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// command is a subcommand run instead of the TUI
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string, out io.Writer) error
+}
+
+// commands lists the available subcommands in the order shown by help
+var commands = []command{
+	{"decks", "List decks with card counts", runDecks},
+	{"add", "Add a card: add --deck X --front ... --back ...", runAdd},
+	{"due", "Show how many cards are due", runDue},
+	{"stats", "Show card and review statistics", runStats},
+	{"import", "Import an .apkg, .csv or .tsv file: import <file> [--deck X]", runImport},
+	{"export", "Export to an .apkg, .csv or .tsv file: export <file> [--deck X]", runExport},
+	{"migrate", "Copy the JSON data directory into the SQLite database", runMigrate},
+	{"backup", "List, create or restore backups: backup list|create|restore <name>", runBackup},
+}
+
+// Run executes the subcommand named by args[0]
+func Run(cfg *config.Config, args []string, out io.Writer) error {
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(out)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			err := cmd.run(cfg, args[1:], out)
+			if errors.Is(err, flag.ErrHelp) {
+				// Flag usage has already been printed
+				return nil
+			}
+			return err
+		}
+	}
+
+	printUsage(out)
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// printUsage lists the subcommands
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: anktui [command]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run without a command to start the TUI. Most commands accept --json.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"anktui/config"
+	"fmt"
+	"io"
+)
+
+// deckInfo is the JSON form of a deck listing
+type deckInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Cards       int    `json:"cards"`
+	New         int    `json:"new"`
+	Due         int    `json:"due"`
+}
+
+// runDecks lists every deck with its card counts
+func runDecks(cfg *config.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("decks", out)
+	asJSON := flags.Bool("json", false, "print JSON")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStorage(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	decks, err := store.LoadAllDecks()
+	if err != nil {
+		return err
+	}
+
+	infos := make([]deckInfo, 0, len(decks))
+	for _, deck := range decks {
+		total, new, due := deck.GetCardStats()
+		infos = append(infos, deckInfo{
+			ID:          deck.ID,
+			Name:        deck.Name,
+			Description: deck.Description,
+			Cards:       total,
+			New:         new,
+			Due:         due,
+		})
+	}
+
+	if *asJSON {
+		return printJSON(out, infos)
+	}
+	if len(infos) == 0 {
+		fmt.Fprintln(out, "No decks")
+		return nil
+	}
+	for _, info := range infos {
+		fmt.Fprintf(out, "%-30s %5d cards %5d new %5d due  %s\n", info.Name, info.Cards, info.New, info.Due, info.ID)
+	}
+	return nil
+}
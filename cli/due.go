@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"fmt"
+	"io"
+)
+
+// dueInfo is the JSON form of the due counts
+type dueInfo struct {
+	Due   int        `json:"due"`
+	New   int        `json:"new"`
+	Decks []deckInfo `json:"decks"`
+}
+
+// runDue prints the number of cards waiting for review
+func runDue(cfg *config.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("due", out)
+	deckName := flags.String("deck", "", "only count this deck (name or ID)")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStorage(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	decks, err := store.LoadAllDecks()
+	if err != nil {
+		return err
+	}
+	if *deckName != "" {
+		deck, err := findDeck(decks, *deckName)
+		if err != nil {
+			return err
+		}
+		decks = []*models.Deck{deck}
+	}
+
+	info := dueInfo{Decks: make([]deckInfo, 0, len(decks))}
+	for _, deck := range decks {
+		total, new, due := deck.GetCardStats()
+		info.Due += due
+		info.New += new
+		info.Decks = append(info.Decks, deckInfo{ID: deck.ID, Name: deck.Name, Cards: total, New: new, Due: due})
+	}
+
+	if *asJSON {
+		return printJSON(out, info)
+	}
+
+	// The total comes first so status bars can read a single line
+	fmt.Fprintf(out, "%d due, %d new\n", info.Due, info.New)
+	if *deckName == "" {
+		for _, deck := range info.Decks {
+			if deck.Due > 0 || deck.New > 0 {
+				fmt.Fprintf(out, "  %-30s %5d due %5d new\n", deck.Name, deck.Due, deck.New)
+			}
+		}
+	}
+	return nil
+}
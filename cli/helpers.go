@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"anktui/storage"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// parseFlags parses flags that may appear before or after positional arguments,
+// returning the positional arguments
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newFlagSet creates a flag set that reports errors to out instead of exiting
+func newFlagSet(name string, out io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(out)
+	return flags
+}
+
+// printJSON writes v as indented JSON
+func printJSON(out io.Writer, v any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// openStorage opens the configured storage, taking the data directory lock when writing
+func openStorage(cfg *config.Config, write bool) (storage.Storage, func(), error) {
+	var store storage.Storage
+	var err error
+	if write {
+		store, err = storage.New(cfg)
+	} else {
+		store, err = storage.NewReadOnly(cfg)
+	}
+	if errors.Is(err, storage.ErrLocked) {
+		// The TUI would overwrite the change the next time it saves the deck
+		return nil, nil, fmt.Errorf("%w; quit it before changing decks from the command line", err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeStore := func() {
+		if closer, ok := store.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return store, closeStore, nil
+}
+
+// findDeck returns the deck whose ID or name (case-insensitively) matches nameOrID
+func findDeck(decks []*models.Deck, nameOrID string) (*models.Deck, error) {
+	for _, deck := range decks {
+		if deck.ID == nameOrID {
+			return deck, nil
+		}
+	}
+	for _, deck := range decks {
+		if strings.EqualFold(deck.Name, nameOrID) {
+			return deck, nil
+		}
+	}
+	return nil, fmt.Errorf("deck %q not found", nameOrID)
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"anktui/config"
+	"anktui/storage"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteCommandsWhileLocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		args    []string
+	}{
+		{"add with JSON storage", config.StorageJSON, []string{"add", "--deck", "Spanish", "--front", "perro", "--back", "dog"}},
+		{"add with SQLite storage", config.StorageSQLite, []string{"add", "--deck", "Spanish", "--front", "perro", "--back", "dog"}},
+		{"import with SQLite storage", config.StorageSQLite, []string{"import", "missing.csv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.DataDirectory = t.TempDir()
+			cfg.StorageBackend = tt.backend
+
+			// Another instance, such as the TUI, holds the data directory
+			running, err := storage.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer running.(io.Closer).Close()
+
+			err = Run(cfg, tt.args, &bytes.Buffer{})
+			if !errors.Is(err, storage.ErrLocked) || !strings.Contains(err.Error(), "in use") {
+				t.Errorf("Run = %v, want a data directory in use error", err)
+			}
+		})
+	}
+}
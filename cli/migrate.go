@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"anktui/config"
+	"anktui/storage"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// runMigrate copies decks and review history from JSON files into SQLite
+func runMigrate(cfg *config.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	useSQLite := flags.Bool("switch", false, "set storage_backend to sqlite in the config file afterwards")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	src, err := storage.NewJSONStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// The JSON storage holds the data directory lock for both
+	dataDir, err := cfg.GetExpandedDataDir()
+	if err != nil {
+		return err
+	}
+	dst, err := storage.OpenSQLiteStorage(storage.SQLitePath(dataDir))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	result, err := storage.MigrateJSONToSQLite(src, dst)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Migrated %d decks, %d cards and %d reviews to SQLite\n", result.Decks, result.Cards, result.Reviews)
+
+	if !*useSQLite {
+		fmt.Fprintf(out, "Set \"storage_backend\": %q in %s (or rerun with --switch) to use it\n",
+			config.StorageSQLite, config.GetConfigPath())
+		return nil
+	}
+
+	cfg.StorageBackend = config.StorageSQLite
+	if err := cfg.SaveConfig(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Fprintf(out, "Switched storage_backend to %s\n", config.StorageSQLite)
+	return nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"anktui/algorithms"
+	"anktui/config"
+	"anktui/models"
+	"fmt"
+	"io"
+	"time"
+)
+
+// statsInfo is the JSON form of the statistics
+type statsInfo struct {
+	Cards        int     `json:"cards"`
+	New          int     `json:"new"`
+	Young        int     `json:"young"`
+	Mature       int     `json:"mature"`
+	Due          int     `json:"due"`
+	AverageEase  float64 `json:"average_ease"`
+	ReviewsToday int     `json:"reviews_today"`
+	Reviews7d    int     `json:"reviews_7d"`
+	Reviews30d   int     `json:"reviews_30d"`
+	Retention    float64 `json:"retention"` // Share of review-type answers that were correct, 0 with no reviews
+	ReviewCount  int     `json:"review_count"`
+}
+
+// runStats prints card maturity and review statistics
+func runStats(cfg *config.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("stats", out)
+	deckName := flags.String("deck", "", "only include this deck (name or ID)")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStorage(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	decks, err := store.LoadAllDecks()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var logs []*models.ReviewLog
+	if *deckName != "" {
+		deck, err := findDeck(decks, *deckName)
+		if err != nil {
+			return err
+		}
+		decks = []*models.Deck{deck}
+		logs, err = store.GetReviewLogsByDeck(deck.ID)
+		if err != nil {
+			return err
+		}
+	} else {
+		logs, err = store.GetReviewLogsInRange(time.Time{}, now.Add(24*time.Hour))
+		if err != nil {
+			return err
+		}
+	}
+
+	var cards []models.Card
+	info := statsInfo{}
+	for _, deck := range decks {
+		cards = append(cards, deck.Cards...)
+		_, _, due := deck.GetCardStats()
+		info.Due += due
+	}
+	info.Cards, info.Mature, info.Young, info.New = algorithms.CalculateRetentionStats(cards)
+	info.AverageEase = algorithms.AverageEase(cards)
+
+	perDay := algorithms.ReviewsPerDay(logs, now, 30, cfg.StudySession.DayRolloverHour)
+	for i, count := range perDay {
+		info.Reviews30d += count
+		if i >= len(perDay)-7 {
+			info.Reviews7d += count
+		}
+	}
+	info.ReviewsToday = perDay[len(perDay)-1]
+
+	passed, total := algorithms.TrueRetention(logs)
+	info.ReviewCount = total
+	if total > 0 {
+		info.Retention = float64(passed) / float64(total)
+	}
+
+	if *asJSON {
+		return printJSON(out, info)
+	}
+
+	fmt.Fprintf(out, "Cards:     %d (%d new, %d young, %d mature)\n", info.Cards, info.New, info.Young, info.Mature)
+	fmt.Fprintf(out, "Due:       %d\n", info.Due)
+	fmt.Fprintf(out, "Avg ease:  %.0f%%\n", info.AverageEase*100)
+	fmt.Fprintf(out, "Reviews:   %d today, %d in 7 days, %d in 30 days\n", info.ReviewsToday, info.Reviews7d, info.Reviews30d)
+	if total > 0 {
+		fmt.Fprintf(out, "Retention: %.1f%% (%d/%d)\n", info.Retention*100, passed, total)
+	} else {
+		fmt.Fprintln(out, "Retention: -")
+	}
+	return nil
+}
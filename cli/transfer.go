@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"anktui/algorithms"
+	"anktui/config"
+	"anktui/interop"
+	"anktui/models"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runImport imports an Anki package or a delimited text file
+func runImport(cfg *config.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("import", out)
+	deckName := flags.String("deck", "", "deck for .csv/.tsv rows (name or ID); created if missing, defaults to the file name")
+	duplicates := flags.String("duplicates", "skip", "rows matching an existing front: skip, update or duplicate")
+	asJSON := flags.Bool("json", false, "print JSON")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: anktui import <file.apkg|file.csv|file.tsv> [--deck name]")
+	}
+	path, err := config.ExpandPath(positional[0])
+	if err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStorage(cfg, true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if !interop.IsDelimitedPath(path) {
+		mediaDir, err := cfg.GetMediaDir()
+		if err != nil {
+			return err
+		}
+		result, err := interop.ImportAPKG(path, mediaDir, cfg.StudySession.DayRolloverHour)
+		if err != nil {
+			return err
+		}
+		for _, deck := range result.Decks {
+			if err := store.SaveDeck(deck); err != nil {
+				return err
+			}
+		}
+
+		if *asJSON {
+			names := make([]string, 0, len(result.Decks))
+			for _, deck := range result.Decks {
+				names = append(names, deck.Name)
+			}
+			return printJSON(out, map[string]any{
+				"decks":         names,
+				"notes":         result.NotesImported,
+				"cards":         result.CardsImported,
+				"media_files":   result.MediaFiles,
+				"skipped_notes": result.SkippedNotes(),
+				"skipped_cards": result.SkippedCards,
+				"warnings":      result.Warnings,
+			})
+		}
+		fmt.Fprintf(out, "Imported %d cards from %d notes into %d decks\n",
+			result.CardsImported, result.NotesImported, len(result.Decks))
+		if skipped := result.SkippedNotes(); skipped > 0 {
+			fmt.Fprintf(out, "Skipped %d notes with unsupported note types\n", skipped)
+		}
+		if result.SkippedCards > 0 {
+			fmt.Fprintf(out, "Skipped %d cards whose question was empty\n", result.SkippedCards)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(out, "Warning: %s\n", warning)
+		}
+		return nil
+	}
+
+	// Delimited text goes into one deck
+	policy, err := parseDuplicatePolicy(*duplicates)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	delimiter := interop.DelimiterForPath(path)
+	if delimiter == ',' {
+		delimiter = interop.DetectDelimiter(string(data))
+	}
+	rows, err := interop.ReadDelimited(strings.NewReader(string(data)), delimiter)
+	if err != nil {
+		return err
+	}
+
+	options := interop.DelimitedOptions{Delimiter: delimiter, Mapping: interop.DefaultColumnMapping(), Duplicates: policy}
+	if len(rows) > 0 {
+		if mapping, ok := interop.MappingFromHeader(rows[0]); ok {
+			options.HasHeader = true
+			options.Mapping = mapping
+		}
+	}
+
+	name := *deckName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	decks, err := store.LoadAllDecks()
+	if err != nil {
+		return err
+	}
+	deck, err := findDeck(decks, name)
+	if err != nil {
+		deck = models.NewDeck(name, "Imported from "+filepath.Base(path))
+	}
+
+	result := interop.ImportDelimited(deck, rows, options)
+	if result.Added > 0 || result.Updated > 0 {
+		deck.MarkModified()
+		if err := store.SaveDeck(deck); err != nil {
+			return err
+		}
+	}
+
+	if *asJSON {
+		return printJSON(out, map[string]any{
+			"deck":    deck.Name,
+			"added":   result.Added,
+			"updated": result.Updated,
+			"skipped": result.Skipped,
+			"errors":  result.Errors,
+		})
+	}
+	fmt.Fprintf(out, "Added %d, updated %d and skipped %d cards in %s\n", result.Added, result.Updated, result.Skipped, deck.Name)
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(out, "Error: %s\n", rowErr)
+	}
+	return nil
+}
+
+// parseDuplicatePolicy converts a --duplicates value to a policy
+func parseDuplicatePolicy(value string) (interop.DuplicatePolicy, error) {
+	switch strings.ToLower(value) {
+	case "skip":
+		return interop.DuplicateSkip, nil
+	case "update":
+		return interop.DuplicateUpdate, nil
+	case "duplicate", "add":
+		return interop.DuplicateAdd, nil
+	default:
+		return 0, fmt.Errorf("unknown duplicate policy %q (use skip, update or duplicate)", value)
+	}
+}
+
+// runExport exports decks to an Anki package or a delimited text file
+func runExport(cfg *config.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("export", out)
+	deckName := flags.String("deck", "", "export only this deck (name or ID)")
+	asJSON := flags.Bool("json", false, "print JSON")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: anktui export <file.apkg|file.csv|file.tsv> [--deck name]")
+	}
+	path, err := config.ExpandPath(positional[0])
+	if err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStorage(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	decks, err := store.LoadAllDecks()
+	if err != nil {
+		return err
+	}
+	if *deckName != "" {
+		deck, err := findDeck(decks, *deckName)
+		if err != nil {
+			return err
+		}
+		decks = []*models.Deck{deck}
+	}
+
+	var result *interop.ExportResult
+	if interop.IsDelimitedPath(path) {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer file.Close()
+
+		count, err := interop.ExportDelimited(file, decks, interop.DelimiterForPath(path))
+		if err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		result = &interop.ExportResult{Decks: len(decks), Cards: count}
+	} else {
+		var logs []*models.ReviewLog
+		for _, deck := range decks {
+			deckLogs, err := store.GetReviewLogsByDeck(deck.ID)
+			if err != nil {
+				return err
+			}
+			logs = append(logs, deckLogs...)
+		}
+		mediaDir, err := cfg.GetMediaDir()
+		if err != nil {
+			return err
+		}
+		learningSteps, err := algorithms.ParseSteps(cfg.Scheduler.LearningSteps)
+		if err != nil {
+			return err
+		}
+		relearningSteps, err := algorithms.ParseSteps(cfg.Scheduler.RelearningSteps)
+		if err != nil {
+			return err
+		}
+		if result, err = interop.ExportAPKG(path, decks, logs, mediaDir, cfg.StudySession.DayRolloverHour, learningSteps, relearningSteps); err != nil {
+			return err
+		}
+	}
+
+	if *asJSON {
+		return printJSON(out, map[string]any{
+			"path":        path,
+			"decks":       result.Decks,
+			"cards":       result.Cards,
+			"reviews":     result.Reviews,
+			"media_files": result.MediaFiles,
+		})
+	}
+	fmt.Fprintf(out, "Exported %d cards from %d decks to %s\n", result.Cards, result.Decks, path)
+	return nil
+}
@@ -6,20 +6,47 @@ import (
 	"path/filepath"
 )
 
+// Storage backends selectable with storage_backend
+const (
+	StorageJSON   = "json"
+	StorageSQLite = "sqlite"
+)
+
 type StudySessionConfig struct {
-	ShowProgress    bool `json:"show_progress"`
-	CardsPerSession int  `json:"cards_per_session"`
-	NewCardsPerDay  int  `json:"new_cards_per_day"`
+	ShowProgress    bool   `json:"show_progress"`
+	CardsPerSession int    `json:"cards_per_session"`
+	NewCardsPerDay  int    `json:"new_cards_per_day"`
+	ReviewsPerDay   int    `json:"reviews_per_day"`
+	DayRolloverHour int    `json:"day_rollover_hour"`   // Hour (0-23) at which daily limits reset
+	LearnAheadMins  int    `json:"learn_ahead_minutes"` // Show learning cards early when nothing else is left
+	LeechThreshold  int    `json:"leech_threshold"`     // Lapses after which a card is a leech, 0 to turn off
+	LeechAction     string `json:"leech_action"`        // "tag" to only tag leeches or "suspend" to also suspend them
+	ReviewOrder     string `json:"review_order"`        // "due", "deck", "random" or "overdue" when studying all decks at once
+}
+
+// SchedulerConfig holds the spaced repetition algorithm settings
+type SchedulerConfig struct {
+	Algorithm        string    `json:"algorithm"`         // "sm2" or "fsrs"
+	DesiredRetention float64   `json:"desired_retention"` // FSRS target recall probability
+	Weights          []float64 `json:"weights,omitempty"` // FSRS model parameters, defaults used when empty
+	MaximumInterval  int       `json:"maximum_interval"`  // FSRS interval cap in days
+	LearningSteps    []string  `json:"learning_steps"`    // Delays such as "1m" or "10m" before a new card graduates
+	RelearningSteps  []string  `json:"relearning_steps"`  // Delays before a forgotten card returns to day intervals
 }
 
 type Config struct {
 	DataDirectory     string             `json:"data_directory"`
+	StorageBackend    string             `json:"storage_backend"` // "json" or "sqlite"
 	AutoCreateDataDir bool               `json:"auto_create_data_dir"`
 	DefaultEaseFactor float64            `json:"default_ease_factor"`
 	Theme             string             `json:"theme"`
 	BackupEnabled     bool               `json:"backup_enabled"`
-	BackupDirectory   string             `json:"backup_directory"`
+	BackupDirectory   string             `json:"backup_directory"`   // Defaults to a backups folder in the data directory
+	BackupKeepDaily   int                `json:"backup_keep_daily"`  // Days of which the newest snapshot is kept
+	BackupKeepWeekly  int                `json:"backup_keep_weekly"` // Weeks of which the newest snapshot is kept
 	StudySession      StudySessionConfig `json:"study_session"`
+	Scheduler         SchedulerConfig    `json:"scheduler"`
+	DeckSchedulers    map[string]string  `json:"deck_schedulers,omitempty"` // Per-deck algorithm overrides keyed by deck ID
 }
 
 // DefaultConfig returns the default configuration
@@ -33,15 +60,31 @@ func DefaultConfig() *Config {
 
 	return &Config{
 		DataDirectory:     dataDir,
+		StorageBackend:    StorageJSON,
 		AutoCreateDataDir: true,
 		DefaultEaseFactor: 2.5,
 		Theme:             "default",
 		BackupEnabled:     false,
 		BackupDirectory:   "",
+		BackupKeepDaily:   7,
+		BackupKeepWeekly:  4,
 		StudySession: StudySessionConfig{
 			ShowProgress:    true,
 			CardsPerSession: 20,
 			NewCardsPerDay:  10,
+			ReviewsPerDay:   200,
+			DayRolloverHour: 4,
+			LearnAheadMins:  20,
+			LeechThreshold:  8,
+			LeechAction:     "tag",
+			ReviewOrder:     "due",
+		},
+		Scheduler: SchedulerConfig{
+			Algorithm:        "sm2",
+			DesiredRetention: 0.9,
+			MaximumInterval:  36500,
+			LearningSteps:    []string{"1m", "10m"},
+			RelearningSteps:  []string{"10m"},
 		},
 	}
 }
@@ -98,13 +141,13 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	// Parse the JSON
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+	// Parse the JSON over the defaults so settings added later have sensible values
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
 		return nil, err
 	}
 
-	return &config, nil
+	return config, nil
 }
 
 // SaveConfig saves the configuration to the config file
@@ -127,6 +170,14 @@ func (c *Config) SaveConfig() error {
 	return os.WriteFile(configPath, data, 0644)
 }
 
+// SchedulerFor returns the scheduling algorithm name to use for a deck
+func (c *Config) SchedulerFor(deckID string) string {
+	if name, ok := c.DeckSchedulers[deckID]; ok && name != "" {
+		return name
+	}
+	return c.Scheduler.Algorithm
+}
+
 // EnsureDataDir creates the data directory if it doesn't exist and auto-create is enabled
 func (c *Config) EnsureDataDir() error {
 	if !c.AutoCreateDataDir {
@@ -134,13 +185,9 @@ func (c *Config) EnsureDataDir() error {
 	}
 
 	// Expand tilde in path if present
-	dataDir := c.DataDirectory
-	if len(dataDir) > 0 && dataDir[0] == '~' {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		dataDir = filepath.Join(homeDir, dataDir[1:])
+	dataDir, err := ExpandPath(c.DataDirectory)
+	if err != nil {
+		return err
 	}
 
 	return os.MkdirAll(dataDir, 0755)
@@ -148,14 +195,39 @@ func (c *Config) EnsureDataDir() error {
 
 // GetExpandedDataDir returns the data directory with tilde expansion
 func (c *Config) GetExpandedDataDir() (string, error) {
-	dataDir := c.DataDirectory
-	if len(dataDir) > 0 && dataDir[0] == '~' {
+	return ExpandPath(c.DataDirectory)
+}
+
+// GetMediaDir returns the directory holding media files referenced by cards
+func (c *Config) GetMediaDir() (string, error) {
+	dataDir, err := c.GetExpandedDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "media"), nil
+}
+
+// GetBackupDir returns the directory holding data directory snapshots
+func (c *Config) GetBackupDir() (string, error) {
+	if c.BackupDirectory != "" {
+		return ExpandPath(c.BackupDirectory)
+	}
+	dataDir, err := c.GetExpandedDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "backups"), nil
+}
+
+// ExpandPath replaces a leading tilde in path with the user's home directory
+func ExpandPath(path string) (string, error) {
+	if len(path) > 0 && path[0] == '~' {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return "", err
 		}
-		dataDir = filepath.Join(homeDir, dataDir[1:])
+		path = filepath.Join(homeDir, path[1:])
 	}
 
-	return dataDir, nil
+	return path, nil
 }
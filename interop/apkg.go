@@ -0,0 +1,4 @@
+package interop
+
+// ankiFieldSeparator separates the field values of a note in the notes table
+const ankiFieldSeparator = "\x1f"
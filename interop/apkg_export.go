@@ -0,0 +1,620 @@
+package interop
+
+import (
+	"anktui/models"
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// ankiSchema creates an empty collection using the legacy (schema 11) layout
+// that every Anki client can open
+const ankiSchema = `
+CREATE TABLE col (
+	id integer primary key, crt integer not null, mod integer not null, scm integer not null,
+	ver integer not null, dty integer not null, usn integer not null, ls integer not null,
+	conf text not null, models text not null, decks text not null, dconf text not null, tags text not null
+);
+CREATE TABLE notes (
+	id integer primary key, guid text not null, mid integer not null, mod integer not null,
+	usn integer not null, tags text not null, flds text not null, sfld integer not null,
+	csum integer not null, flags integer not null, data text not null
+);
+CREATE TABLE cards (
+	id integer primary key, nid integer not null, did integer not null, ord integer not null,
+	mod integer not null, usn integer not null, type integer not null, queue integer not null,
+	due integer not null, ivl integer not null, factor integer not null, reps integer not null,
+	lapses integer not null, left integer not null, odue integer not null, odid integer not null,
+	flags integer not null, data text not null
+);
+CREATE TABLE revlog (
+	id integer primary key, cid integer not null, usn integer not null, ease integer not null,
+	ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null,
+	type integer not null
+);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+// Anki revlog entry types
+const (
+	ankiRevlogLearn   = 0
+	ankiRevlogReview  = 1
+	ankiRevlogRelearn = 2
+)
+
+// ankiCardCSS styles exported cards so rendered markdown stays readable
+const ankiCardCSS = `.card {
+  font-family: arial;
+  font-size: 20px;
+  text-align: left;
+  color: black;
+  background-color: white;
+}
+pre {
+  text-align: left;
+  padding: 8px;
+  background-color: #f3f4f6;
+  border-radius: 4px;
+}
+`
+
+// ExportResult summarises what an .apkg export wrote
+type ExportResult struct {
+	Decks      int
+	Cards      int
+	Reviews    int
+	MediaFiles int
+}
+
+// ExportAPKG writes decks to an Anki package at path, one Anki note per note with basic,
+// reversed and cloze note types matching anktui's. Cards of custom note types become basic
+// notes of their own. Card content is converted from markdown to HTML, SM-2 scheduling state is kept,
+// and the given review log entries become the package's review history.
+// Media files referenced by cards are looked up in mediaDir, due dates are counted in
+// study days starting at rolloverHour, and the learning and relearning steps become the
+// package's deck options.
+func ExportAPKG(path string, decks []*models.Deck, logs []*models.ReviewLog, mediaDir string, rolloverHour int, learningSteps, relearningSteps []time.Duration) (*ExportResult, error) {
+	dbFile, err := os.CreateTemp("", "anktui-export-*.anki2")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	dbPath := dbFile.Name()
+	dbFile.Close()
+	defer os.Remove(dbPath)
+
+	result, mediaFiles, err := writeCollection(dbPath, decks, logs, rolloverHour, learningSteps, relearningSteps)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package: %w", err)
+	}
+	defer out.Close()
+
+	archive := zip.NewWriter(out)
+	if err := addFileToZip(archive, "collection.anki2", dbPath); err != nil {
+		return nil, err
+	}
+
+	// Media files are stored as numbered entries described by the media map
+	mediaMap := make(map[string]string)
+	for _, name := range mediaFiles {
+		source := filepath.Join(mediaDir, name)
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		entry := strconv.Itoa(len(mediaMap))
+		if err := addFileToZip(archive, entry, source); err != nil {
+			return nil, err
+		}
+		mediaMap[entry] = name
+	}
+	result.MediaFiles = len(mediaMap)
+
+	mediaJSON, err := json.Marshal(mediaMap)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := archive.Create("media")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(mediaJSON); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write package: %w", err)
+	}
+	return result, out.Close()
+}
+
+// addFileToZip copies the file at source into the archive under name
+func addFileToZip(archive *zip.Writer, name, source string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// writeCollection fills a new collection database and returns the media files the cards reference
+func writeCollection(dbPath string, decks []*models.Deck, logs []*models.ReviewLog, rolloverHour int, learningSteps, relearningSteps []time.Duration) (*ExportResult, []string, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(ankiSchema); err != nil {
+		return nil, nil, fmt.Errorf("failed to create collection schema: %w", err)
+	}
+
+	now := time.Now()
+	ids := newAnkiIDs(now)
+	collectionCreated := collectionStart(decks, now, rolloverHour)
+	modelIDs := map[ankiExportModel]int64{
+		ankiExportBasic:    ids.next(),
+		ankiExportReversed: ids.next(),
+		ankiExportCloze:    ids.next(),
+	}
+
+	// Deck definitions, keeping Anki's mandatory default deck
+	ankiDecks := map[string]any{"1": ankiDeckJSON(1, "Default", "", now)}
+	deckIDs := make(map[string]int64, len(decks))
+	for _, deck := range decks {
+		id := ids.next()
+		deckIDs[deck.ID] = id
+		ankiDecks[strconv.FormatInt(id, 10)] = ankiDeckJSON(id, deck.Name, deck.Description, now)
+	}
+
+	ankiModels := make(map[string]any, len(modelIDs))
+	for model, id := range modelIDs {
+		ankiModels[strconv.FormatInt(id, 10)] = model.json(id, now)
+	}
+	colModels, _ := json.Marshal(ankiModels)
+	colDecks, _ := json.Marshal(ankiDecks)
+	colConf, _ := json.Marshal(ankiCollectionConfJSON(modelIDs[ankiExportBasic]))
+	newDelays, lapseDelays := ankiDelays(learningSteps), ankiDelays(relearningSteps)
+	colDeckConf, _ := json.Marshal(map[string]any{"1": ankiDeckConfJSON(newDelays, lapseDelays)})
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		collectionCreated.Unix(), now.UnixMilli(), now.UnixMilli(),
+		string(colConf), string(colModels), string(colDecks), string(colDeckConf)); err != nil {
+		return nil, nil, fmt.Errorf("failed to write collection metadata: %w", err)
+	}
+
+	result := &ExportResult{Decks: len(decks)}
+	cardIDs := make(map[string]int64)
+	reviewCounts := make(map[string]int)
+	for _, log := range logs {
+		reviewCounts[log.CardID]++
+	}
+
+	var mediaFiles []string
+	seenMedia := make(map[string]bool)
+	newPosition := 0
+
+	for _, deck := range decks {
+		// Anki note IDs of the notes already written, so siblings share them
+		noteIDs := make(map[string]int64)
+		for _, card := range deck.Cards {
+			cardID := ids.next()
+			cardIDs[card.ID] = cardID
+
+			model, key, fields, ord := ankiNoteOf(deck, &card)
+			noteID, written := noteIDs[key]
+			if !written {
+				noteID = ids.next()
+				noteIDs[key] = noteID
+				sortField := stripHTML(fields[0])
+				if _, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+					noteID, ankiGUID(), modelIDs[model], card.Modified.Unix(), ankiTags(card.Tags),
+					strings.Join(fields, ankiFieldSeparator), sortField, ankiChecksum(sortField)); err != nil {
+					return nil, nil, fmt.Errorf("failed to write note: %w", err)
+				}
+			}
+
+			// Map the scheduling state onto Anki's card types and queues
+			cardType, queue, due, interval, factor, left := ankiCardNew, ankiQueueNew, int64(0), 0, 0, 0
+			reps := card.Repetition
+			if count := reviewCounts[card.ID]; count > reps {
+				reps = count
+			}
+			dueDay := int64(max(models.StudyDaysBetween(collectionCreated, card.NextReview, rolloverHour), 0))
+			switch {
+			case card.IsNew():
+				newPosition++
+				due = int64(newPosition)
+			case card.InLearning():
+				cardType, interval, left = ankiCardLearning, 0, ankiLearningLeft(newDelays, card.Step)
+				if card.Learning == models.Relearning {
+					cardType, interval, left = ankiCardRelearning, max(card.Interval, 1), ankiLearningLeft(lapseDelays, card.Step)
+				}
+				factor = int(card.EaseFactor * 1000)
+				// Steps shorter than a day are due at a time, longer ones on a day
+				queue, due = ankiQueueLearning, card.NextReview.Unix()
+				if card.NextReview.Sub(card.LastReview) >= 24*time.Hour {
+					queue, due = ankiQueueDayLearning, dueDay
+				}
+			default:
+				cardType, queue, due = ankiCardReview, ankiQueueReview, dueDay
+				interval = max(card.Interval, 1)
+				factor = int(card.EaseFactor * 1000)
+			}
+			if card.Suspended {
+				queue = ankiQueueSuspended
+			} else if card.IsBuried(now) {
+				queue = ankiQueueUserBuried
+			}
+
+			if _, err := tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, ?, ?, -1, ?, ?, ?, ?, ?, ?, ?, ?, 0, 0, 0, '')`,
+				cardID, noteID, deckIDs[deck.ID], ord, card.Modified.Unix(),
+				cardType, queue, due, interval, factor, reps, card.Lapses, left); err != nil {
+				return nil, nil, fmt.Errorf("failed to write card: %w", err)
+			}
+			result.Cards++
+
+			for _, name := range referencedMedia(card.Front + "\n" + card.Back) {
+				if !seenMedia[name] {
+					seenMedia[name] = true
+					mediaFiles = append(mediaFiles, name)
+				}
+			}
+		}
+	}
+
+	// Review history for the exported cards
+	usedReviewIDs := make(map[int64]bool)
+	for _, log := range logs {
+		cardID, ok := cardIDs[log.CardID]
+		if !ok {
+			continue
+		}
+
+		// Review IDs are millisecond timestamps and must be unique
+		reviewID := log.Timestamp.UnixMilli()
+		for usedReviewIDs[reviewID] {
+			reviewID++
+		}
+		usedReviewIDs[reviewID] = true
+
+		reviewType := ankiRevlogReview
+		switch log.Type {
+		case models.ReviewTypeLearn:
+			reviewType = ankiRevlogLearn
+		case models.ReviewTypeRelearn:
+			reviewType = ankiRevlogRelearn
+		}
+		taken := min(log.TimeTaken.Milliseconds(), 60000)
+
+		// Anki stores learning step intervals as negative seconds
+		interval, lastInterval := log.NewInterval, log.PrevInterval
+		if log.NewDelay > 0 {
+			interval = -int(log.NewDelay.Seconds())
+		}
+		if log.PrevDelay > 0 {
+			lastInterval = -int(log.PrevDelay.Seconds())
+		}
+
+		if _, err := tx.Exec(`INSERT INTO revlog VALUES (?, ?, -1, ?, ?, ?, ?, ?, ?)`,
+			reviewID, cardID, int(log.Rating)+1, interval, lastInterval,
+			int(log.NewEase*1000), taken, reviewType); err != nil {
+			return nil, nil, fmt.Errorf("failed to write review log: %w", err)
+		}
+		result.Reviews++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to write collection: %w", err)
+	}
+
+	return result, mediaFiles, nil
+}
+
+// ankiLearningLeft returns Anki's left for a card on a learning step: the steps left to
+// graduate, both in total and today, in its thousands and last three digits
+func ankiLearningLeft(steps []float64, step int) int {
+	remaining := max(len(steps)-step, 1)
+	return remaining*1000 + remaining
+}
+
+// ankiDelays converts learning steps to Anki's step delays in minutes
+func ankiDelays(steps []time.Duration) []float64 {
+	delays := make([]float64, len(steps))
+	for i, step := range steps {
+		delays[i] = step.Minutes()
+	}
+	return delays
+}
+
+// ankiExportModel is one of the Anki note types cards are exported with
+type ankiExportModel int
+
+const (
+	ankiExportBasic ankiExportModel = iota
+	ankiExportReversed
+	ankiExportCloze
+)
+
+// Names of the exported note types. The importer recognises the reversed one
+const (
+	ankiBasicModelName    = "Basic (anktui)"
+	ankiReversedModelName = "Basic (and reversed card) (anktui)"
+	ankiClozeModelName    = "Cloze (anktui)"
+)
+
+// ankiNoteOf returns the Anki note type a card is exported with, a key shared by the cards
+// of the same note, the HTML fields of that note and the card's ord within it. Cards of
+// custom note types are exported as basic notes of their own, without the front their
+// templates repeat on the back
+func ankiNoteOf(deck *models.Deck, card *models.Card) (model ankiExportModel, key string, fields []string, ord int) {
+	note := deck.GetNote(card.NoteID)
+	switch {
+	case card.Type == models.ClozeCard:
+		key = card.NoteID
+		if key == "" {
+			key = card.ID
+		}
+		return ankiExportCloze, key, []string{markdownToHTML(card.Front), markdownToHTML(card.Back)}, max(card.Ord-1, 0)
+	case note != nil && note.Type == models.ReversedNote:
+		return ankiExportReversed, note.ID, []string{markdownToHTML(note.Front), markdownToHTML(note.Back)}, max(card.Ord-1, 0)
+	case note != nil && note.Type == models.BasicNote:
+		return ankiExportBasic, note.ID, []string{markdownToHTML(note.Front), markdownToHTML(note.Back)}, 0
+	default:
+		return ankiExportBasic, card.ID, []string{markdownToHTML(card.Question()), markdownToHTML(card.Answer())}, 0
+	}
+}
+
+// json returns the definition of the note type in the collection's models
+func (m ankiExportModel) json(id int64, now time.Time) map[string]any {
+	answer := "{{FrontSide}}\n\n<hr id=answer>\n\n"
+	switch m {
+	case ankiExportReversed:
+		return ankiModelJSON(id, ankiReversedModelName, ankiModelStandard, []string{"Front", "Back"}, [][2]string{
+			{"{{Front}}", answer + "{{Back}}"},
+			{"{{Back}}", answer + "{{Front}}"},
+		}, now)
+	case ankiExportCloze:
+		return ankiModelJSON(id, ankiClozeModelName, ankiModelCloze, []string{"Text", "Back Extra"}, [][2]string{
+			{"{{cloze:Text}}", "{{cloze:Text}}<br>\n{{Back Extra}}"},
+		}, now)
+	default:
+		return ankiModelJSON(id, ankiBasicModelName, ankiModelStandard, []string{"Front", "Back"}, [][2]string{
+			{"{{Front}}", answer + "{{Back}}"},
+		}, now)
+	}
+}
+
+// collectionStart picks a collection creation day no later than any card, so due days are never negative.
+// Like Anki's, the collection starts at the rollover hour
+func collectionStart(decks []*models.Deck, now time.Time, rolloverHour int) time.Time {
+	earliest := now
+	for _, deck := range decks {
+		for _, card := range deck.Cards {
+			if card.Created.Before(earliest) && !card.Created.IsZero() {
+				earliest = card.Created
+			}
+			if card.NextReview.Before(earliest) && !card.NextReview.IsZero() {
+				earliest = card.NextReview
+			}
+		}
+	}
+	return models.StudyDayStart(earliest, rolloverHour)
+}
+
+// ankiIDs hands out unique millisecond-style identifiers
+type ankiIDs struct {
+	last int64
+}
+
+func newAnkiIDs(now time.Time) *ankiIDs {
+	return &ankiIDs{last: now.UnixMilli()}
+}
+
+func (a *ankiIDs) next() int64 {
+	a.last++
+	return a.last
+}
+
+// ankiGUID returns a random note GUID
+func ankiGUID() string {
+	var buf [8]byte
+	rand.Read(buf[:])
+	return strconv.FormatUint(binary.BigEndian.Uint64(buf[:]), 36)
+}
+
+// ankiChecksum returns the note checksum Anki uses for duplicate detection
+func ankiChecksum(sortField string) int64 {
+	sum := sha1.Sum([]byte(sortField))
+	value, _ := strconv.ParseInt(fmt.Sprintf("%x", sum[:4]), 16, 64)
+	return value
+}
+
+// ankiTags formats tags the way Anki stores them, space separated with surrounding spaces
+func ankiTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " " + strings.Join(tags, " ") + " "
+}
+
+var markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)\)`)
+
+// referencedMedia returns local file names of images referenced by markdown
+func referencedMedia(markdown string) []string {
+	var names []string
+	for _, match := range markdownImagePattern.FindAllStringSubmatch(markdown, -1) {
+		if strings.Contains(match[1], "://") {
+			continue
+		}
+		names = append(names, filepath.Base(match[1]))
+	}
+	return names
+}
+
+var markdownRenderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithHardWraps()),
+)
+
+// markdownToHTML renders card markdown to the HTML stored in Anki note fields
+func markdownToHTML(markdown string) string {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(markdown), &buf); err != nil {
+		return markdown
+	}
+	rendered := strings.TrimSpace(buf.String())
+
+	// Drop the paragraph wrapper around single-line content
+	if strings.Count(rendered, "<p>") == 1 && strings.HasPrefix(rendered, "<p>") && strings.HasSuffix(rendered, "</p>") {
+		rendered = strings.TrimSuffix(strings.TrimPrefix(rendered, "<p>"), "</p>")
+	}
+	return rendered
+}
+
+func ankiCollectionConfJSON(modelID int64) map[string]any {
+	return map[string]any{
+		"activeDecks":   []int64{1},
+		"curDeck":       1,
+		"newSpread":     0,
+		"collapseTime":  1200,
+		"timeLim":       0,
+		"estTimes":      true,
+		"dueCounts":     true,
+		"curModel":      strconv.FormatInt(modelID, 10),
+		"nextPos":       1,
+		"sortType":      "noteFld",
+		"sortBackwards": false,
+		"addToCur":      true,
+	}
+}
+
+// ankiModelJSON describes a note type with the given fields and question and answer templates
+func ankiModelJSON(id int64, name string, modelType int, fieldNames []string, templates [][2]string, now time.Time) map[string]any {
+	var fields []any
+	for ord, name := range fieldNames {
+		fields = append(fields, map[string]any{
+			"name": name, "ord": ord, "font": "Arial", "size": 20,
+			"media": []string{}, "rtl": false, "sticky": false,
+		})
+	}
+	var tmpls, req []any
+	for ord, template := range templates {
+		tmpls = append(tmpls, map[string]any{
+			"name":  fmt.Sprintf("Card %d", ord+1),
+			"ord":   ord,
+			"qfmt":  template[0],
+			"afmt":  template[1],
+			"bqfmt": "",
+			"bafmt": "",
+			"did":   nil,
+		})
+		// Each card needs the field its question shows
+		req = append(req, []any{ord, "all", []int{ord}})
+	}
+
+	model := map[string]any{
+		"id":        id,
+		"name":      name,
+		"type":      modelType,
+		"mod":       now.Unix(),
+		"usn":       -1,
+		"sortf":     0,
+		"did":       1,
+		"tags":      []string{},
+		"vers":      []any{},
+		"css":       ankiCardCSS,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"latexsvg":  false,
+		"flds":      fields,
+		"tmpls":     tmpls,
+	}
+	if modelType == ankiModelStandard {
+		model["req"] = req
+	}
+	return model
+}
+
+func ankiDeckJSON(id int64, name, description string, now time.Time) map[string]any {
+	return map[string]any{
+		"id":               id,
+		"name":             name,
+		"desc":             description,
+		"mod":              now.Unix(),
+		"usn":              -1,
+		"conf":             1,
+		"dyn":              0,
+		"collapsed":        false,
+		"browserCollapsed": false,
+		"extendNew":        0,
+		"extendRev":        50,
+		"newToday":         []int{0, 0},
+		"revToday":         []int{0, 0},
+		"lrnToday":         []int{0, 0},
+		"timeToday":        []int{0, 0},
+	}
+}
+
+func ankiDeckConfJSON(newDelays, lapseDelays []float64) map[string]any {
+	return map[string]any{
+		"id":       1,
+		"name":     "Default",
+		"mod":      0,
+		"usn":      0,
+		"maxTaken": 60,
+		"autoplay": true,
+		"timer":    0,
+		"replayq":  true,
+		"new": map[string]any{
+			"bury": true, "delays": newDelays, "initialFactor": 2500,
+			"ints": []int{1, 4, 7}, "order": 1, "perDay": 20, "separate": true,
+		},
+		"rev": map[string]any{
+			"bury": true, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1,
+			"maxIvl": 36500, "minSpace": 1, "perDay": 100,
+		},
+		"lapse": map[string]any{
+			"delays": lapseDelays, "leechAction": 0, "leechFails": 8, "minInt": 1, "mult": 0,
+		},
+	}
+}
@@ -0,0 +1,532 @@
+package interop
+
+import (
+	"anktui/models"
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver registered as "sqlite"
+)
+
+// Anki note type kinds as stored in the collection's models JSON
+const (
+	ankiModelStandard = 0
+	ankiModelCloze    = 1
+)
+
+// Anki card types as stored in the cards table
+const (
+	ankiCardNew        = 0
+	ankiCardLearning   = 1
+	ankiCardReview     = 2
+	ankiCardRelearning = 3
+)
+
+// Anki card queues as stored in the cards table
+const (
+	ankiQueueUserBuried  = -3
+	ankiQueueSchedBuried = -2 // Buried automatically as a sibling
+	ankiQueueSuspended   = -1
+	ankiQueueNew         = 0
+	ankiQueueLearning    = 1 // Learning steps due within a day, at a timestamp
+	ankiQueueReview      = 2
+	ankiQueueDayLearning = 3 // Learning steps of a day or more, due on a day
+)
+
+// Anki's default learning and relearning steps in minutes, for decks without options
+var (
+	ankiDefaultLearningSteps   = []float64{1, 10}
+	ankiDefaultRelearningSteps = []float64{10}
+)
+
+// ImportResult summarises what an .apkg import produced
+type ImportResult struct {
+	Decks            []*models.Deck
+	NotesImported    int
+	CardsImported    int
+	MediaFiles       int
+	SkippedNoteTypes map[string]int // Notes skipped, keyed by note type name
+	SkippedCards     int            // Cards of imported note types whose question rendered empty
+	Warnings         []string
+}
+
+// SkippedNotes returns the total number of notes that were not imported
+func (r *ImportResult) SkippedNotes() int {
+	total := 0
+	for _, count := range r.SkippedNoteTypes {
+		total += count
+	}
+	return total
+}
+
+// ankiModel is the subset of an Anki note type definition used by the importer
+type ankiModel struct {
+	Name   string `json:"name"`
+	Type   int    `json:"type"`
+	Fields []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+	} `json:"flds"`
+	Templates []struct {
+		Name     string `json:"name"`
+		Ord      int    `json:"ord"`
+		Question string `json:"qfmt"`
+		Answer   string `json:"afmt"`
+	} `json:"tmpls"`
+}
+
+// ankiDeck is the subset of an Anki deck definition used by the importer
+type ankiDeck struct {
+	Name    string `json:"name"`
+	Dynamic int    `json:"dyn"`
+	Conf    int64  `json:"conf"` // Options group in the collection's dconf
+}
+
+// ankiDeckConf is the subset of an Anki deck options group used by the importer
+type ankiDeckConf struct {
+	New struct {
+		Delays []float64 `json:"delays"`
+	} `json:"new"`
+	Lapse struct {
+		Delays []float64 `json:"delays"`
+	} `json:"lapse"`
+}
+
+// ankiNote is a row of the notes table
+type ankiNote struct {
+	ID      int64
+	ModelID int64
+	Fields  []string
+	Tags    []string
+}
+
+// ImportAPKG reads an Anki package and converts its notes into decks of cards.
+// Media files are copied into mediaDir. Cloze notes become cloze cards, and notes whose
+// type is missing from the package are skipped and reported in the result. Buried cards
+// stay buried until the study day starting at rolloverHour.
+func ImportAPKG(path, mediaDir string, rolloverHour int) (*ImportResult, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	// Prefer the newest legacy schema; the zstd-compressed anki21b format is not supported
+	collection := files["collection.anki21"]
+	if collection == nil {
+		if files["collection.anki21b"] != nil {
+			return nil, errors.New("package uses the Anki 2.1.50+ format; re-export it with \"Support older Anki versions\" enabled")
+		}
+		collection = files["collection.anki2"]
+	}
+	if collection == nil {
+		return nil, errors.New("package does not contain an Anki collection")
+	}
+
+	dbPath, err := extractToTemp(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dbPath)
+
+	result, err := importCollection(dbPath, rolloverHour)
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaFile := files["media"]; mediaFile != nil {
+		count, err := importMedia(files, mediaFile, mediaDir)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("media not imported: %v", err))
+		}
+		result.MediaFiles = count
+	}
+
+	return result, nil
+}
+
+// extractToTemp copies a zip entry to a temporary file and returns its path
+func extractToTemp(file *zip.File) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read collection: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "anktui-import-*.anki2")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to extract collection: %w", err)
+	}
+
+	return dst.Name(), nil
+}
+
+// importCollection converts the notes and cards of an Anki collection database
+func importCollection(dbPath string, rolloverHour int) (*ImportResult, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collection: %w", err)
+	}
+	defer db.Close()
+
+	// Collection metadata
+	var created int64
+	var modelsJSON, decksJSON, deckConfJSON string
+	if err := db.QueryRow("SELECT crt, models, decks, dconf FROM col").Scan(&created, &modelsJSON, &decksJSON, &deckConfJSON); err != nil {
+		return nil, fmt.Errorf("failed to read collection metadata: %w", err)
+	}
+	collectionCreated := time.Unix(created, 0)
+
+	var ankiModels map[string]ankiModel
+	if err := json.Unmarshal([]byte(modelsJSON), &ankiModels); err != nil {
+		return nil, fmt.Errorf("failed to parse note types: %w", err)
+	}
+	var ankiDecks map[string]ankiDeck
+	if err := json.Unmarshal([]byte(decksJSON), &ankiDecks); err != nil {
+		return nil, fmt.Errorf("failed to parse decks: %w", err)
+	}
+	// Deck options only give learning cards their step, so unreadable ones fall back to defaults
+	var ankiDeckConfs map[string]ankiDeckConf
+	json.Unmarshal([]byte(deckConfJSON), &ankiDeckConfs)
+
+	notes, err := readNotes(db)
+	if err != nil {
+		return nil, err
+	}
+	lastReviews, err := readLastReviews(db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{SkippedNoteTypes: make(map[string]int)}
+
+	// Skip notes whose type cannot be mapped, counting each note once
+	skippedNotes := make(map[int64]bool)
+	for _, note := range notes {
+		if _, ok := ankiModels[fmt.Sprint(note.ModelID)]; !ok {
+			result.SkippedNoteTypes["(missing note type)"]++
+			skippedNotes[note.ID] = true
+		}
+	}
+
+	rows, err := db.Query(`SELECT id, nid, did, odid, ord, mod, type, queue, due, odue, ivl, factor, reps, lapses, left
+		FROM cards ORDER BY did, due, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cards: %w", err)
+	}
+	defer rows.Close()
+
+	decksByID := make(map[int64]*models.Deck)
+	importedNotes := make(map[int64]bool)
+	noteIDs := make(map[int64]string) // Note IDs shared by the cards of each cloze or multi-card note
+	now := time.Now()
+
+	for rows.Next() {
+		var id, noteID, deckID, originalDeckID, modified, due, originalDue int64
+		var ord, cardType, queue, interval, factor, reps, lapses, left int
+		if err := rows.Scan(&id, &noteID, &deckID, &originalDeckID, &ord, &modified, &cardType, &queue, &due, &originalDue, &interval, &factor, &reps, &lapses, &left); err != nil {
+			return nil, fmt.Errorf("failed to read card: %w", err)
+		}
+
+		note, ok := notes[noteID]
+		if !ok || skippedNotes[noteID] {
+			continue
+		}
+		model := ankiModels[fmt.Sprint(note.ModelID)]
+
+		var front, back string
+		if model.Type == ankiModelCloze {
+			front, back, ok = renderAnkiCloze(note)
+		} else {
+			front, back, ok = renderAnkiCard(model, note, ord)
+		}
+		if !ok {
+			result.SkippedCards++
+			continue
+		}
+
+		// Cards in filtered decks belong to their original deck, where they are due on their
+		// original due date rather than at their position in the filtered deck
+		if originalDeckID != 0 {
+			deckID, due = originalDeckID, originalDue
+		}
+		deck, ok := decksByID[deckID]
+		if !ok {
+			name := ankiDecks[fmt.Sprint(deckID)].Name
+			if name == "" {
+				name = "Imported"
+			}
+			deck = models.NewDeck(name, "Imported from Anki")
+			decksByID[deckID] = deck
+		}
+
+		card := models.NewCard(front, back)
+		card.Created = time.UnixMilli(note.ID)
+		card.Tags = slices.Clone(note.Tags)
+		if model.Type == ankiModelCloze || len(model.Templates) > 1 {
+			// Siblings share a note. Anki numbers cards from ord 0, so c1 is ord 0
+			if noteIDs[noteID] == "" {
+				noteIDs[noteID] = uuid.New().String()
+			}
+			card.NoteID = noteIDs[noteID]
+			card.Ord = ord + 1
+		}
+		if model.Type == ankiModelCloze {
+			card.Type = models.ClozeCard
+		} else if len(model.Templates) > 1 && deck.GetNote(card.NoteID) == nil {
+			// The cards were rendered from templates anktui does not have, so the note keeps
+			// them as they are rather than regenerating them as a basic note. Reversed notes
+			// exported by anktui come back as reversed notes
+			ankiNote := models.NewNote(models.ImportedNoteType(model.Name), front, back)
+			if model.Name == ankiReversedModelName && len(note.Fields) == 2 {
+				ankiNote = models.NewNote(models.ReversedNote, htmlToMarkdown(note.Fields[0]), htmlToMarkdown(note.Fields[1]))
+			}
+			ankiNote.ID = card.NoteID
+			ankiNote.Created = card.Created
+			deck.Notes = append(deck.Notes, *ankiNote)
+		}
+		card.Modified = time.Unix(modified, 0)
+		card.Lapses = lapses
+		if lastReview, ok := lastReviews[id]; ok {
+			card.LastReview = lastReview
+		}
+
+		// Preserve scheduling state
+		switch cardType {
+		case ankiCardReview:
+			card.Interval = max(interval, 1)
+			card.Repetition = max(reps, 1)
+			card.EaseFactor = ankiEase(factor)
+			card.NextReview = collectionCreated.AddDate(0, 0, int(due))
+		case ankiCardLearning, ankiCardRelearning:
+			card.EaseFactor = ankiEase(factor)
+			card.NextReview = time.Unix(due, 0)
+			if queue == ankiQueueDayLearning {
+				card.NextReview = collectionCreated.AddDate(0, 0, int(due))
+			}
+			card.Learning = models.Learning
+			steps := ankiDefaultLearningSteps
+			conf, hasConf := ankiDeckConfs[fmt.Sprint(ankiDecks[fmt.Sprint(deckID)].Conf)]
+			if hasConf {
+				steps = conf.New.Delays
+			}
+			if cardType == ankiCardRelearning {
+				// Only forgotten review cards have an interval to return to
+				card.Learning = models.Relearning
+				card.Interval = max(interval, 1)
+				card.Repetition = max(reps, 1)
+				steps = ankiDefaultRelearningSteps
+				if hasConf {
+					steps = conf.Lapse.Delays
+				}
+			}
+			card.Step = ankiLearningStep(steps, left)
+		default:
+			card.NextReview = now
+		}
+
+		switch queue {
+		case ankiQueueSuspended:
+			card.Suspended = true
+		case ankiQueueSchedBuried, ankiQueueUserBuried:
+			card.BuriedUntil = models.NextRollover(now, rolloverHour)
+		}
+
+		deck.Cards = append(deck.Cards, *card)
+		importedNotes[noteID] = true
+		result.CardsImported++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cards: %w", err)
+	}
+
+	result.NotesImported = len(importedNotes)
+	for _, deck := range decksByID {
+		result.Decks = append(result.Decks, deck)
+	}
+	sort.Slice(result.Decks, func(i, j int) bool {
+		return result.Decks[i].Name < result.Decks[j].Name
+	})
+
+	return result, nil
+}
+
+// ankiLearningStep returns the index of the step a learning card is on. Anki keeps the
+// number of steps left in the last three digits of left
+func ankiLearningStep(steps []float64, left int) int {
+	if len(steps) == 0 {
+		return 0
+	}
+	return min(max(len(steps)-left%1000, 0), len(steps)-1)
+}
+
+// readNotes loads every note in the collection keyed by note ID
+func readNotes(db *sql.DB) (map[int64]*ankiNote, error) {
+	rows, err := db.Query("SELECT id, mid, flds, tags FROM notes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make(map[int64]*ankiNote)
+	for rows.Next() {
+		var note ankiNote
+		var fields, tags string
+		if err := rows.Scan(&note.ID, &note.ModelID, &fields, &tags); err != nil {
+			return nil, fmt.Errorf("failed to read note: %w", err)
+		}
+		note.Fields = strings.Split(fields, ankiFieldSeparator)
+		note.Tags = strings.Fields(tags)
+		notes[note.ID] = &note
+	}
+
+	return notes, rows.Err()
+}
+
+// readLastReviews returns the time of the most recent review of each card
+func readLastReviews(db *sql.DB) (map[int64]time.Time, error) {
+	rows, err := db.Query("SELECT cid, MAX(id) FROM revlog GROUP BY cid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	defer rows.Close()
+
+	lastReviews := make(map[int64]time.Time)
+	for rows.Next() {
+		var cardID, reviewID int64
+		if err := rows.Scan(&cardID, &reviewID); err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+		// Review IDs are millisecond timestamps
+		lastReviews[cardID] = time.UnixMilli(reviewID)
+	}
+
+	return lastReviews, rows.Err()
+}
+
+// importMedia copies the files listed in the package's media map into mediaDir
+func importMedia(files map[string]*zip.File, mediaFile *zip.File, mediaDir string) (int, error) {
+	reader, err := mediaFile.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	// The media map associates numbered zip entries with their original file names
+	var mediaMap map[string]string
+	if err := json.NewDecoder(reader).Decode(&mediaMap); err != nil {
+		return 0, fmt.Errorf("unsupported media map: %w", err)
+	}
+	if len(mediaMap) == 0 {
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(mediaDir, 0755); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for entry, name := range mediaMap {
+		file := files[entry]
+		if file == nil {
+			continue
+		}
+		if err := copyZipEntry(file, filepath.Join(mediaDir, filepath.Base(name))); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// copyZipEntry writes the contents of a zip entry to dest
+func copyZipEntry(file *zip.File, dest string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// ankiEase converts Anki's permille ease factor, using the default for unset values
+func ankiEase(factor int) float64 {
+	if factor <= 0 {
+		return 2.5
+	}
+	return float64(factor) / 1000
+}
+
+// ankiAnswerRule is the rule Anki puts between the repeated question and the answer
+var ankiAnswerRule = regexp.MustCompile(`(?i)<hr[^>]*id\s*=\s*["']?answer["']?[^>]*>`)
+
+// renderAnkiCard renders the question and answer of a standard note's card template as markdown
+func renderAnkiCard(model ankiModel, note *ankiNote, ord int) (front, back string, ok bool) {
+	fields := make(map[string]string, len(model.Fields))
+	for _, field := range model.Fields {
+		if field.Ord < len(note.Fields) {
+			fields[field.Name] = note.Fields[field.Ord]
+		}
+	}
+
+	for _, tmpl := range model.Templates {
+		if tmpl.Ord != ord {
+			continue
+		}
+
+		front = htmlToMarkdown(models.RenderTemplate(tmpl.Question, fields))
+
+		// The answer usually repeats the question; keep only what follows it
+		answer := strings.ReplaceAll(tmpl.Answer, "{{FrontSide}}", "")
+		answer = ankiAnswerRule.ReplaceAllString(answer, "")
+		back = htmlToMarkdown(models.RenderTemplate(answer, fields))
+
+		return front, back, front != ""
+	}
+
+	return "", "", false
+}
+
+// renderAnkiCloze returns the cloze text and extra field of a cloze note
+func renderAnkiCloze(note *ankiNote) (text, extra string, ok bool) {
+	if len(note.Fields) == 0 {
+		return "", "", false
+	}
+	text = htmlToMarkdown(note.Fields[0])
+	if len(note.Fields) > 1 {
+		extra = htmlToMarkdown(note.Fields[1])
+	}
+	return text, extra, models.HasCloze(text)
+}
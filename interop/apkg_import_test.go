@@ -0,0 +1,253 @@
+package interop
+
+import (
+	"anktui/models"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAnkiCard is a row of the cards table of a test collection
+type testAnkiCard struct {
+	ord, cardType, queue, due, ivl, left int
+	did, odid, odue                      int // Deck 1 unless did is set
+}
+
+// writeTestCollection creates a collection holding one note of a two-template note type
+// with the given cards, and returns its path
+func writeTestCollection(t *testing.T, fields []string, tags string, cards []testAnkiCard) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ankiModels, _ := json.Marshal(map[string]any{
+		"10": map[string]any{
+			"name": "Vocab",
+			"type": ankiModelStandard,
+			"flds": []map[string]any{{"name": "Word", "ord": 0}, {"name": "Meaning", "ord": 1}},
+			"tmpls": []map[string]any{
+				{"name": "Recognise", "ord": 0, "qfmt": "{{Word}}", "afmt": "{{FrontSide}}<hr id=answer>{{Meaning}}"},
+				{"name": "Recall", "ord": 1, "qfmt": "{{Meaning}}", "afmt": "{{FrontSide}}<hr id=answer>{{Word}}"},
+			},
+		},
+	})
+	ankiDecks, _ := json.Marshal(map[string]any{"1": map[string]any{"name": "Spanish", "dyn": 0, "conf": 1}})
+	ankiDeckConfs, _ := json.Marshal(map[string]any{
+		"1": map[string]any{"new": map[string]any{"delays": []float64{1, 10, 60}}, "lapse": map[string]any{"delays": []float64{10, 60}}},
+	})
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{ankiSchema, nil},
+		{`INSERT INTO col VALUES (1, 0, 0, 0, 11, 0, 0, 0, '{}', ?, ?, ?, '{}')`, []any{string(ankiModels), string(ankiDecks), string(ankiDeckConfs)}},
+		{`INSERT INTO notes VALUES (100, 'guid', 10, 0, 0, ?, ?, '', 0, 0, '')`, []any{tags, strings.Join(fields, ankiFieldSeparator)}},
+	}
+	for i, card := range cards {
+		deckID := card.did
+		if deckID == 0 {
+			deckID = 1
+		}
+		statements = append(statements, struct {
+			query string
+			args  []any
+		}{
+			`INSERT INTO cards VALUES (?, 100, ?, ?, 0, 0, ?, ?, ?, ?, 2500, 1, 0, ?, ?, ?, 0, '')`,
+			[]any{200 + i, deckID, card.ord, card.cardType, card.queue, card.due, card.ivl, card.left, card.odue, card.odid},
+		})
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement.query, statement.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestImportedNoteEditing(t *testing.T) {
+	path := writeTestCollection(t, []string{"perro", "dog"}, "animal", []testAnkiCard{
+		{ord: 0, cardType: ankiCardReview, queue: 2, due: 10, ivl: 5},
+		{ord: 1},
+	})
+	result, err := importCollection(path, 4)
+	if err != nil {
+		t.Fatalf("importCollection failed: %v", err)
+	}
+	if len(result.Decks) != 1 || len(result.Decks[0].Cards) != 2 {
+		t.Fatalf("imported %d decks, want one deck of two cards", len(result.Decks))
+	}
+	deck := result.Decks[0]
+	recognise, recall := deck.Cards[0], deck.Cards[1]
+	if recognise.Ord != 1 {
+		recognise, recall = recall, recognise
+	}
+	note := deck.GetNote(recall.NoteID)
+	if note == nil || !note.Type.IsImported() || recognise.NoteID != recall.NoteID {
+		t.Fatalf("cards do not share an imported note")
+	}
+
+	// Regenerating the note as a basic one is refused, and the card is edited on its own
+	edited := *note
+	edited.Type, edited.Front, edited.Back = models.BasicNote, "hound", "perro"
+	deck.UpdateNote(&edited, nil)
+	deck.UpdateCardContent(recall.ID, "hound", "perro")
+
+	if len(deck.Cards) != 2 {
+		t.Fatalf("deck has %d cards after the edit, want 2", len(deck.Cards))
+	}
+	if got := deck.GetCard(recognise.ID); got == nil || got.Front != recognise.Front || got.Interval != 5 {
+		t.Errorf("sibling changed to %+v", got)
+	}
+	if got := deck.GetCard(recall.ID); got == nil || got.Front != "hound" || got.Back != "perro" {
+		t.Errorf("edited card is %+v", got)
+	}
+	if note := deck.GetNote(recall.NoteID); note.Type != models.ImportedNoteType("Vocab") {
+		t.Errorf("note type changed to %q", note.Type)
+	}
+}
+
+func TestImportCardState(t *testing.T) {
+	tests := []struct {
+		name          string
+		card          testAnkiCard
+		wantLearning  models.LearningState
+		wantStep      int
+		wantSuspended bool
+		wantBuried    bool
+	}{
+		{"new", testAnkiCard{}, models.NotLearning, 0, false, false},
+		{"suspended review", testAnkiCard{cardType: ankiCardReview, queue: ankiQueueSuspended, due: 10, ivl: 5}, models.NotLearning, 0, true, false},
+		{"suspended new", testAnkiCard{queue: ankiQueueSuspended}, models.NotLearning, 0, true, false},
+		{"buried as a sibling", testAnkiCard{cardType: ankiCardReview, queue: ankiQueueSchedBuried, due: 10, ivl: 5}, models.NotLearning, 0, false, true},
+		{"buried by the user", testAnkiCard{queue: ankiQueueUserBuried}, models.NotLearning, 0, false, true},
+		{"first learning step", testAnkiCard{cardType: ankiCardLearning, queue: 1, due: 1700000000, left: 3003}, models.Learning, 0, false, false},
+		{"second learning step", testAnkiCard{cardType: ankiCardLearning, queue: 1, due: 1700000000, left: 2002}, models.Learning, 1, false, false},
+		{"last learning step", testAnkiCard{cardType: ankiCardLearning, queue: 1, due: 1700000000, left: 1001}, models.Learning, 2, false, false},
+		{"second relearning step", testAnkiCard{cardType: ankiCardRelearning, queue: 1, due: 1700000000, ivl: 5, left: 1001}, models.Relearning, 1, false, false},
+		{"more steps left than the deck has", testAnkiCard{cardType: ankiCardRelearning, queue: 1, due: 1700000000, ivl: 5, left: 5}, models.Relearning, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := importCollection(writeTestCollection(t, []string{"perro", "dog"}, "animal noun", []testAnkiCard{tt.card, {ord: 1}}), 4)
+			if err != nil {
+				t.Fatalf("importCollection failed: %v", err)
+			}
+			deck := result.Decks[0]
+			card, sibling := &deck.Cards[0], &deck.Cards[1]
+			if card.Ord != 1 {
+				card, sibling = sibling, card
+			}
+
+			if card.Learning != tt.wantLearning || card.Step != tt.wantStep {
+				t.Errorf("learning = %q step %d, want %q step %d", card.Learning, card.Step, tt.wantLearning, tt.wantStep)
+			}
+			if card.Suspended != tt.wantSuspended {
+				t.Errorf("suspended = %v, want %v", card.Suspended, tt.wantSuspended)
+			}
+			if buried := card.IsBuried(time.Now()); buried != tt.wantBuried {
+				t.Errorf("buried = %v, want %v", buried, tt.wantBuried)
+			}
+			if sibling.Suspended || sibling.IsBuried(time.Now()) {
+				t.Errorf("sibling was suspended or buried too")
+			}
+
+			// Each card gets its own copy of the note's tags
+			card.Tags[0] = "changed"
+			if sibling.Tags[0] != "animal" {
+				t.Errorf("sibling tags changed to %q with the card's", sibling.Tags)
+			}
+		})
+	}
+}
+
+func TestImportFilteredDeckCard(t *testing.T) {
+	created := time.Unix(0, 0) // The test collection's creation time
+	tests := []struct {
+		name string
+		card testAnkiCard
+		want time.Time
+	}{
+		{"review card", testAnkiCard{cardType: ankiCardReview, queue: ankiQueueReview, due: -100000, ivl: 5, did: 2, odid: 1, odue: 10}, created.AddDate(0, 0, 10)},
+		{"learning card", testAnkiCard{cardType: ankiCardLearning, queue: ankiQueueLearning, due: -100000, left: 1001, did: 2, odid: 1, odue: 1700000000}, time.Unix(1700000000, 0)},
+		{"day learning card", testAnkiCard{cardType: ankiCardLearning, queue: ankiQueueDayLearning, due: -100000, left: 1001, did: 2, odid: 1, odue: 12}, created.AddDate(0, 0, 12)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := importCollection(writeTestCollection(t, []string{"perro", "dog"}, "", []testAnkiCard{tt.card}), 4)
+			if err != nil {
+				t.Fatalf("importCollection failed: %v", err)
+			}
+			if len(result.Decks) != 1 || result.Decks[0].Name != "Spanish" {
+				t.Fatalf("imported %d decks, want the card in its home deck", len(result.Decks))
+			}
+			if got := result.Decks[0].Cards[0].NextReview; !got.Equal(tt.want) {
+				t.Errorf("due %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLearningCardRoundTrip(t *testing.T) {
+	now := time.Now()
+	learningSteps := []time.Duration{time.Minute, 10 * time.Minute, 48 * time.Hour}
+	relearningSteps := []time.Duration{10 * time.Minute, 72 * time.Hour}
+	tests := []struct {
+		name     string
+		learning models.LearningState
+		step     int
+		wantDays int // Study days from now until the imported card is due
+	}{
+		{"learning step of minutes", models.Learning, 1, 0},
+		{"learning step of days", models.Learning, 2, 2},
+		{"relearning step of minutes", models.Relearning, 0, 0},
+		{"relearning step of days", models.Relearning, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := models.NewDeck("Spanish", "")
+			card := models.NewCard("perro", "dog")
+			card.Learning = tt.learning
+			card.Step = tt.step
+			card.LastReview = now
+			card.NextReview = now.Add(learningSteps[tt.step])
+			if tt.learning == models.Relearning {
+				card.Interval, card.Repetition, card.Lapses = 5, 3, 1
+				card.NextReview = now.Add(relearningSteps[tt.step])
+			}
+			deck.Cards = append(deck.Cards, *card)
+
+			path := filepath.Join(t.TempDir(), "collection.anki2")
+			if _, _, err := writeCollection(path, []*models.Deck{deck}, nil, 4, learningSteps, relearningSteps); err != nil {
+				t.Fatalf("writeCollection failed: %v", err)
+			}
+			result, err := importCollection(path, 4)
+			if err != nil {
+				t.Fatalf("importCollection failed: %v", err)
+			}
+			got := result.Decks[0].Cards[0]
+
+			if got.Learning != tt.learning || got.Step != tt.step {
+				t.Errorf("learning = %q step %d, want %q step %d", got.Learning, got.Step, tt.learning, tt.step)
+			}
+			if days := models.StudyDaysBetween(now, got.NextReview, 4); days != tt.wantDays {
+				t.Errorf("due in %d study days (%v), want %d", days, got.NextReview, tt.wantDays)
+			}
+			// Cards learning for the first time have no day interval to graduate from
+			wantInterval, wantRepetition := 1, 0
+			if tt.learning == models.Relearning {
+				wantInterval, wantRepetition = 5, 3
+			}
+			if got.Interval != wantInterval || got.Repetition != wantRepetition {
+				t.Errorf("interval %d repetition %d, want %d and %d", got.Interval, got.Repetition, wantInterval, wantRepetition)
+			}
+		})
+	}
+}
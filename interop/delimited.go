@@ -0,0 +1,398 @@
+package interop
+
+import (
+	"anktui/models"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delimiters offered for delimited text import and export
+var Delimiters = []rune{',', '\t', ';', '|'}
+
+// DelimiterName returns a readable name for a delimiter
+func DelimiterName(delimiter rune) string {
+	switch delimiter {
+	case ',':
+		return "Comma"
+	case '\t':
+		return "Tab"
+	case ';':
+		return "Semicolon"
+	case '|':
+		return "Pipe"
+	default:
+		return string(delimiter)
+	}
+}
+
+// DelimiterForPath guesses the delimiter from a file extension, defaulting to comma
+func DelimiterForPath(path string) rune {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".tsv") || strings.HasSuffix(lower, ".tab") {
+		return '\t'
+	}
+	return ','
+}
+
+// IsDelimitedPath reports whether a file extension indicates delimited text
+func IsDelimitedPath(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{".csv", ".tsv", ".tab", ".txt"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectDelimiter picks the delimiter that appears most often in the first line of text
+func DetectDelimiter(text string) rune {
+	firstLine, _, _ := strings.Cut(text, "\n")
+	best, bestCount := ',', 0
+	for _, delimiter := range Delimiters {
+		if count := strings.Count(firstLine, string(delimiter)); count > bestCount {
+			best, bestCount = delimiter, count
+		}
+	}
+	return best
+}
+
+// Unmapped marks a card field that is not read from any column
+const Unmapped = -1
+
+// ColumnMapping assigns column indexes to card fields
+type ColumnMapping struct {
+	Front    int
+	Back     int
+	Tags     int
+	Interval int // Days
+	Ease     int // Ease factor as 2.5, 250% or Anki's 2500
+	Due      int // Next review date
+}
+
+// DefaultColumnMapping maps the first two columns to front and back
+func DefaultColumnMapping() ColumnMapping {
+	return ColumnMapping{Front: 0, Back: 1, Tags: Unmapped, Interval: Unmapped, Ease: Unmapped, Due: Unmapped}
+}
+
+// MappingFromHeader maps columns by recognised header names, reporting whether the header
+// named both a front and a back column. Unrecognised headers yield the default mapping
+func MappingFromHeader(header []string) (ColumnMapping, bool) {
+	mapping := ColumnMapping{Front: Unmapped, Back: Unmapped, Tags: Unmapped, Interval: Unmapped, Ease: Unmapped, Due: Unmapped}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "front", "question", "q":
+			mapping.Front = i
+		case "back", "answer", "a":
+			mapping.Back = i
+		case "tags", "tag":
+			mapping.Tags = i
+		case "interval", "ivl":
+			mapping.Interval = i
+		case "ease", "ease_factor", "factor":
+			mapping.Ease = i
+		case "due", "next_review":
+			mapping.Due = i
+		}
+	}
+
+	if mapping.Front == Unmapped || mapping.Back == Unmapped {
+		return DefaultColumnMapping(), false
+	}
+	return mapping, true
+}
+
+// DuplicatePolicy decides what happens to rows whose front matches an existing card
+type DuplicatePolicy int
+
+const (
+	DuplicateSkip   DuplicatePolicy = iota // Leave the existing card untouched
+	DuplicateUpdate                        // Overwrite the existing card's content
+	DuplicateAdd                           // Add the row as another card
+)
+
+// String returns a human-readable representation of the policy
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicateSkip:
+		return "Skip"
+	case DuplicateUpdate:
+		return "Update"
+	case DuplicateAdd:
+		return "Duplicate"
+	default:
+		return "Unknown"
+	}
+}
+
+// DelimitedOptions configures a delimited text import
+type DelimitedOptions struct {
+	Delimiter  rune
+	HasHeader  bool
+	Mapping    ColumnMapping
+	Duplicates DuplicatePolicy
+}
+
+// DelimitedImportResult summarises a delimited text import
+type DelimitedImportResult struct {
+	Added   int
+	Updated int
+	Skipped int      // Duplicates left alone
+	Errors  []string // Rows that could not be imported
+}
+
+// ReadDelimited parses delimited text into rows, honouring quoted multi-line fields
+func ReadDelimited(r io.Reader, delimiter rune) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	return rows, nil
+}
+
+// NormalizeFront reduces card text to a form used for duplicate detection
+func NormalizeFront(front string) string {
+	return strings.ToLower(strings.Join(strings.Fields(front), " "))
+}
+
+// NoteKeys indexes the deck's notes by their normalized first field, the front of basic,
+// reversed and cloze notes, for duplicate detection. Notes of custom types are left out.
+// Cards from before notes existed are indexed by their front with an empty note ID
+func NoteKeys(deck *models.Deck) map[string]string {
+	keys := make(map[string]string, len(deck.Notes))
+	for _, note := range deck.Notes {
+		if note.Type.IsBuiltIn() {
+			keys[NormalizeFront(note.Front)] = note.ID
+		}
+	}
+	for _, card := range deck.Cards {
+		if card.NoteID == "" || deck.GetNote(card.NoteID) == nil {
+			keys[NormalizeFront(card.Front)] = ""
+		}
+	}
+	return keys
+}
+
+// ImportDelimited adds the rows to the deck as basic notes according to the options. Rows
+// matching the first field of an existing note update that note and its cards
+func ImportDelimited(deck *models.Deck, rows [][]string, opts DelimitedOptions) *DelimitedImportResult {
+	result := &DelimitedImportResult{}
+	if opts.HasHeader && len(rows) > 0 {
+		rows = rows[1:]
+	}
+
+	// Give cards from before notes existed their notes, so every duplicate has one
+	for i := range deck.Cards {
+		deck.NoteFor(&deck.Cards[i])
+	}
+	existing := NoteKeys(deck)
+
+	mapping := opts.Mapping
+	now := time.Now()
+
+	for i, row := range rows {
+		line := i + 1
+		if opts.HasHeader {
+			line++
+		}
+
+		front := strings.TrimSpace(column(row, mapping.Front))
+		back := strings.TrimSpace(column(row, mapping.Back))
+		if front == "" && back == "" {
+			continue
+		}
+		if front == "" || back == "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("row %d: front and back are required", line))
+			continue
+		}
+
+		// Scheduling columns are read onto a scratch card and copied to the note's first card
+		parsed := models.NewCard(front, back)
+		tags := models.ParseTags(column(row, mapping.Tags))
+		scheduled, err := applySchedulingColumns(parsed, row, mapping, now)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("row %d: %v", line, err))
+			continue
+		}
+
+		key := NormalizeFront(front)
+		if noteID, ok := existing[key]; ok && opts.Duplicates != DuplicateAdd {
+			if opts.Duplicates == DuplicateSkip {
+				result.Skipped++
+				continue
+			}
+
+			// Update the note and any mapped fields, keeping the cards' identity and history
+			note := deck.GetNote(noteID)
+			if note.Type == models.ClozeNote && !models.HasCloze(front) {
+				result.Errors = append(result.Errors, fmt.Sprintf("row %d: the cloze note needs a deletion like {{c1::answer}}", line))
+				continue
+			}
+			edited := *note
+			edited.Front, edited.Back = front, back
+			deck.UpdateNote(&edited, nil)
+			if mapping.Tags != Unmapped {
+				deck.SetNoteTags(noteID, tags)
+			}
+			if scheduled {
+				copyScheduling(deck, noteID, parsed)
+			}
+			result.Updated++
+			continue
+		}
+
+		note := models.NewNote(models.BasicNote, front, back)
+		deck.AddNote(note, nil)
+		if len(tags) > 0 {
+			deck.SetNoteTags(note.ID, tags)
+		}
+		if scheduled {
+			copyScheduling(deck, note.ID, parsed)
+		}
+		existing[key] = note.ID
+		result.Added++
+	}
+
+	return result
+}
+
+// copyScheduling gives the first card of a note the scheduling read from a row
+func copyScheduling(deck *models.Deck, noteID string, parsed *models.Card) {
+	for i := range deck.Cards {
+		card := &deck.Cards[i]
+		if card.NoteID == noteID && card.Ord == 1 {
+			card.Interval = parsed.Interval
+			card.EaseFactor = parsed.EaseFactor
+			card.Repetition = parsed.Repetition
+			card.NextReview = parsed.NextReview
+		}
+	}
+}
+
+// applySchedulingColumns copies interval, ease and due date from the row onto a new card,
+// reporting whether the row carried any scheduling data
+func applySchedulingColumns(card *models.Card, row []string, mapping ColumnMapping, now time.Time) (bool, error) {
+	scheduled := false
+
+	if value := strings.TrimSpace(column(row, mapping.Interval)); value != "" {
+		scheduled = true
+		interval, err := strconv.Atoi(value)
+		if err != nil || interval < 0 {
+			return false, fmt.Errorf("invalid interval %q", value)
+		}
+		if interval > 0 {
+			card.Interval = interval
+			card.Repetition = 1
+			card.NextReview = now.AddDate(0, 0, interval)
+		}
+	}
+
+	if value := strings.TrimSpace(column(row, mapping.Ease)); value != "" {
+		scheduled = true
+		ease, err := parseEase(value)
+		if err != nil {
+			return false, err
+		}
+		card.EaseFactor = ease
+	}
+
+	if value := strings.TrimSpace(column(row, mapping.Due)); value != "" {
+		scheduled = true
+		due, err := parseDate(value)
+		if err != nil {
+			return false, err
+		}
+		card.NextReview = due
+		if card.Repetition == 0 {
+			card.Repetition = 1
+		}
+	}
+
+	return scheduled, nil
+}
+
+// column returns the value of column index in row, or "" when unmapped or missing
+func column(row []string, index int) string {
+	if index < 0 || index >= len(row) {
+		return ""
+	}
+	return row[index]
+}
+
+// parseEase accepts an ease factor as 2.5, 250% or Anki's permille 2500
+func parseEase(value string) (float64, error) {
+	percent := strings.HasSuffix(value, "%")
+	ease, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || ease <= 0 {
+		return 0, fmt.Errorf("invalid ease %q", value)
+	}
+
+	switch {
+	case percent || (ease >= 100 && ease < 1000):
+		ease /= 100
+	case ease >= 1000:
+		ease /= 1000
+	}
+	if ease < 1.3 {
+		ease = 1.3
+	}
+	return ease, nil
+}
+
+// parseDate accepts a date as YYYY-MM-DD or RFC 3339
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid due date %q", value)
+}
+
+// ExportDelimited writes the cards of decks as delimited text with a header row
+func ExportDelimited(w io.Writer, decks []*models.Deck, delimiter rune) (int, error) {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	if err := writer.Write([]string{"front", "back", "tags", "interval", "ease", "due", "deck"}); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, deck := range decks {
+		for _, card := range deck.Cards {
+			// Only learned cards carry an interval and due date
+			interval, due := "", ""
+			if card.Repetition > 0 {
+				interval = strconv.Itoa(card.Interval)
+				due = card.NextReview.Format("2006-01-02")
+			}
+			record := []string{
+				card.Front,
+				card.Back,
+				strings.Join(card.Tags, " "),
+				interval,
+				strconv.FormatFloat(card.EaseFactor, 'f', 2, 64),
+				due,
+				deck.Name,
+			}
+			if err := writer.Write(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+
+	writer.Flush()
+	return count, writer.Error()
+}
@@ -0,0 +1,179 @@
+package interop
+
+import (
+	"anktui/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMappingFromHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   ColumnMapping
+		wantOK bool
+	}{
+		{
+			"front and back",
+			"Front,Back",
+			ColumnMapping{Front: 0, Back: 1, Tags: Unmapped, Interval: Unmapped, Ease: Unmapped, Due: Unmapped},
+			true,
+		},
+		{
+			"every column in another order",
+			" tags ,Answer,Question,ivl,factor,next_review",
+			ColumnMapping{Front: 2, Back: 1, Tags: 0, Interval: 3, Ease: 4, Due: 5},
+			true,
+		},
+		{"no back column", "front,tags", DefaultColumnMapping(), false},
+		{"not a header", "hola,hello", DefaultColumnMapping(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MappingFromHeader(strings.Split(tt.header, ","))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MappingFromHeader(%q) = %+v, %v, want %+v, %v", tt.header, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		text string
+		want rune
+	}{
+		{"front,back\nhola,hello", ','},
+		{"front\tback\ttags\nhola\thello, hi\tgreeting", '\t'},
+		{"a;b;c\n1,2,3,4,5", ';'},
+		{"a|b", '|'},
+		{"just one column", ','},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := DetectDelimiter(tt.text); got != tt.want {
+				t.Errorf("DetectDelimiter(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEase(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"2.5", 2.5, false},
+		{"250%", 2.5, false},
+		{"250", 2.5, false},
+		{"2500", 2.5, false},
+		{"1.1", 1.3, false},
+		{"0", 0, true},
+		{"easy", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseEase(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseEase(%q) = %v, %v, want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportDelimited(t *testing.T) {
+	fullMapping := ColumnMapping{Front: 0, Back: 1, Tags: 2, Interval: 3, Ease: 4, Due: Unmapped}
+	tests := []struct {
+		name       string
+		rows       string
+		opts       DelimitedOptions
+		wantResult DelimitedImportResult
+		wantErrors int
+		wantCards  []string // Front and back of each card in deck order
+		wantTags   string   // Tags of the existing card
+		wantIvl    int      // Interval of the existing card
+	}{
+		{
+			name:       "adds rows and skips duplicates",
+			rows:       "gato,cat\n  PERRO ,puppy\n,\nsol,",
+			opts:       DelimitedOptions{Mapping: DefaultColumnMapping(), Duplicates: DuplicateSkip},
+			wantResult: DelimitedImportResult{Added: 1, Skipped: 1},
+			wantErrors: 1,
+			wantCards:  []string{"perro=dog", "gato=cat"},
+			wantTags:   "animal",
+			wantIvl:    5,
+		},
+		{
+			name:       "header row and updates through the note",
+			rows:       "front,back,tags,ivl,ease\nperro,dog (animal),pet noun,20,250%\ngato,cat,,,",
+			opts:       DelimitedOptions{HasHeader: true, Mapping: fullMapping, Duplicates: DuplicateUpdate},
+			wantResult: DelimitedImportResult{Added: 1, Updated: 1},
+			wantCards:  []string{"perro=dog (animal)", "gato=cat"},
+			wantTags:   "pet noun",
+			wantIvl:    20,
+		},
+		{
+			name:       "duplicates added as new notes",
+			rows:       "perro,hound",
+			opts:       DelimitedOptions{Mapping: DefaultColumnMapping(), Duplicates: DuplicateAdd},
+			wantResult: DelimitedImportResult{Added: 1},
+			wantCards:  []string{"perro=dog", "perro=hound"},
+			wantTags:   "animal",
+			wantIvl:    5,
+		},
+		{
+			name:       "invalid scheduling columns",
+			rows:       "gato,cat,,soon,\nsol,sun,,,hard",
+			opts:       DelimitedOptions{Mapping: fullMapping, Duplicates: DuplicateSkip},
+			wantErrors: 2,
+			wantCards:  []string{"perro=dog"},
+			wantTags:   "animal",
+			wantIvl:    5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := models.NewDeck("Spanish", "")
+			note := models.NewNote(models.BasicNote, "perro", "dog")
+			deck.AddNote(note, nil)
+			deck.SetNoteTags(note.ID, []string{"animal"})
+			deck.Cards[0].Interval = 5
+
+			rows, err := ReadDelimited(strings.NewReader(tt.rows), ',')
+			if err != nil {
+				t.Fatalf("ReadDelimited failed: %v", err)
+			}
+			result := ImportDelimited(deck, rows, tt.opts)
+
+			if result.Added != tt.wantResult.Added || result.Updated != tt.wantResult.Updated || result.Skipped != tt.wantResult.Skipped {
+				t.Errorf("added %d, updated %d, skipped %d, want %d, %d, %d",
+					result.Added, result.Updated, result.Skipped,
+					tt.wantResult.Added, tt.wantResult.Updated, tt.wantResult.Skipped)
+			}
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("errors = %q, want %d", result.Errors, tt.wantErrors)
+			}
+
+			var cards []string
+			for _, card := range deck.Cards {
+				cards = append(cards, card.Front+"="+card.Back)
+				if deck.GetNote(card.NoteID) == nil {
+					t.Errorf("card %q has no note", card.Front)
+				}
+			}
+			if !slices.Equal(cards, tt.wantCards) {
+				t.Errorf("cards = %q, want %q", cards, tt.wantCards)
+			}
+
+			existing := deck.Cards[0]
+			if tags := strings.Join(existing.Tags, " "); tags != tt.wantTags {
+				t.Errorf("existing card has tags %q, want %q", tags, tt.wantTags)
+			}
+			if existing.Interval != tt.wantIvl {
+				t.Errorf("existing card has interval %d, want %d", existing.Interval, tt.wantIvl)
+			}
+		})
+	}
+}
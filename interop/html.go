@@ -0,0 +1,46 @@
+package interop
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlBreakPattern     = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlPrePattern       = regexp.MustCompile(`(?is)<pre[^>]*>\s*(?:<code[^>]*>)?(.*?)(?:</code>)?\s*</pre>`)
+	htmlListItemPattern  = regexp.MustCompile(`(?i)<li(\s[^>]*)?>`)
+	htmlBlockPattern     = regexp.MustCompile(`(?i)</?(div|p|ul|ol|h[1-6]|tr|table)(\s[^>]*)?>|</li>`)
+	htmlBoldPattern      = regexp.MustCompile(`(?i)</?(b|strong)(\s[^>]*)?>`)
+	htmlItalicPattern    = regexp.MustCompile(`(?i)</?(i|em)(\s[^>]*)?>`)
+	htmlCodePattern      = regexp.MustCompile(`(?i)</?code(\s[^>]*)?>`)
+	htmlImagePattern     = regexp.MustCompile(`(?i)<img[^>]*\ssrc\s*=\s*["']?([^"' >]+)["']?[^>]*>`)
+	htmlTagPattern       = regexp.MustCompile(`<[^>]+>`)
+	blankLinesPattern    = regexp.MustCompile(`\n{3,}`)
+	trailingSpacePattern = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// htmlToMarkdown converts the small subset of HTML Anki fields usually contain into markdown
+func htmlToMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = htmlPrePattern.ReplaceAllString(s, "\n```\n$1\n```\n")
+	s = htmlImagePattern.ReplaceAllString(s, "![]($1)")
+	s = htmlListItemPattern.ReplaceAllString(s, "\n- ")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlBlockPattern.ReplaceAllString(s, "\n")
+	s = htmlBoldPattern.ReplaceAllString(s, "**")
+	s = htmlItalicPattern.ReplaceAllString(s, "*")
+	s = htmlCodePattern.ReplaceAllString(s, "`")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	s = trailingSpacePattern.ReplaceAllString(s, "\n")
+	s = blankLinesPattern.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// stripHTML removes all tags and entities, leaving plain text
+func stripHTML(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
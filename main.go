@@ -1,6 +1,7 @@
 package main
 
 import (
+	"anktui/cli"
 	"anktui/config"
 	"anktui/storage"
 	"anktui/ui"
@@ -11,28 +12,49 @@ import (
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts a subcommand or the TUI and returns the exit code. Deferred cleanup runs
+// before main exits, as os.Exit would skip it
+func run() int {
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
-	// Initialize storage
-	store, err := storage.NewJSONStorage(cfg)
+	// Run a subcommand instead of the TUI when one is given
+	if len(os.Args) > 1 {
+		if err := cli.Run(cfg, os.Args[1:], os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Initialize the configured storage backend
+	store, err := storage.New(cfg)
 	if err != nil {
 		fmt.Printf("Error initializing storage: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
-	// Create the application
+	// Create the application, releasing its storage when the program exits
 	app := ui.NewApp(cfg, store)
+	defer app.Close()
 
 	// Start the TUI program
 	p := tea.NewProgram(app, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	if err := app.Err(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
 	}
+	return 0
 }
@@ -6,10 +6,23 @@ import (
 	"github.com/google/uuid"
 )
 
+// LearningState marks a card that is working through minute-resolution learning steps
+type LearningState string
+
+const (
+	NotLearning LearningState = ""           // New, or graduated to day intervals
+	Learning    LearningState = "learning"   // New card being learned
+	Relearning  LearningState = "relearning" // Review card that was forgotten
+)
+
 type Card struct {
 	ID       string    `json:"id"`
 	Front    string    `json:"front"`
 	Back     string    `json:"back"`
+	Tags     []string  `json:"tags,omitempty"`
+	Type     CardType  `json:"type,omitempty"`
+	NoteID   string    `json:"note_id,omitempty"` // Shared by cards generated from the same text
+	Ord      int       `json:"ord,omitempty"`     // Cloze number of a cloze card
 	Created  time.Time `json:"created"`
 	Modified time.Time `json:"modified"`
 
@@ -19,6 +32,20 @@ type Card struct {
 	EaseFactor float64   `json:"ease_factor"` // Difficulty multiplier (default 2.5)
 	NextReview time.Time `json:"next_review"`
 	LastReview time.Time `json:"last_review,omitempty"`
+
+	Suspended   bool      `json:"suspended,omitempty"`    // Kept out of study sessions until unsuspended
+	BuriedUntil time.Time `json:"buried_until,omitempty"` // Kept out of study sessions until the next study day
+	Flag        Flag      `json:"flag,omitempty"`
+	Lapses      int       `json:"lapses,omitempty"`      // Times the card was forgotten after it had been learned
+	BorrowedBy  string    `json:"borrowed_by,omitempty"` // ID of the filtered deck the card is lent to
+
+	// Learning steps (the card is due again within minutes while Learning is set)
+	Learning LearningState `json:"learning,omitempty"`
+	Step     int           `json:"step,omitempty"` // Index of the current learning or relearning step
+
+	// FSRS memory state (zero until the card is first reviewed with FSRS)
+	Stability  float64 `json:"stability,omitempty"`  // Days until recall probability drops to 90%
+	Difficulty float64 `json:"difficulty,omitempty"` // Inherent difficulty from 1 (easy) to 10 (hard)
 }
 
 // NewCard creates a new flashcard with default values
@@ -37,11 +64,78 @@ func NewCard(front, back string) *Card {
 	}
 }
 
+// Flag is a colored marker users put on cards, for example to find them again in the browser
+type Flag int
+
+const (
+	NoFlag Flag = iota
+	RedFlag
+	OrangeFlag
+	GreenFlag
+	BlueFlag
+)
+
+// String returns the flag's color
+func (f Flag) String() string {
+	switch f {
+	case RedFlag:
+		return "red"
+	case OrangeFlag:
+		return "orange"
+	case GreenFlag:
+		return "green"
+	case BlueFlag:
+		return "blue"
+	default:
+		return "none"
+	}
+}
+
+// Next returns the flag after f, going back to no flag after the last color
+func (f Flag) Next() Flag {
+	return (f + 1) % (BlueFlag + 1)
+}
+
+// IsBuried reports whether the card is buried at now
+func (c *Card) IsBuried(now time.Time) bool {
+	return now.Before(c.BuriedUntil)
+}
+
+// IsAvailable reports whether the card can be studied, being neither suspended nor buried
+func (c *Card) IsAvailable(now time.Time) bool {
+	return !c.Suspended && !c.IsBuried(now)
+}
+
 // IsReviewDue checks if the card is ready for review
 func (c *Card) IsReviewDue() bool {
 	return time.Now().After(c.NextReview) || time.Now().Equal(c.NextReview)
 }
 
+// IsNew reports whether the card has never been studied
+func (c *Card) IsNew() bool {
+	return c.Repetition == 0 && c.LastReview.IsZero()
+}
+
+// InLearning reports whether the card is in its learning or relearning steps
+func (c *Card) InLearning() bool {
+	return c.Learning != NotLearning
+}
+
+// ResetProgress turns the card back into a new card, clearing its scheduling state
+func (c *Card) ResetProgress() {
+	c.Interval = 1
+	c.Repetition = 0
+	c.EaseFactor = 2.5
+	c.NextReview = time.Now()
+	c.LastReview = time.Time{}
+	c.Learning = NotLearning
+	c.Step = 0
+	c.Stability = 0
+	c.Difficulty = 0
+	c.Lapses = 0
+	c.MarkModified()
+}
+
 // MarkModified updates the modified timestamp
 func (c *Card) MarkModified() {
 	c.Modified = time.Now()
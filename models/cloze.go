@@ -0,0 +1,141 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CardType says how a card's text is turned into a question and an answer
+type CardType string
+
+const (
+	BasicCard CardType = ""      // Front is the question and Back the answer
+	ClozeCard CardType = "cloze" // Front holds cloze deletions and Back optional extra text
+
+	TemplateCard CardType = "template" // Front and Back are rendered from a custom note type's templates
+)
+
+// clozePattern matches {{c1::answer}} and {{c1::answer::hint}}
+var clozePattern = regexp.MustCompile(`(?s)\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// HasCloze reports whether text contains cloze deletions
+func HasCloze(text string) bool {
+	return clozePattern.MatchString(text)
+}
+
+// ClozeNumbers returns the distinct cloze numbers used in text, in ascending order
+func ClozeNumbers(text string) []int {
+	seen := make(map[int]bool)
+	var numbers []int
+	for _, match := range clozePattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n <= 0 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// ClozeQuestion blanks the deletions numbered ord, showing their hint if they have one,
+// and reveals every other deletion
+func ClozeQuestion(text string, ord int) string {
+	return replaceClozes(text, ord, func(answer, hint string) string {
+		if hint != "" {
+			return "[" + hint + "]"
+		}
+		return "[...]"
+	})
+}
+
+// ClozeAnswer reveals every deletion, passing those numbered ord through highlight
+func ClozeAnswer(text string, ord int, highlight func(answer string) string) string {
+	return replaceClozes(text, ord, func(answer, hint string) string {
+		return highlight(answer)
+	})
+}
+
+// replaceClozes replaces deletions numbered ord with the result of replace and the rest with their answer
+func replaceClozes(text string, ord int, replace func(answer, hint string) string) string {
+	return clozePattern.ReplaceAllStringFunc(text, func(deletion string) string {
+		match := clozePattern.FindStringSubmatch(deletion)
+		if n, _ := strconv.Atoi(match[1]); n == ord {
+			return replace(match[2], match[3])
+		}
+		return match[2]
+	})
+}
+
+// Question returns the text shown before the answer is revealed
+func (c *Card) Question() string {
+	if c.Type == ClozeCard {
+		return ClozeQuestion(c.Front, c.Ord)
+	}
+	return c.Front
+}
+
+// Answer returns the back of the card without the front that templates often repeat on it,
+// or the rule put between the two
+func (c *Card) Answer() string {
+	if c.Type != TemplateCard {
+		return c.Back
+	}
+	back, ok := strings.CutPrefix(strings.TrimSpace(c.Back), strings.TrimSpace(c.Front))
+	if !ok {
+		return c.Back
+	}
+	back = strings.TrimSpace(back)
+	if rule, rest, found := strings.Cut(back, "\n"); found && strings.TrimSpace(rule) != "" && strings.Trim(strings.TrimSpace(rule), "-=*_") == "" {
+		back = rest
+	}
+	return strings.TrimSpace(back)
+}
+
+// ExpectedAnswer returns the text a typed answer is compared with: the deletions of a cloze
+// card, or the first non-empty line of the back with markdown emphasis removed. The front
+// that templates often repeat on the back is skipped
+func (c *Card) ExpectedAnswer() string {
+	if c.Type == ClozeCard {
+		var answers []string
+		for _, match := range clozePattern.FindAllStringSubmatch(c.Front, -1) {
+			if n, _ := strconv.Atoi(match[1]); n == c.Ord {
+				answers = append(answers, match[2])
+			}
+		}
+		return strings.Join(answers, ", ")
+	}
+
+	return firstAnswerLine(c.Answer())
+}
+
+// ExpectedAnswer returns the text a typed answer for one of the deck's cards is compared
+// with. Cards whose custom note type template names an answer field expect that field of
+// their note, and other cards their own expected answer
+func (d *Deck) ExpectedAnswer(card *Card, noteTypes []*CustomNoteType) string {
+	if note := d.GetNote(card.NoteID); note != nil && card.Type == TemplateCard {
+		if noteType := FindNoteType(noteTypes, note.Type); noteType != nil {
+			if template := noteType.Template(card.Ord); template != nil && template.AnswerField != "" {
+				if answer := firstAnswerLine(note.Fields[template.AnswerField]); answer != "" {
+					return answer
+				}
+			}
+		}
+	}
+	return card.ExpectedAnswer()
+}
+
+// firstAnswerLine returns the first line of text with content, without markdown emphasis
+func firstAnswerLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "*_`#> ")
+		// Skip horizontal rules, which templates often put between front and back
+		if strings.Trim(line, "-=") != "" {
+			return line
+		}
+	}
+	return ""
+}
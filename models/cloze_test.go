@@ -0,0 +1,114 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClozeNumbers(t *testing.T) {
+	tests := []struct {
+		text      string
+		want      []int
+		wantCloze bool
+	}{
+		{"no deletions", nil, false},
+		{"{{c1::Paris}} is in {{c2::France}}", []int{1, 2}, true},
+		{"{{c3::a}} {{c1::b}} {{c3::c}}", []int{1, 3}, true},
+		{"{{c2::answer::hint}}", []int{2}, true},
+		{"{{c1::across\nlines}}", []int{1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := ClozeNumbers(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("ClozeNumbers(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if got := HasCloze(tt.text); got != tt.wantCloze {
+				t.Errorf("HasCloze(%q) = %v, want %v", tt.text, got, tt.wantCloze)
+			}
+		})
+	}
+}
+
+func TestClozeQuestionAndAnswer(t *testing.T) {
+	const text = "{{c1::Paris}} is the capital of {{c2::France::country}}, on the {{c1::Seine}}"
+	highlight := func(answer string) string { return "*" + answer + "*" }
+	tests := []struct {
+		ord          int
+		wantQuestion string
+		wantAnswer   string
+	}{
+		{1, "[...] is the capital of France, on the [...]", "*Paris* is the capital of France, on the *Seine*"},
+		{2, "Paris is the capital of [country], on the Seine", "Paris is the capital of *France*, on the Seine"},
+		{3, "Paris is the capital of France, on the Seine", "Paris is the capital of France, on the Seine"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantQuestion, func(t *testing.T) {
+			if got := ClozeQuestion(text, tt.ord); got != tt.wantQuestion {
+				t.Errorf("ClozeQuestion(%d) = %q, want %q", tt.ord, got, tt.wantQuestion)
+			}
+			if got := ClozeAnswer(text, tt.ord, highlight); got != tt.wantAnswer {
+				t.Errorf("ClozeAnswer(%d) = %q, want %q", tt.ord, got, tt.wantAnswer)
+			}
+		})
+	}
+}
+
+func TestCardExpectedAnswer(t *testing.T) {
+	tests := []struct {
+		name string
+		card Card
+		want string
+	}{
+		{"basic", Card{Front: "hola", Back: "hello"}, "hello"},
+		{"first line", Card{Front: "ser", Back: "to be\n\nirregular verb"}, "to be"},
+		{"markdown emphasis", Card{Front: "ser", Back: "**to be**"}, "to be"},
+		{"rule skipped", Card{Front: "ser", Back: "---\nto be"}, "to be"},
+		{"cloze", Card{Type: ClozeCard, Ord: 1, Front: "{{c1::Paris}} on the {{c1::Seine}} in {{c2::France}}"}, "Paris, Seine"},
+		{"template repeating the front", Card{Type: TemplateCard, Front: "perro", Back: "perro\n---\ndog"}, "dog"},
+		{"template without the front", Card{Type: TemplateCard, Front: "perro", Back: "dog"}, "dog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.card.ExpectedAnswer(); got != tt.want {
+				t.Errorf("ExpectedAnswer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeckExpectedAnswer(t *testing.T) {
+	vocab := NewCustomNoteType("Vocab", []string{"Word", "Meaning", "Example"}, []CardTemplate{
+		{Name: "Recognise", Front: "{{Word}}", Back: "{{FrontSide}}\n---\n{{Meaning}}\n\n{{Example}}", AnswerField: "Meaning"},
+		{Name: "Recall", Front: "{{Meaning}}", Back: "{{FrontSide}}\n---\n{{Word}}", AnswerField: "Word"},
+		{Name: "Example", Front: "{{Example}}", Back: "{{Word}}"},
+	})
+	tests := []struct {
+		name   string
+		fields map[string]string
+		ord    int
+		want   string
+	}{
+		{"answer field", map[string]string{"Word": "perro", "Meaning": "**dog**", "Example": "El perro ladra"}, 1, "dog"},
+		{"each template has its own", map[string]string{"Word": "perro", "Meaning": "dog", "Example": "El perro ladra"}, 2, "perro"},
+		{"template without one uses the back", map[string]string{"Word": "perro", "Meaning": "dog", "Example": "El perro ladra"}, 3, "perro"},
+		{"empty answer field uses the back", map[string]string{"Word": "perro", "Example": "El perro ladra"}, 1, "El perro ladra"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := NewDeck("Spanish", "")
+			deck.AddNote(NewCustomNote(vocab, tt.fields), vocab)
+			var card *Card
+			for i := range deck.Cards {
+				if deck.Cards[i].Ord == tt.ord {
+					card = &deck.Cards[i]
+				}
+			}
+			if card == nil {
+				t.Fatalf("no card for template %d", tt.ord)
+			}
+			if got := deck.ExpectedAnswer(card, []*CustomNoteType{vocab}); got != tt.want {
+				t.Errorf("ExpectedAnswer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
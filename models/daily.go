@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// DailyLimits caps how many cards a deck shows per study day
+type DailyLimits struct {
+	NewCards int // New cards introduced per day, 0 disables new cards
+	Reviews  int // Review cards answered per day
+}
+
+// DailyCounts records how many cards of a deck were studied on one study day
+type DailyCounts struct {
+	Day      string `json:"day"`       // Study day the counts belong to, see StudyDay
+	NewCards int    `json:"new_cards"` // New cards rated for the first time
+	Reviews  int    `json:"reviews"`   // Review cards rated
+}
+
+// StudyDay returns the study day t falls on as YYYY-MM-DD. Days start at rolloverHour
+// rather than midnight, so a late night session counts towards the day before
+func StudyDay(t time.Time, rolloverHour int) string {
+	return t.Add(-time.Duration(rolloverHour) * time.Hour).Format("2006-01-02")
+}
+
+// StudyDayStart returns when the study day t falls on starts
+func StudyDayStart(t time.Time, rolloverHour int) time.Time {
+	year, month, day := t.Add(-time.Duration(rolloverHour) * time.Hour).Date()
+	return time.Date(year, month, day, rolloverHour, 0, 0, 0, t.Location())
+}
+
+// NextRollover returns when the study day after the one t falls on starts
+func NextRollover(t time.Time, rolloverHour int) time.Time {
+	return StudyDayStart(t, rolloverHour).AddDate(0, 0, 1)
+}
+
+// StudyDaysBetween returns how many study days after the one from falls on to falls on,
+// negative when to falls on an earlier day
+func StudyDaysBetween(from, to time.Time, rolloverHour int) int {
+	shift := -time.Duration(rolloverHour) * time.Hour
+	fromYear, fromMonth, fromDay := from.Add(shift).Date()
+	toYear, toMonth, toDay := to.Add(shift).Date()
+	// Count whole days in UTC, where daylight saving can't stretch or shrink them
+	start := time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// CountsFor returns the deck's counts for day, which are zero until a card is studied that day
+func (d *Deck) CountsFor(day string) DailyCounts {
+	if d.Today.Day != day {
+		return DailyCounts{Day: day}
+	}
+	return d.Today
+}
+
+// RecordStudied counts a rated card towards the deck's totals for day
+func (d *Deck) RecordStudied(day string, wasNew bool) {
+	d.Today = d.CountsFor(day)
+	if wasNew {
+		d.Today.NewCards++
+	} else {
+		d.Today.Reviews++
+	}
+}
+
+// Remaining returns how many more new and review cards the deck may show on day
+func (d *Deck) Remaining(limits DailyLimits, day string) (newCards, reviews int) {
+	counts := d.CountsFor(day)
+	return max(limits.NewCards-counts.NewCards, 0), max(limits.Reviews-counts.Reviews, 0)
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStudyDay(t *testing.T) {
+	tests := []struct {
+		time         time.Time
+		rolloverHour int
+		want         string
+		wantStart    time.Time
+	}{
+		{date(2025, 3, 1, 12), 4, "2025-03-01", date(2025, 3, 1, 4)},
+		{date(2025, 3, 2, 3), 4, "2025-03-01", date(2025, 3, 1, 4)},
+		{date(2025, 3, 2, 4), 4, "2025-03-02", date(2025, 3, 2, 4)},
+		{date(2025, 3, 1, 0), 0, "2025-03-01", date(2025, 3, 1, 0)},
+		{date(2025, 1, 1, 1), 4, "2024-12-31", date(2024, 12, 31, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.time.String(), func(t *testing.T) {
+			if got := StudyDay(tt.time, tt.rolloverHour); got != tt.want {
+				t.Errorf("StudyDay() = %s, want %s", got, tt.want)
+			}
+			if got := StudyDayStart(tt.time, tt.rolloverHour); !got.Equal(tt.wantStart) {
+				t.Errorf("StudyDayStart() = %v, want %v", got, tt.wantStart)
+			}
+			if got, want := NextRollover(tt.time, tt.rolloverHour), tt.wantStart.AddDate(0, 0, 1); !got.Equal(want) {
+				t.Errorf("NextRollover() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStudyDaysBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"same study day", date(2025, 3, 1, 5), date(2025, 3, 2, 3), 0},
+		{"past the rollover", date(2025, 3, 1, 23), date(2025, 3, 2, 4), 1},
+		{"earlier day", date(2025, 3, 5, 12), date(2025, 3, 1, 12), -4},
+		{"across months", date(2025, 2, 27, 12), date(2025, 3, 2, 12), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StudyDaysBetween(tt.from, tt.to, 4); got != tt.want {
+				t.Errorf("StudyDaysBetween() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStudyDaysAcrossDaylightSaving(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// Clocks went forward on the night of 29 March 2025, making the 30th 23 hours long
+	before := time.Date(2025, 3, 29, 12, 0, 0, 0, madrid)
+	if got := StudyDaysBetween(before, time.Date(2025, 3, 31, 3, 0, 0, 0, madrid), 4); got != 1 {
+		t.Errorf("StudyDaysBetween() = %d, want 1", got)
+	}
+	if got := StudyDaysBetween(before, time.Date(2025, 3, 31, 12, 0, 0, 0, madrid), 4); got != 2 {
+		t.Errorf("StudyDaysBetween() = %d, want 2", got)
+	}
+	if got := NextRollover(before, 4).Sub(before); got != 15*time.Hour {
+		t.Errorf("next rollover in %v, want 15h", got)
+	}
+}
+
+func TestDeckDailyCounts(t *testing.T) {
+	deck := NewDeck("Spanish", "")
+	limits := DailyLimits{NewCards: 2, Reviews: 3}
+
+	deck.RecordStudied("2025-03-01", true)
+	deck.RecordStudied("2025-03-01", true)
+	deck.RecordStudied("2025-03-01", true)
+	deck.RecordStudied("2025-03-01", false)
+	if newCards, reviews := deck.Remaining(limits, "2025-03-01"); newCards != 0 || reviews != 2 {
+		t.Errorf("remaining = %d new and %d reviews, want 0 and 2", newCards, reviews)
+	}
+
+	// A new study day starts from zero
+	if newCards, reviews := deck.Remaining(limits, "2025-03-02"); newCards != 2 || reviews != 3 {
+		t.Errorf("remaining the next day = %d new and %d reviews, want 2 and 3", newCards, reviews)
+	}
+	deck.RecordStudied("2025-03-02", false)
+	if deck.Today != (DailyCounts{Day: "2025-03-02", Reviews: 1}) {
+		t.Errorf("counts = %+v, want one review on 2025-03-02", deck.Today)
+	}
+}
+
+// date returns the start of an hour in UTC
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
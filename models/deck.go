@@ -1,18 +1,23 @@
 package models
 
 import (
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Deck struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Cards       []Card    `json:"cards"`
-	Created     time.Time `json:"created"`
-	Modified    time.Time `json:"modified"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Cards       []Card      `json:"cards"`
+	Notes       []Note      `json:"notes,omitempty"` // Content the cards are generated from
+	Created     time.Time   `json:"created"`
+	Modified    time.Time   `json:"modified"`
+	Today       DailyCounts `json:"today"`            // Cards studied on the most recent study day
+	Filter      *DeckFilter `json:"filter,omitempty"` // Set on filtered decks, which borrow cards from other decks
 }
 
 // NewDeck creates a new deck with the given name and description
@@ -34,11 +39,12 @@ func (d *Deck) AddCard(card *Card) {
 	d.MarkModified()
 }
 
-// RemoveCard removes a card by ID from the deck
+// RemoveCard removes a card by ID from the deck, along with its note if no other card uses it
 func (d *Deck) RemoveCard(cardID string) bool {
 	for i, card := range d.Cards {
 		if card.ID == cardID {
 			d.Cards = append(d.Cards[:i], d.Cards[i+1:]...)
+			d.removeOrphanNote(card.NoteID)
 			d.MarkModified()
 			return true
 		}
@@ -56,33 +62,52 @@ func (d *Deck) GetCard(cardID string) *Card {
 	return nil
 }
 
-// GetReviewCards returns all cards that are due for review
-func (d *Deck) GetReviewCards() []Card {
+// GetReviewCards returns all previously studied cards that are due for review, leaving out
+// suspended and buried cards and cards lent to a filtered deck. Cards with a sibling
+// studied on the current study day, which starts at rolloverHour, are buried until the
+// next day
+func (d *Deck) GetReviewCards(rolloverHour int) []Card {
+	now := time.Now()
+	day := StudyDay(now, rolloverHour)
 	var reviewCards []Card
-	for _, card := range d.Cards {
-		if card.IsReviewDue() {
-			reviewCards = append(reviewCards, card)
+	for i, card := range d.Cards {
+		if card.IsNew() || !card.IsAvailable(now) || card.IsBorrowed() || !card.IsReviewDue() {
+			continue
+		}
+		// Cards in learning steps keep going even after a sibling was studied
+		if !card.InLearning() && d.siblingStudiedOn(&d.Cards[i], day, rolloverHour) {
+			continue
 		}
+		reviewCards = append(reviewCards, card)
 	}
 	return reviewCards
 }
 
-// GetNewCards returns all cards that have never been reviewed
-func (d *Deck) GetNewCards() []Card {
+// GetNewCards returns all cards that have never been reviewed and are neither suspended,
+// buried nor lent to a filtered deck, burying those with a sibling studied on the current
+// study day
+func (d *Deck) GetNewCards(rolloverHour int) []Card {
+	now := time.Now()
+	day := StudyDay(now, rolloverHour)
 	var newCards []Card
-	for _, card := range d.Cards {
-		if card.Repetition == 0 {
+	for i, card := range d.Cards {
+		if card.IsNew() && card.IsAvailable(now) && !card.IsBorrowed() && !d.siblingStudiedOn(&d.Cards[i], day, rolloverHour) {
 			newCards = append(newCards, card)
 		}
 	}
 	return newCards
 }
 
-// GetCardStats returns statistics about the deck
+// GetCardStats returns statistics about the deck. Suspended and buried cards, and cards lent
+// to a filtered deck, count towards the total only
 func (d *Deck) GetCardStats() (total, new, review int) {
 	total = len(d.Cards)
+	now := time.Now()
 	for _, card := range d.Cards {
-		if card.Repetition == 0 {
+		if !card.IsAvailable(now) || card.IsBorrowed() {
+			continue
+		}
+		if card.IsNew() {
 			new++
 		} else if card.IsReviewDue() {
 			review++
@@ -91,6 +116,46 @@ func (d *Deck) GetCardStats() (total, new, review int) {
 	return total, new, review
 }
 
+// SetSuspended suspends or unsuspends cards
+func (d *Deck) SetSuspended(cardIDs []string, suspended bool) {
+	for i := range d.Cards {
+		if slices.Contains(cardIDs, d.Cards[i].ID) {
+			d.Cards[i].Suspended = suspended
+			d.Cards[i].MarkModified()
+		}
+	}
+	d.MarkModified()
+}
+
+// BuryCard keeps a card out of study sessions until the study day after now, which
+// starts at rolloverHour
+func (d *Deck) BuryCard(cardID string, now time.Time, rolloverHour int) {
+	if card := d.GetCard(cardID); card != nil {
+		card.BuriedUntil = NextRollover(now, rolloverHour)
+		card.MarkModified()
+		d.MarkModified()
+	}
+}
+
+// SetFlag puts a flag on a card, or removes it with NoFlag
+func (d *Deck) SetFlag(cardID string, flag Flag) {
+	if card := d.GetCard(cardID); card != nil {
+		card.Flag = flag
+		card.MarkModified()
+		d.MarkModified()
+	}
+}
+
+// ResetCards turns cards back into new cards
+func (d *Deck) ResetCards(cardIDs []string) {
+	for i := range d.Cards {
+		if slices.Contains(cardIDs, d.Cards[i].ID) {
+			d.Cards[i].ResetProgress()
+		}
+	}
+	d.MarkModified()
+}
+
 // MarkModified updates the modified timestamp
 func (d *Deck) MarkModified() {
 	d.Modified = time.Now()
@@ -102,3 +167,22 @@ func (d *Deck) UpdateInfo(name, description string) {
 	d.Description = description
 	d.MarkModified()
 }
+
+// Clone returns a deep copy of the deck, so later changes to either leave the other alone
+func (d *Deck) Clone() *Deck {
+	clone := *d
+	clone.Cards = make([]Card, len(d.Cards))
+	for i, card := range d.Cards {
+		card.Tags = slices.Clone(card.Tags)
+		clone.Cards[i] = card
+	}
+	clone.Notes = slices.Clone(d.Notes)
+	for i := range clone.Notes {
+		clone.Notes[i].Fields = maps.Clone(clone.Notes[i].Fields)
+	}
+	if d.Filter != nil {
+		filter := *d.Filter
+		clone.Filter = &filter
+	}
+	return &clone
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// matcher reports whether an item satisfies part of a parsed expression
+type matcher[T any] func(T) bool
+
+// parseExpr parses a boolean expression of terms joined with AND, OR, NOT, a leading "-"
+// and parentheses, where terms next to each other must all match. what names the
+// expression in errors, and term turns each term into a matcher
+func parseExpr[T any](text, what string, term func(token string) (matcher[T], error)) (matcher[T], error) {
+	p := &exprParser[T]{tokens: tokenizeExpr(text), what: what, term: term}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in %s", p.tokens[p.pos], what)
+	}
+	return match, nil
+}
+
+// tokenizeExpr splits an expression into terms and parentheses. Double quotes keep
+// spaces and parentheses inside a term
+func tokenizeExpr(text string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case quoted:
+			current.WriteRune(r)
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// unquote removes the double quotes from a term, keeping any prefix like front:
+func unquote(token string) string {
+	return strings.ReplaceAll(token, `"`, "")
+}
+
+// exprParser is a recursive descent parser where NOT binds tighter than AND and AND binds
+// tighter than OR
+type exprParser[T any] struct {
+	tokens []string
+	pos    int
+	what   string
+	term   func(token string) (matcher[T], error)
+}
+
+// peek returns the next token, or "" at the end
+func (p *exprParser[T]) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// isOperator reports whether token is the keyword op, ignoring case
+func isOperator(token, op string) bool {
+	return strings.EqualFold(token, op)
+}
+
+func (p *exprParser[T]) parseOr() (matcher[T], error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for isOperator(p.peek(), "OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(item T) bool { return l(item) || right(item) }
+	}
+	return left, nil
+}
+
+func (p *exprParser[T]) parseAnd() (matcher[T], error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		if token == "" || token == ")" || isOperator(token, "OR") {
+			return left, nil
+		}
+		if isOperator(token, "AND") {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(item T) bool { return l(item) && right(item) }
+	}
+}
+
+func (p *exprParser[T]) parseNot() (matcher[T], error) {
+	token := p.peek()
+	if isOperator(token, "NOT") {
+		p.pos++
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(item T) bool { return !inner(item) }, nil
+	}
+	if len(token) > 1 && strings.HasPrefix(token, "-") {
+		p.pos++
+		inner, err := p.term(token[1:])
+		if err != nil {
+			return nil, err
+		}
+		return func(item T) bool { return !inner(item) }, nil
+	}
+	return p.parseTerm()
+}
+
+func (p *exprParser[T]) parseTerm() (matcher[T], error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("%s ends where a term was expected", p.what)
+	case token == "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ) in %s", p.what)
+		}
+		p.pos++
+		return inner, nil
+	case token == ")" || isOperator(token, "AND") || isOperator(token, "OR"):
+		return nil, fmt.Errorf("unexpected %q in %s", token, p.what)
+	}
+	p.pos++
+	return p.term(token)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenizeExpr(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"graph AND NOT hard", []string{"graph", "AND", "NOT", "hard"}},
+		{"(easy OR medium) -review", []string{"(", "easy", "OR", "medium", ")", "-review"}},
+		{"  dp\tgreedy\n", []string{"dp", "greedy"}},
+		{`front:"two (pointer) sum" x`, []string{`front:"two (pointer) sum"`, "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := tokenizeExpr(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("tokenizeExpr(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagFilterMatches(t *testing.T) {
+	cards := map[string][]string{
+		"graph":       {"graph"},
+		"hard graph":  {"graph", "Hard"},
+		"dp":          {"dp", "medium"},
+		"greedy":      {"greedy", "easy", "review"},
+		"leetcode dp": {"leetcode::dp"},
+		"untagged":    nil,
+	}
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"dp", "graph", "greedy", "hard graph", "leetcode dp", "untagged"}},
+		{"graph", []string{"graph", "hard graph"}},
+		{"HARD", []string{"hard graph"}},
+		{"graph AND NOT hard", []string{"graph"}},
+		{"graph and not hard", []string{"graph"}},
+		{"graph -hard", []string{"graph"}},
+		{"dp OR greedy", []string{"dp", "greedy"}},
+		{"(easy OR medium) -review", []string{"dp"}},
+		{"easy OR medium -review", []string{"dp", "greedy"}},
+		{"NOT NOT graph", []string{"graph", "hard graph"}},
+		{"leetcode", []string{"leetcode dp"}},
+		{"leetcode::*", []string{"leetcode dp"}},
+		{"g*", []string{"graph", "greedy", "hard graph"}},
+		{`"dp"`, []string{"dp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := ParseTagFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseTagFilter(%q) failed: %v", tt.filter, err)
+			}
+			var got []string
+			for name, tags := range cards {
+				if filter.Matches(&Card{Tags: tags}) {
+					got = append(got, name)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filter %q matched %q, want %q", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagFilterErrors(t *testing.T) {
+	tests := []string{
+		"(graph",
+		"graph)",
+		"()",
+		"graph OR",
+		"OR graph",
+		"graph AND AND dp",
+		"NOT",
+		"[graph",
+	}
+	for _, filter := range tests {
+		t.Run(filter, func(t *testing.T) {
+			if _, err := ParseTagFilter(filter); err == nil {
+				t.Errorf("ParseTagFilter(%q) succeeded, want an error", filter)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FilterKind is what a filtered deck gathers cards for
+type FilterKind string
+
+const (
+	ReviewAheadFilter FilterKind = "review_ahead" // Review cards due within the next Days days
+	ForgottenFilter   FilterKind = "forgotten"    // Cards rated Again within the last Days days
+	CramFilter        FilterKind = "cram"         // Any cards matching the search
+	PreviewFilter     FilterKind = "preview"      // New cards, shown without being scheduled
+)
+
+// FilterKinds lists the kinds of filtered decks in the order they are offered
+var FilterKinds = []FilterKind{ReviewAheadFilter, ForgottenFilter, CramFilter, PreviewFilter}
+
+// String returns a readable name for the kind
+func (k FilterKind) String() string {
+	switch k {
+	case ReviewAheadFilter:
+		return "Review ahead"
+	case ForgottenFilter:
+		return "Forgotten cards"
+	case CramFilter:
+		return "Cram"
+	case PreviewFilter:
+		return "Preview new cards"
+	default:
+		return string(k)
+	}
+}
+
+// UsesDays reports whether the kind looks a number of days ahead or back
+func (k FilterKind) UsesDays() bool {
+	return k == ReviewAheadFilter || k == ForgottenFilter
+}
+
+// Reschedules reports whether ratings of the kind affect scheduling unless chosen otherwise.
+// Previews leave new cards new
+func (k FilterKind) Reschedules() bool {
+	return k != PreviewFilter
+}
+
+// DeckFilter decides which cards a filtered deck borrows from their home decks
+type DeckFilter struct {
+	Kind       FilterKind `json:"kind"`
+	Days       int        `json:"days,omitempty"`
+	Search     string     `json:"search,omitempty"` // Card search limiting where cards come from
+	Limit      int        `json:"limit"`            // Most cards borrowed at once
+	Reschedule bool       `json:"reschedule"`       // Whether ratings affect the cards' real scheduling
+}
+
+// Query returns the search that finds the cards the filter borrows
+func (f *DeckFilter) Query() (*Query, error) {
+	var terms []string
+	switch f.Kind {
+	case ReviewAheadFilter:
+		terms = append(terms, fmt.Sprintf("is:review prop:due<=%d", f.Days))
+	case ForgottenFilter:
+		terms = append(terms, fmt.Sprintf("rated:%d:1", f.Days))
+	case PreviewFilter:
+		terms = append(terms, "is:new")
+	case CramFilter:
+	default:
+		return nil, fmt.Errorf("unknown filtered deck kind %q", f.Kind)
+	}
+	if search := strings.TrimSpace(f.Search); search != "" {
+		terms = append(terms, "("+search+")")
+	}
+	return ParseQuery(strings.Join(terms, " "))
+}
+
+// Describe summarizes the filter, as in "Review ahead 3 days"
+func (f *DeckFilter) Describe() string {
+	switch f.Kind {
+	case ReviewAheadFilter:
+		return fmt.Sprintf("Review ahead %s", pluralDays(f.Days))
+	case ForgottenFilter:
+		return fmt.Sprintf("Forgotten in the last %s", pluralDays(f.Days))
+	default:
+		return f.Kind.String()
+	}
+}
+
+// pluralDays formats a number of days
+func pluralDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// NewFilteredDeck creates a filtered deck. It has no cards of its own until it is built
+func NewFilteredDeck(name string, filter DeckFilter) *Deck {
+	deck := NewDeck(name, filter.Describe())
+	deck.Filter = &filter
+	return deck
+}
+
+// IsFiltered reports whether the deck borrows its cards from other decks
+func (d *Deck) IsFiltered() bool {
+	return d.Filter != nil
+}
+
+// IsBorrowed reports whether a filtered deck has borrowed the card from its home deck
+func (c *Card) IsBorrowed() bool {
+	return c.BorrowedBy != ""
+}
+
+// BorrowedCards returns the cards the filtered deck has borrowed from decks
+func (d *Deck) BorrowedCards(decks []*Deck) []SearchResult {
+	var borrowed []SearchResult
+	for _, deck := range decks {
+		for i := range deck.Cards {
+			if deck.Cards[i].BorrowedBy == d.ID {
+				borrowed = append(borrowed, SearchResult{Deck: deck, Card: &deck.Cards[i]})
+			}
+		}
+	}
+	return borrowed
+}
+
+// BorrowedFrom returns the decks the filtered deck has borrowed cards from
+func (d *Deck) BorrowedFrom(decks []*Deck) []*Deck {
+	var from []*Deck
+	for _, result := range d.BorrowedCards(decks) {
+		if !slices.Contains(from, result.Deck) {
+			from = append(from, result.Deck)
+		}
+	}
+	return from
+}
+
+// ReturnCards gives borrowed cards back to the deck they belong to
+func (d *Deck) ReturnCards(cardIDs []string) {
+	for i := range d.Cards {
+		if slices.Contains(cardIDs, d.Cards[i].ID) {
+			d.Cards[i].BorrowedBy = ""
+		}
+	}
+	d.MarkModified()
+}
+
+// EmptyFiltered returns every card the filtered deck has borrowed to its home deck, and
+// returns the home decks that changed
+func (d *Deck) EmptyFiltered(decks []*Deck) []*Deck {
+	changed := d.BorrowedFrom(decks)
+	for _, deck := range changed {
+		for i := range deck.Cards {
+			if deck.Cards[i].BorrowedBy == d.ID {
+				deck.Cards[i].BorrowedBy = ""
+			}
+		}
+		deck.MarkModified()
+	}
+	return changed
+}
+
+// RebuildFiltered returns the filtered deck's cards and borrows the cards that match its
+// filter now, up to its limit. Suspended and buried cards, and cards another filtered deck
+// has borrowed, are left alone. It returns the home decks that changed and how many cards
+// were borrowed
+func (d *Deck) RebuildFiltered(decks []*Deck, ctx *SearchContext) ([]*Deck, int, error) {
+	query, err := d.Filter.Query()
+	if err != nil {
+		return nil, 0, err
+	}
+	changed := d.EmptyFiltered(decks)
+
+	var matches []SearchResult
+	for _, result := range query.Search(decks, ctx) {
+		if !result.Deck.IsFiltered() && !result.Card.IsBorrowed() && result.Card.IsAvailable(ctx.Now) {
+			matches = append(matches, result)
+		}
+	}
+	if d.Filter.Kind == ReviewAheadFilter {
+		// Cards due soonest first
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Card.NextReview.Before(matches[j].Card.NextReview)
+		})
+	}
+	if d.Filter.Limit > 0 && len(matches) > d.Filter.Limit {
+		matches = matches[:d.Filter.Limit]
+	}
+
+	for _, result := range matches {
+		result.Card.BorrowedBy = d.ID
+		if !slices.Contains(changed, result.Deck) {
+			changed = append(changed, result.Deck)
+		}
+	}
+	for _, deck := range changed {
+		deck.MarkModified()
+	}
+	d.MarkModified()
+	return changed, len(matches), nil
+}
+
+// filteredSessionCards picks the cards a deck has lent to the session's filtered deck
+func filteredSessionCards(deck *Deck, filtered *Deck) []Card {
+	now := time.Now()
+	var cards []Card
+	for _, card := range deck.Cards {
+		if card.BorrowedBy == filtered.ID && card.IsAvailable(now) {
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
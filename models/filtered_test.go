@@ -0,0 +1,123 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRebuildFiltered(t *testing.T) {
+	tests := []struct {
+		name         string
+		filter       DeckFilter
+		want         []string // Borrowed cards in deck order
+		wantBorrowed int
+		wantChanged  []string
+		wantErr      bool
+	}{
+		{
+			name:         "review ahead",
+			filter:       DeckFilter{Kind: ReviewAheadFilter, Days: 3},
+			want:         []string{"correr", "ser", "two pointer sum"},
+			wantBorrowed: 3,
+			wantChanged:  []string{"Languages::Spanish", "Leetcode"},
+		},
+		{
+			name:         "review ahead takes the soonest due up to the limit",
+			filter:       DeckFilter{Kind: ReviewAheadFilter, Days: 3, Limit: 2},
+			want:         []string{"correr", "two pointer sum"},
+			wantBorrowed: 2,
+			wantChanged:  []string{"Languages::Spanish", "Leetcode"},
+		},
+		{
+			name:         "review ahead fewer days",
+			filter:       DeckFilter{Kind: ReviewAheadFilter, Days: 1},
+			want:         []string{"correr", "two pointer sum"},
+			wantBorrowed: 2,
+			wantChanged:  []string{"Languages::Spanish", "Leetcode"},
+		},
+		{
+			name:         "forgotten",
+			filter:       DeckFilter{Kind: ForgottenFilter, Days: 1},
+			want:         []string{"correr"},
+			wantBorrowed: 1,
+			wantChanged:  []string{"Languages::Spanish"},
+		},
+		{
+			name:         "cram leaves suspended cards",
+			filter:       DeckFilter{Kind: CramFilter, Search: "tag:dp OR tag:greeting"},
+			want:         []string{"hola"},
+			wantBorrowed: 1,
+			wantChanged:  []string{"Languages::Spanish"},
+		},
+		{
+			name:         "preview leaves cards borrowed by another deck",
+			filter:       DeckFilter{Kind: PreviewFilter},
+			want:         []string{"hola"},
+			wantBorrowed: 1,
+			wantChanged:  []string{"Languages::Spanish"},
+		},
+		{
+			name:        "nothing matches",
+			filter:      DeckFilter{Kind: PreviewFilter, Search: "deck:Leetcode"},
+			wantChanged: []string{"Languages::Spanish"}, // Its earlier card was returned
+		},
+		{
+			name:    "unknown kind",
+			filter:  DeckFilter{Kind: "unknown"},
+			want:    []string{"hola"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decks, ctx := searchFixture()
+			filtered := NewFilteredDeck("Custom", tt.filter)
+			decks = append(decks, filtered)
+			// One card was borrowed by an earlier build, one by another filtered deck
+			decks[0].GetCard("hola").BorrowedBy = filtered.ID
+			decks[0].GetCard("ayer").BorrowedBy = "another filtered deck"
+
+			changed, borrowed, err := filtered.RebuildFiltered(decks, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RebuildFiltered() error = %v, want error %v", err, tt.wantErr)
+			}
+			if borrowed != tt.wantBorrowed {
+				t.Errorf("borrowed %d cards, want %d", borrowed, tt.wantBorrowed)
+			}
+			var changedNames []string
+			for _, deck := range changed {
+				changedNames = append(changedNames, deck.Name)
+			}
+			if !slices.Equal(changedNames, tt.wantChanged) {
+				t.Errorf("changed decks = %v, want %v", changedNames, tt.wantChanged)
+			}
+
+			var got []string
+			for _, result := range filtered.BorrowedCards(decks) {
+				got = append(got, result.Card.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("borrowed cards = %v, want %v", got, tt.want)
+			}
+			if decks[0].GetCard("ayer").BorrowedBy != "another filtered deck" {
+				t.Errorf("card borrowed by another deck was taken")
+			}
+		})
+	}
+}
+
+func TestEmptyFiltered(t *testing.T) {
+	decks, ctx := searchFixture()
+	filtered := NewFilteredDeck("Custom", DeckFilter{Kind: ReviewAheadFilter, Days: 3})
+	if _, _, err := filtered.RebuildFiltered(decks, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := filtered.EmptyFiltered(decks)
+	if len(changed) != 2 {
+		t.Errorf("emptying changed %d decks, want 2", len(changed))
+	}
+	if borrowed := filtered.BorrowedCards(decks); len(borrowed) != 0 {
+		t.Errorf("%d cards are still borrowed", len(borrowed))
+	}
+}
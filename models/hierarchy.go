@@ -0,0 +1,199 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// DeckSeparator joins the names of nested decks, as in "Languages::Spanish::Verbs"
+const DeckSeparator = "::"
+
+// ParentName returns the name of the deck a nested deck sits under, or "" at the top level
+func ParentName(name string) string {
+	if i := strings.LastIndex(name, DeckSeparator); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// LeafName returns the last part of a nested deck name
+func LeafName(name string) string {
+	if i := strings.LastIndex(name, DeckSeparator); i >= 0 {
+		return name[i+len(DeckSeparator):]
+	}
+	return name
+}
+
+// IsWithin reports whether the deck named name is ancestor or one of its descendants
+func IsWithin(name, ancestor string) bool {
+	return name == ancestor || strings.HasPrefix(name, ancestor+DeckSeparator)
+}
+
+// DecksWithin returns the deck named name, if there is one, and all decks nested under it
+func DecksWithin(decks []*Deck, name string) []*Deck {
+	var within []*Deck
+	for _, deck := range decks {
+		if IsWithin(deck.Name, name) {
+			within = append(within, deck)
+		}
+	}
+	sort.SliceStable(within, func(i, j int) bool {
+		return within[i].Name < within[j].Name
+	})
+	return within
+}
+
+// DeckNode is a deck in the hierarchy. Parents that only exist through their children's
+// names have no deck of their own
+type DeckNode struct {
+	Name     string // Full name, like "Languages::Spanish"
+	Deck     *Deck  // nil when no deck has this exact name
+	Depth    int
+	Children []*DeckNode
+}
+
+// Label returns the node's own part of the name
+func (n *DeckNode) Label() string {
+	return LeafName(n.Name)
+}
+
+// Decks returns the node's deck and the decks of all its descendants
+func (n *DeckNode) Decks() []*Deck {
+	var decks []*Deck
+	if n.Deck != nil {
+		decks = append(decks, n.Deck)
+	}
+	for _, child := range n.Children {
+		decks = append(decks, child.Decks()...)
+	}
+	return decks
+}
+
+// CardStats adds up GetCardStats over the node's deck and its descendants
+func (n *DeckNode) CardStats() (total, newCards, reviewCards int) {
+	for _, deck := range n.Decks() {
+		t, nc, rc := deck.GetCardStats()
+		total += t
+		newCards += nc
+		reviewCards += rc
+	}
+	return total, newCards, reviewCards
+}
+
+// Remaining adds up what is left of today's limits over the node's deck and its descendants
+func (n *DeckNode) Remaining(limits DailyLimits, day string) (newCards, reviews int) {
+	for _, deck := range n.Decks() {
+		nc, rc := deck.Remaining(limits, day)
+		newCards += nc
+		reviews += rc
+	}
+	return newCards, reviews
+}
+
+// BuildDeckTree arranges decks into a tree by their names, sorted alphabetically
+func BuildDeckTree(decks []*Deck) []*DeckNode {
+	nodes := make(map[string]*DeckNode)
+	var roots []*DeckNode
+
+	// node returns the node for name, creating it and its missing ancestors
+	var node func(name string) *DeckNode
+	node = func(name string) *DeckNode {
+		if existing, ok := nodes[name]; ok {
+			return existing
+		}
+		created := &DeckNode{Name: name, Depth: strings.Count(name, DeckSeparator)}
+		nodes[name] = created
+		if parent := ParentName(name); parent != "" {
+			parentNode := node(parent)
+			parentNode.Children = append(parentNode.Children, created)
+		} else {
+			roots = append(roots, created)
+		}
+		return created
+	}
+
+	for _, deck := range decks {
+		n := node(deck.Name)
+		if n.Deck == nil {
+			n.Deck = deck
+			continue
+		}
+
+		// Two decks share a name, so show the second one as a sibling under the same parent
+		duplicate := &DeckNode{Name: deck.Name, Deck: deck, Depth: n.Depth}
+		if parent := ParentName(deck.Name); parent != "" {
+			parentNode := nodes[parent]
+			parentNode.Children = append(parentNode.Children, duplicate)
+		} else {
+			roots = append(roots, duplicate)
+		}
+	}
+
+	sortDeckNodes(roots)
+	return roots
+}
+
+// sortDeckNodes sorts nodes and their children by name
+func sortDeckNodes(nodes []*DeckNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+	for _, n := range nodes {
+		sortDeckNodes(n.Children)
+	}
+}
+
+// FlattenDeckTree lists the nodes in display order, leaving out the children of collapsed nodes
+func FlattenDeckTree(roots []*DeckNode, collapsed map[string]bool) []*DeckNode {
+	var rows []*DeckNode
+	for _, n := range roots {
+		rows = append(rows, n)
+		if !collapsed[n.Name] {
+			rows = append(rows, FlattenDeckTree(n.Children, collapsed)...)
+		}
+	}
+	return rows
+}
+
+// MoveCards moves cards to another deck. Cards move along with their siblings and note,
+// keeping their scheduling state
+func (d *Deck) MoveCards(cardIDs []string, to *Deck) int {
+	moving := make(map[string]bool)
+	notes := make(map[string]bool)
+	for _, card := range d.Cards {
+		for _, id := range cardIDs {
+			if card.ID == id {
+				moving[card.ID] = true
+				if card.NoteID != "" {
+					notes[card.NoteID] = true
+				}
+			}
+		}
+	}
+
+	moved := 0
+	kept := d.Cards[:0]
+	for _, card := range d.Cards {
+		if moving[card.ID] || notes[card.NoteID] && card.NoteID != "" {
+			to.Cards = append(to.Cards, card)
+			moved++
+			continue
+		}
+		kept = append(kept, card)
+	}
+	d.Cards = kept
+
+	keptNotes := d.Notes[:0]
+	for _, note := range d.Notes {
+		if notes[note.ID] {
+			to.Notes = append(to.Notes, note)
+			continue
+		}
+		keptNotes = append(keptNotes, note)
+	}
+	d.Notes = keptNotes
+
+	d.MarkModified()
+	to.MarkModified()
+	return moved
+}
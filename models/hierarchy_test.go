@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildDeckTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		decks []string
+		want  []string // Flattened nodes as depth, name and whether they have a deck
+	}{
+		{
+			"nested",
+			[]string{"Languages::Spanish", "Leetcode", "Languages::French::Verbs"},
+			[]string{"0 Languages -", "1 Languages::French -", "2 Languages::French::Verbs +", "1 Languages::Spanish +", "0 Leetcode +"},
+		},
+		{
+			"duplicate top-level name",
+			[]string{"Spanish", "Spanish"},
+			[]string{"0 Spanish +", "0 Spanish +"},
+		},
+		{
+			"duplicate nested name stays under its parent",
+			[]string{"A::B", "A", "A::B", "A::B::C"},
+			[]string{"0 A +", "1 A::B +", "2 A::B::C +", "1 A::B +"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decks []*Deck
+			for _, name := range tt.decks {
+				decks = append(decks, NewDeck(name, ""))
+			}
+
+			var got []string
+			for _, node := range FlattenDeckTree(BuildDeckTree(decks), nil) {
+				hasDeck := "-"
+				if node.Deck != nil {
+					hasDeck = "+"
+				}
+				if node.Depth != strings.Count(node.Name, DeckSeparator) {
+					t.Errorf("%s has depth %d", node.Name, node.Depth)
+				}
+				got = append(got, fmt.Sprintf("%d %s %s", node.Depth, node.Name, hasDeck))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tree = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import "sort"
+
+// LeechTag is added to the note of a card once it becomes a leech
+const LeechTag = "leech"
+
+// Leech actions accepted in configuration
+const (
+	LeechTagOnly = "tag"     // Tag the note and keep studying the card
+	LeechSuspend = "suspend" // Tag the note and suspend the card
+)
+
+// LeechSettings decide when a card that keeps being forgotten becomes a leech and what
+// happens to it then
+type LeechSettings struct {
+	Threshold int    // Lapses that make a card a leech, or 0 to turn leech detection off
+	Action    string // LeechTagOnly or LeechSuspend
+}
+
+// IsLeech reports whether the card has lapsed often enough to be a leech, or was tagged as one
+func (c *Card) IsLeech(threshold int) bool {
+	return threshold > 0 && c.Lapses >= threshold || c.HasTag(LeechTag)
+}
+
+// reachedLeech reports whether the card's latest lapse makes it a leech. Like Anki, a card
+// that stays a leech is handled again every half threshold lapses after the first time
+func (c *Card) reachedLeech(threshold int) bool {
+	if threshold <= 0 || c.Lapses < threshold {
+		return false
+	}
+	return (c.Lapses-threshold)%max(threshold/2, 1) == 0
+}
+
+// HandleLeech applies the leech action to a card that has just lapsed, reporting whether
+// the card became a leech
+func (d *Deck) HandleLeech(cardID string, settings LeechSettings) bool {
+	card := d.GetCard(cardID)
+	if card == nil || !card.reachedLeech(settings.Threshold) {
+		return false
+	}
+	d.TagCards([]string{cardID}, []string{LeechTag}, nil)
+	if settings.Action == LeechSuspend {
+		d.SetSuspended([]string{cardID}, true)
+	}
+	return true
+}
+
+// Leeches returns the leeches among decks with the most lapses first
+func Leeches(decks []*Deck, threshold int) []SearchResult {
+	var leeches []SearchResult
+	for _, deck := range decks {
+		for i := range deck.Cards {
+			if deck.Cards[i].IsLeech(threshold) {
+				leeches = append(leeches, SearchResult{Deck: deck, Card: &deck.Cards[i]})
+			}
+		}
+	}
+	sort.SliceStable(leeches, func(i, j int) bool {
+		return leeches[i].Card.Lapses > leeches[j].Card.Lapses
+	})
+	return leeches
+}
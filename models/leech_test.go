@@ -0,0 +1,58 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHandleLeech(t *testing.T) {
+	tests := []struct {
+		name          string
+		lapses        int
+		settings      LeechSettings
+		wantLeech     bool
+		wantSuspended bool
+	}{
+		{"below the threshold", 7, LeechSettings{Threshold: 8, Action: LeechSuspend}, false, false},
+		{"at the threshold", 8, LeechSettings{Threshold: 8, Action: LeechSuspend}, true, true},
+		{"tag only", 8, LeechSettings{Threshold: 8, Action: LeechTagOnly}, true, false},
+		{"between repeats", 11, LeechSettings{Threshold: 8, Action: LeechSuspend}, false, false},
+		{"half the threshold later", 12, LeechSettings{Threshold: 8, Action: LeechSuspend}, true, true},
+		{"every lapse at threshold one", 3, LeechSettings{Threshold: 1, Action: LeechTagOnly}, true, false},
+		{"detection off", 20, LeechSettings{Threshold: 0, Action: LeechSuspend}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := NewDeck("Spanish", "")
+			deck.AddNote(NewNote(ReversedNote, "perro", "dog"), nil)
+			card, sibling := &deck.Cards[0], &deck.Cards[1]
+			card.Lapses = tt.lapses
+
+			if got := deck.HandleLeech(card.ID, tt.settings); got != tt.wantLeech {
+				t.Errorf("HandleLeech() = %v, want %v", got, tt.wantLeech)
+			}
+			// The tag belongs to the note, but only the lapsed card is suspended
+			if card.HasTag(LeechTag) != tt.wantLeech || sibling.HasTag(LeechTag) != tt.wantLeech {
+				t.Errorf("card tags %q and sibling tags %q, want leech tag %v", card.Tags, sibling.Tags, tt.wantLeech)
+			}
+			if card.Suspended != tt.wantSuspended || sibling.Suspended {
+				t.Errorf("suspended = %v and sibling %v, want %v and false", card.Suspended, sibling.Suspended, tt.wantSuspended)
+			}
+		})
+	}
+}
+
+func TestLeeches(t *testing.T) {
+	verbs := NewDeck("Verbs", "")
+	verbs.Cards = []Card{{ID: "ser", Lapses: 9}, {ID: "ir", Lapses: 2}, {ID: "tener", Lapses: 1, Tags: []string{LeechTag}}}
+	nouns := NewDeck("Nouns", "")
+	nouns.Cards = []Card{{ID: "perro", Lapses: 12}, {ID: "gato"}}
+
+	var got []string
+	for _, leech := range Leeches([]*Deck{verbs, nouns}, 8) {
+		got = append(got, leech.Card.ID)
+	}
+	if want := []string{"perro", "ser", "tener"}; !slices.Equal(got, want) {
+		t.Errorf("Leeches() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NoteType decides which cards a note generates: one of the built-in types below, or the
+// ID of a CustomNoteType
+type NoteType string
+
+const (
+	BasicNote    NoteType = "basic"    // One front to back card
+	ReversedNote NoteType = "reversed" // A front to back card and a back to front card
+	ClozeNote    NoteType = "cloze"    // One card per cloze number in the front
+)
+
+// NoteTypes lists the built-in note types in the order offered by the editor
+var NoteTypes = []NoteType{BasicNote, ReversedNote, ClozeNote}
+
+// importedNotePrefix starts the type of notes imported from Anki with card templates
+// anktui does not have
+const importedNotePrefix = "anki:"
+
+// ImportedNoteType returns the type of notes imported with the named Anki note type
+func ImportedNoteType(name string) NoteType {
+	return NoteType(importedNotePrefix + name)
+}
+
+// IsImported reports whether notes of the type were imported with card templates anktui
+// does not have. Their cards can't be regenerated, so each one is edited on its own
+func (t NoteType) IsImported() bool {
+	return strings.HasPrefix(string(t), importedNotePrefix)
+}
+
+// IsBuiltIn reports whether the note type is one of NoteTypes rather than a custom one
+func (t NoteType) IsBuiltIn() bool {
+	return slices.Contains(NoteTypes, t)
+}
+
+// String returns a human-readable name for the note type
+func (t NoteType) String() string {
+	switch t {
+	case BasicNote:
+		return "Basic"
+	case ReversedNote:
+		return "Basic (and reversed card)"
+	case ClozeNote:
+		return "Cloze"
+	default:
+		if t.IsImported() {
+			return strings.TrimPrefix(string(t), importedNotePrefix) + " (imported)"
+		}
+		return string(t)
+	}
+}
+
+// Note holds the content that one or more cards of a deck are generated from.
+// Each card keeps its own scheduling state and links back through Card.NoteID
+type Note struct {
+	ID       string            `json:"id"`
+	Type     NoteType          `json:"type"`
+	Front    string            `json:"front"`
+	Back     string            `json:"back"`
+	Fields   map[string]string `json:"fields,omitempty"` // Field values of notes of a custom type
+	Created  time.Time         `json:"created"`
+	Modified time.Time         `json:"modified"`
+}
+
+// NewNote creates a note of the given type
+func NewNote(noteType NoteType, front, back string) *Note {
+	now := time.Now()
+	return &Note{
+		ID:       uuid.New().String(),
+		Type:     noteType,
+		Front:    front,
+		Back:     back,
+		Created:  now,
+		Modified: now,
+	}
+}
+
+// cardContent is the generated content of one card of a note
+type cardContent struct {
+	front, back string
+	cardType    CardType
+}
+
+// cards returns the content of each card the note generates, keyed by ordinal. Notes of
+// a custom type need that type to be passed in; without it ok is false
+func (n *Note) cards(custom *CustomNoteType) (cards map[int]cardContent, ok bool) {
+	switch n.Type {
+	case ClozeNote:
+		cards := make(map[int]cardContent)
+		for _, number := range ClozeNumbers(n.Front) {
+			cards[number] = cardContent{n.Front, n.Back, ClozeCard}
+		}
+		return cards, true
+	case ReversedNote:
+		return map[int]cardContent{
+			1: {n.Front, n.Back, BasicCard},
+			2: {n.Back, n.Front, BasicCard},
+		}, true
+	case BasicNote, "":
+		return map[int]cardContent{1: {n.Front, n.Back, BasicCard}}, true
+	default:
+		if custom == nil || custom.ID != n.Type {
+			return nil, false
+		}
+		return custom.cards(n), true
+	}
+}
+
+// GetNote returns a note by ID
+func (d *Deck) GetNote(noteID string) *Note {
+	for i := range d.Notes {
+		if d.Notes[i].ID == noteID {
+			return &d.Notes[i]
+		}
+	}
+	return nil
+}
+
+// NoteFor returns the note a card was generated from. Cards created before notes existed
+// get a note built from their own content
+func (d *Deck) NoteFor(card *Card) *Note {
+	if card.NoteID != "" {
+		if note := d.GetNote(card.NoteID); note != nil {
+			return note
+		}
+	}
+
+	noteType := BasicNote
+	if card.Type == ClozeCard {
+		noteType = ClozeNote
+	}
+	note := NewNote(noteType, card.Front, card.Back)
+	note.Created = card.Created
+
+	if card.NoteID != "" {
+		// Cloze siblings already share an ID
+		note.ID = card.NoteID
+	} else {
+		card.NoteID = note.ID
+		card.Ord = 1
+	}
+	d.Notes = append(d.Notes, *note)
+	return &d.Notes[len(d.Notes)-1]
+}
+
+// AddNote adds a note to the deck along with the cards it generates. custom is the
+// note's type when it is a custom one, and nil for the built-in types
+func (d *Deck) AddNote(note *Note, custom *CustomNoteType) {
+	d.Notes = append(d.Notes, *note)
+	d.syncNoteCards(note, custom)
+	d.MarkModified()
+}
+
+// UpdateNote changes a note's type and content to those of edited, a changed copy of it,
+// and regenerates its cards. Cards whose ordinal still exists keep their progress, cards
+// the note no longer generates are removed and new ones are added. custom is the new type
+// when it is a custom one. Imported notes keep their type and cards, which are edited with
+// UpdateCardContent instead
+func (d *Deck) UpdateNote(edited *Note, custom *CustomNoteType) {
+	note := d.GetNote(edited.ID)
+	if note == nil || note.Type.IsImported() {
+		return
+	}
+	note.Type = edited.Type
+	note.Front = edited.Front
+	note.Back = edited.Back
+	note.Fields = edited.Fields
+	note.Modified = time.Now()
+	d.syncNoteCards(note, custom)
+	d.MarkModified()
+}
+
+// UpdateCardContent changes the front and back of one card of an imported note, leaving
+// its siblings and the note's type as they are
+func (d *Deck) UpdateCardContent(cardID, front, back string) {
+	card := d.GetCard(cardID)
+	if card == nil {
+		return
+	}
+	if card.Front != front || card.Back != back {
+		card.UpdateContent(front, back)
+	}
+	if note := d.GetNote(card.NoteID); note != nil {
+		note.Modified = time.Now()
+	}
+	d.MarkModified()
+}
+
+// syncNoteCards brings the deck's cards in line with what note generates. The cards of a
+// note whose custom type is missing are left as they are
+func (d *Deck) syncNoteCards(note *Note, custom *CustomNoteType) {
+	wanted, ok := note.cards(custom)
+	if !ok {
+		return
+	}
+
+	// Cards added to the note take the tags of the ones it already has
+	var tags []string
+	existing := make(map[int]bool)
+	kept := d.Cards[:0]
+	for _, card := range d.Cards {
+		if card.NoteID == note.ID {
+			tags = card.Tags
+			content, ok := wanted[card.Ord]
+			if !ok || existing[card.Ord] {
+				continue
+			}
+			existing[card.Ord] = true
+			card.Type = content.cardType
+			if card.Front != content.front || card.Back != content.back {
+				card.UpdateContent(content.front, content.back)
+			}
+		}
+		kept = append(kept, card)
+	}
+	d.Cards = kept
+
+	for _, ord := range sortedOrds(wanted) {
+		if existing[ord] {
+			continue
+		}
+		content := wanted[ord]
+		card := NewCard(content.front, content.back)
+		card.Type = content.cardType
+		card.NoteID = note.ID
+		card.Ord = ord
+		card.Tags = slices.Clone(tags)
+		d.Cards = append(d.Cards, *card)
+	}
+}
+
+// sortedOrds returns the ordinals of generated cards in ascending order
+func sortedOrds(cards map[int]cardContent) []int {
+	ords := make([]int, 0, len(cards))
+	for ord := range cards {
+		ords = append(ords, ord)
+	}
+	sort.Ints(ords)
+	return ords
+}
+
+// removeOrphanNote removes a note once none of its cards are left
+func (d *Deck) removeOrphanNote(noteID string) {
+	if noteID == "" {
+		return
+	}
+	for _, card := range d.Cards {
+		if card.NoteID == noteID {
+			return
+		}
+	}
+	for i, note := range d.Notes {
+		if note.ID == noteID {
+			d.Notes = append(d.Notes[:i], d.Notes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Siblings returns the other cards generated from the same note as card
+func (d *Deck) Siblings(card *Card) []*Card {
+	if card.NoteID == "" {
+		return nil
+	}
+	var siblings []*Card
+	for i := range d.Cards {
+		if d.Cards[i].NoteID == card.NoteID && d.Cards[i].ID != card.ID {
+			siblings = append(siblings, &d.Cards[i])
+		}
+	}
+	return siblings
+}
+
+// siblingStudiedOn reports whether another card of card's note was studied on day
+func (d *Deck) siblingStudiedOn(card *Card, day string, rolloverHour int) bool {
+	for _, sibling := range d.Siblings(card) {
+		if !sibling.LastReview.IsZero() && StudyDay(sibling.LastReview, rolloverHour) == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,345 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// frontSideField stands for the rendered front of the card in back templates
+const frontSideField = "FrontSide"
+
+// CardTemplate renders one card of a custom note type. Templates use {{Field}} for a
+// field's value, {{#Field}}...{{/Field}} for text shown only when the field is not empty,
+// {{^Field}}...{{/Field}} for text shown only when it is, and {{FrontSide}} on the back
+// for the rendered front. Anki filters such as {{text:Field}} are ignored
+type CardTemplate struct {
+	Ord         int    `json:"ord,omitempty"` // Card.Ord of the template's cards, kept when other templates are removed or moved
+	Name        string `json:"name"`
+	Front       string `json:"front"`
+	Back        string `json:"back"`
+	AnswerField string `json:"answer_field,omitempty"` // Field typed answers are compared with; empty compares with the back
+}
+
+// CustomNoteType is a user-defined note type with named fields and one card template per
+// card its notes generate. Notes of the type store its ID as their Type
+type CustomNoteType struct {
+	ID        NoteType       `json:"id"`
+	Name      string         `json:"name"`
+	Fields    []string       `json:"fields"`
+	Templates []CardTemplate `json:"templates"`
+	Created   time.Time      `json:"created"`
+	Modified  time.Time      `json:"modified"`
+}
+
+// NewCustomNoteType creates a note type with the given fields and templates
+func NewCustomNoteType(name string, fields []string, templates []CardTemplate) *CustomNoteType {
+	now := time.Now()
+	noteType := &CustomNoteType{
+		ID:        NoteType(uuid.New().String()),
+		Name:      name,
+		Fields:    fields,
+		Templates: templates,
+		Created:   now,
+		Modified:  now,
+	}
+	noteType.AssignOrds()
+	return noteType
+}
+
+// AssignOrds gives templates without an ordinal the next unused one. Note types saved before
+// templates had ordinals get their positions, which their cards were generated with
+func (t *CustomNoteType) AssignOrds() {
+	next := NextTemplateOrd(t.Templates)
+	for i := range t.Templates {
+		if t.Templates[i].Ord == 0 {
+			t.Templates[i].Ord = next
+			next++
+		}
+	}
+}
+
+// NextTemplateOrd returns the ordinal for a template added after templates
+func NextTemplateOrd(templates []CardTemplate) int {
+	next := 1
+	for _, template := range templates {
+		next = max(next, template.Ord+1)
+	}
+	return next
+}
+
+// Template returns the template that generates the cards with the given ordinal, or nil
+func (t *CustomNoteType) Template(ord int) *CardTemplate {
+	for i := range t.Templates {
+		if t.Templates[i].Ord == ord {
+			return &t.Templates[i]
+		}
+	}
+	return nil
+}
+
+// FindNoteType returns the note type with the given ID, or nil
+func FindNoteType(noteTypes []*CustomNoteType, id NoteType) *CustomNoteType {
+	for _, noteType := range noteTypes {
+		if noteType.ID == id {
+			return noteType
+		}
+	}
+	return nil
+}
+
+// Validate checks that the note type has a name, distinct fields and templates that only
+// use its fields
+func (t *CustomNoteType) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("the note type needs a name")
+	}
+	if len(t.Fields) == 0 {
+		return fmt.Errorf("the note type needs at least one field")
+	}
+	for i, field := range t.Fields {
+		switch {
+		case field == "":
+			return fmt.Errorf("field %d has no name", i+1)
+		case strings.ContainsAny(field, "{}#^/:"):
+			return fmt.Errorf("field name %q cannot contain braces, #, ^, / or :", field)
+		case field == frontSideField:
+			return fmt.Errorf("%s is reserved for the rendered front", frontSideField)
+		case slices.Contains(t.Fields[:i], field):
+			return fmt.Errorf("field %q appears twice", field)
+		}
+	}
+	if len(t.Templates) == 0 {
+		return fmt.Errorf("the note type needs at least one card template")
+	}
+
+	for i, template := range t.Templates {
+		name := template.Name
+		if name == "" {
+			name = fmt.Sprintf("Card %d", i+1)
+		}
+		if template.Ord < 1 || slices.ContainsFunc(t.Templates[:i], func(other CardTemplate) bool { return other.Ord == template.Ord }) {
+			return fmt.Errorf("%s needs its own card number", name)
+		}
+		if strings.TrimSpace(template.Front) == "" {
+			return fmt.Errorf("the front of %s is empty", name)
+		}
+		if err := t.checkTemplate(template.Front, false); err != nil {
+			return fmt.Errorf("front of %s: %w", name, err)
+		}
+		if err := t.checkTemplate(template.Back, true); err != nil {
+			return fmt.Errorf("back of %s: %w", name, err)
+		}
+		if template.AnswerField != "" && !slices.Contains(t.Fields, template.AnswerField) {
+			return fmt.Errorf("the answer field of %s, %q, is not a field of the note type", name, template.AnswerField)
+		}
+	}
+	return nil
+}
+
+// checkTemplate parses a template and checks the fields it refers to
+func (t *CustomNoteType) checkTemplate(text string, back bool) error {
+	nodes, err := parseTemplate(text)
+	if err != nil {
+		return err
+	}
+	var check func(nodes []templateNode) error
+	check = func(nodes []templateNode) error {
+		for _, node := range nodes {
+			if node.field == "" {
+				continue
+			}
+			if !slices.Contains(t.Fields, node.field) && !(back && node.field == frontSideField) {
+				return fmt.Errorf("unknown field {{%s}}", node.field)
+			}
+			if err := check(node.children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(nodes)
+}
+
+// GeneratesCards reports whether a note with these field values would have any cards,
+// which needs at least one template with a non-empty front
+func (t *CustomNoteType) GeneratesCards(fields map[string]string) bool {
+	return len(t.cards(&Note{Fields: fields})) > 0
+}
+
+// cards renders each template whose front is not empty for the note, keyed by the template's
+// ordinal
+func (t *CustomNoteType) cards(note *Note) map[int]cardContent {
+	cards := make(map[int]cardContent)
+	for _, template := range t.Templates {
+		front := strings.TrimSpace(RenderTemplate(template.Front, note.Fields))
+		if front == "" {
+			continue
+		}
+		fields := map[string]string{frontSideField: front}
+		for name, value := range note.Fields {
+			fields[name] = value
+		}
+		back := strings.TrimSpace(RenderTemplate(template.Back, fields))
+		cards[template.Ord] = cardContent{front, back, TemplateCard}
+	}
+	return cards
+}
+
+// NewCustomNote creates a note of a custom note type from its field values
+func NewCustomNote(noteType *CustomNoteType, fields map[string]string) *Note {
+	note := NewNote(noteType.ID, "", "")
+	note.Fields = fields
+	return note
+}
+
+// ApplyNoteType renders the cards of the deck's notes of a note type again after it was
+// edited, keeping the progress of cards whose template still exists. renames maps renamed
+// fields to their old names, and those fields keep their values; fields no longer in the
+// type are dropped. It returns the number of notes updated
+func (d *Deck) ApplyNoteType(old, noteType *CustomNoteType, renames map[string]string) int {
+	updated := 0
+	for i := range d.Notes {
+		note := &d.Notes[i]
+		if note.Type != noteType.ID {
+			continue
+		}
+		note.Fields = remapFields(old.Fields, noteType.Fields, renames, note.Fields)
+		note.Modified = time.Now()
+		d.syncNoteCards(note, noteType)
+		updated++
+	}
+	if updated > 0 {
+		d.MarkModified()
+	}
+	return updated
+}
+
+// CountNotesOfType returns how many of the deck's notes are of a note type
+func (d *Deck) CountNotesOfType(id NoteType) int {
+	count := 0
+	for _, note := range d.Notes {
+		if note.Type == id {
+			count++
+		}
+	}
+	return count
+}
+
+// remapFields carries field values over to a changed list of fields. Fields keep their
+// values by name, and a field renamed from an old one takes that field's value. Values of
+// fields that were removed are dropped rather than guessed onto new fields
+func remapFields(oldFields, newFields []string, renames map[string]string, values map[string]string) map[string]string {
+	remapped := make(map[string]string)
+	for _, field := range newFields {
+		source := field
+		if from, ok := renames[field]; ok {
+			source = from
+		}
+		if value, ok := values[source]; ok && slices.Contains(oldFields, source) {
+			remapped[field] = value
+		}
+	}
+	return remapped
+}
+
+// templateNode is literal text, a field substitution, or a section shown depending on
+// whether a field is empty
+type templateNode struct {
+	text     string // Literal text when field is empty
+	field    string
+	section  bool
+	inverted bool // {{^Field}} sections are shown when the field is empty
+	children []templateNode
+}
+
+// parseTemplate splits a template into text, fields and nested sections
+func parseTemplate(text string) ([]templateNode, error) {
+	type openSection struct {
+		node  templateNode
+		nodes []templateNode // Nodes of the enclosing level
+	}
+	var stack []openSection
+	var nodes []templateNode
+
+	for text != "" {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			nodes = append(nodes, templateNode{text: text})
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, templateNode{text: text[:start]})
+		}
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed {{ in template")
+		}
+		tag := strings.TrimSpace(text[start+2 : start+end])
+		text = text[start+end+2:]
+
+		switch {
+		case strings.HasPrefix(tag, "#") || strings.HasPrefix(tag, "^"):
+			section := templateNode{field: templateField(tag[1:]), section: true, inverted: tag[0] == '^'}
+			stack = append(stack, openSection{node: section, nodes: nodes})
+			nodes = nil
+		case strings.HasPrefix(tag, "/"):
+			name := templateField(tag[1:])
+			if len(stack) == 0 || stack[len(stack)-1].node.field != name {
+				return nil, fmt.Errorf("{{/%s}} does not close an open section", name)
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			open.node.children = nodes
+			nodes = append(open.nodes, open.node)
+		case tag == "":
+			return nil, fmt.Errorf("empty {{}} in template")
+		default:
+			nodes = append(nodes, templateNode{field: templateField(tag)})
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("{{#%s}} is never closed", stack[len(stack)-1].node.field)
+	}
+	return nodes, nil
+}
+
+// RenderTemplate fills in a template with field values. Templates that do not parse are
+// returned unchanged
+func RenderTemplate(text string, fields map[string]string) string {
+	nodes, err := parseTemplate(text)
+	if err != nil {
+		return text
+	}
+	var b strings.Builder
+	renderTemplateNodes(&b, nodes, fields)
+	return b.String()
+}
+
+// templateField returns the field a tag refers to, dropping Anki filters such as text: or hint:
+func templateField(tag string) string {
+	if i := strings.LastIndex(tag, ":"); i >= 0 {
+		tag = tag[i+1:]
+	}
+	return strings.TrimSpace(tag)
+}
+
+// renderTemplateNodes writes the rendered nodes to b
+func renderTemplateNodes(b *strings.Builder, nodes []templateNode, fields map[string]string) {
+	for _, node := range nodes {
+		switch {
+		case node.section:
+			if (strings.TrimSpace(fields[node.field]) != "") != node.inverted {
+				renderTemplateNodes(b, node.children, fields)
+			}
+		case node.field != "":
+			b.WriteString(fields[node.field])
+		default:
+			b.WriteString(node.text)
+		}
+	}
+}
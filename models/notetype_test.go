@@ -0,0 +1,154 @@
+package models
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	fields := map[string]string{
+		"Word":    "perro",
+		"Meaning": "dog",
+		"Example": "",
+		"Blank":   "  ",
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"plain text", "no fields here", "no fields here"},
+		{"field", "{{Word}} means {{Meaning}}", "perro means dog"},
+		{"spaces inside braces", "{{ Word }}", "perro"},
+		{"unknown field", "[{{Missing}}]", "[]"},
+		{"anki filter", "{{text:Word}} {{hint:Meaning}}", "perro dog"},
+		{"section shown", "{{#Meaning}}({{Meaning}}){{/Meaning}}", "(dog)"},
+		{"section hidden", "a{{#Example}}({{Example}}){{/Example}}b", "ab"},
+		{"blank counts as empty", "a{{#Blank}}x{{/Blank}}b", "ab"},
+		{"inverted section shown", "{{^Example}}no example{{/Example}}", "no example"},
+		{"inverted section hidden", "{{^Word}}no word{{/Word}}", ""},
+		{"nested sections", "{{#Word}}{{Word}}{{#Example}}: {{Example}}{{/Example}}{{^Example}}!{{/Example}}{{/Word}}", "perro!"},
+		{"unclosed tag", "{{Word", "{{Word"},
+		{"unclosed section", "{{#Word}}{{Word}}", "{{#Word}}{{Word}}"},
+		{"mismatched close", "{{#Word}}x{{/Meaning}}", "{{#Word}}x{{/Meaning}}"},
+		{"empty tag", "{{}}", "{{}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderTemplate(tt.template, fields); got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomNoteTypeValidate(t *testing.T) {
+	valid := func() *CustomNoteType {
+		return NewCustomNoteType("Vocab", []string{"Word", "Meaning"}, []CardTemplate{
+			{Name: "Recognise", Front: "{{Word}}", Back: "{{FrontSide}}\n---\n{{Meaning}}"},
+			{Name: "Recall", Front: "{{Meaning}}", Back: "{{Word}}"},
+		})
+	}
+	tests := []struct {
+		name    string
+		change  func(noteType *CustomNoteType)
+		wantErr bool
+	}{
+		{"valid", func(*CustomNoteType) {}, false},
+		{"no name", func(nt *CustomNoteType) { nt.Name = " " }, true},
+		{"no fields", func(nt *CustomNoteType) { nt.Fields = nil }, true},
+		{"empty field name", func(nt *CustomNoteType) { nt.Fields[1] = "" }, true},
+		{"field name with a colon", func(nt *CustomNoteType) { nt.Fields[1] = "text:Meaning" }, true},
+		{"field name with braces", func(nt *CustomNoteType) { nt.Fields[1] = "{Meaning}" }, true},
+		{"reserved field name", func(nt *CustomNoteType) { nt.Fields[1] = "FrontSide" }, true},
+		{"duplicate field", func(nt *CustomNoteType) { nt.Fields[1] = "Word" }, true},
+		{"no templates", func(nt *CustomNoteType) { nt.Templates = nil }, true},
+		{"empty front", func(nt *CustomNoteType) { nt.Templates[0].Front = " " }, true},
+		{"unknown field", func(nt *CustomNoteType) { nt.Templates[0].Front = "{{Spelling}}" }, true},
+		{"FrontSide on the front", func(nt *CustomNoteType) { nt.Templates[0].Front = "{{FrontSide}}" }, true},
+		{"unclosed section", func(nt *CustomNoteType) { nt.Templates[1].Back = "{{#Word}}{{Word}}" }, true},
+		{"missing ord", func(nt *CustomNoteType) { nt.Templates[1].Ord = 0 }, true},
+		{"duplicate ord", func(nt *CustomNoteType) { nt.Templates[1].Ord = nt.Templates[0].Ord }, true},
+		{"answer field", func(nt *CustomNoteType) { nt.Templates[1].AnswerField = "Word" }, false},
+		{"unknown answer field", func(nt *CustomNoteType) { nt.Templates[1].AnswerField = "Spelling" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noteType := valid()
+			tt.change(noteType)
+			if err := noteType.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssignOrds(t *testing.T) {
+	tests := []struct {
+		name string
+		ords []int
+		want []int
+	}{
+		{"saved before ords", []int{0, 0, 0}, []int{1, 2, 3}},
+		{"already assigned", []int{2, 1}, []int{2, 1}},
+		{"new template after a removed one", []int{1, 3, 0}, []int{1, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noteType := &CustomNoteType{}
+			for _, ord := range tt.ords {
+				noteType.Templates = append(noteType.Templates, CardTemplate{Ord: ord})
+			}
+			noteType.AssignOrds()
+			for i, template := range noteType.Templates {
+				if template.Ord != tt.want[i] {
+					t.Errorf("template %d has ord %d, want %d", i, template.Ord, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRemapFields(t *testing.T) {
+	values := map[string]string{"Word": "perro", "Meaning": "dog", "Notes": "noun"}
+	oldFields := []string{"Word", "Meaning", "Notes"}
+	tests := []struct {
+		name      string
+		newFields []string
+		renames   map[string]string
+		want      map[string]string
+	}{
+		{"unchanged", oldFields, nil, values},
+		{"reordered", []string{"Notes", "Word", "Meaning"}, nil, values},
+		{"field removed", []string{"Word", "Meaning"}, nil, map[string]string{"Word": "perro", "Meaning": "dog"}},
+		{"field added", []string{"Word", "Meaning", "Notes", "Audio"}, nil, values},
+		{
+			"removed and added in its place is not a rename",
+			[]string{"Word", "Meaning", "Example"}, nil,
+			map[string]string{"Word": "perro", "Meaning": "dog"},
+		},
+		{
+			"renamed",
+			[]string{"Spanish", "Meaning", "Notes"}, map[string]string{"Spanish": "Word"},
+			map[string]string{"Spanish": "perro", "Meaning": "dog", "Notes": "noun"},
+		},
+		{
+			"renamed from a field that does not exist",
+			[]string{"Word", "Meaning", "Example"}, map[string]string{"Example": "Sentence"},
+			map[string]string{"Word": "perro", "Meaning": "dog"},
+		},
+		{
+			"swapped",
+			[]string{"Meaning", "Word"}, map[string]string{"Meaning": "Word", "Word": "Meaning"},
+			map[string]string{"Meaning": "perro", "Word": "dog"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := remapFields(oldFields, tt.newFields, tt.renames, values)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("remapFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
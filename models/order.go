@@ -0,0 +1,78 @@
+package models
+
+import (
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// ReviewOrder decides how the due cards of several decks are interleaved in one session
+type ReviewOrder string
+
+// Review orders accepted in configuration
+const (
+	OrderByDue         ReviewOrder = "due"     // Cards due earliest first
+	OrderByDeck        ReviewOrder = "deck"    // One card from each deck in turn
+	OrderRandom        ReviewOrder = "random"  // Shuffled
+	OrderByOverdueness ReviewOrder = "overdue" // Most overdue relative to their interval first
+)
+
+// orderCards sorts session cards by order, using cardDecks to find each card's deck. An
+// unknown or empty order keeps the cards as they are
+func orderCards(cards []Card, order ReviewOrder, cardDecks map[string]string, now time.Time) {
+	switch order {
+	case OrderByDue:
+		sortByDue(cards)
+	case OrderByDeck:
+		roundRobin(cards, cardDecks)
+	case OrderRandom:
+		rand.Shuffle(len(cards), func(i, j int) {
+			cards[i], cards[j] = cards[j], cards[i]
+		})
+	case OrderByOverdueness:
+		sort.SliceStable(cards, func(i, j int) bool {
+			return relativeOverdueness(&cards[i], now) > relativeOverdueness(&cards[j], now)
+		})
+	}
+}
+
+// sortByDue sorts cards with the earliest due first
+func sortByDue(cards []Card) {
+	sort.SliceStable(cards, func(i, j int) bool {
+		return cards[i].NextReview.Before(cards[j].NextReview)
+	})
+}
+
+// roundRobin takes the earliest due card of each deck in turn, in the order the decks first
+// appear
+func roundRobin(cards []Card, cardDecks map[string]string) {
+	var deckIDs []string
+	byDeck := make(map[string][]Card)
+	for _, card := range cards {
+		deckID := cardDecks[card.ID]
+		if _, ok := byDeck[deckID]; !ok {
+			deckIDs = append(deckIDs, deckID)
+		}
+		byDeck[deckID] = append(byDeck[deckID], card)
+	}
+	for _, deckID := range deckIDs {
+		sortByDue(byDeck[deckID])
+	}
+
+	i := 0
+	for round := 0; i < len(cards); round++ {
+		for _, deckID := range deckIDs {
+			if round < len(byDeck[deckID]) {
+				cards[i] = byDeck[deckID][round]
+				i++
+			}
+		}
+	}
+}
+
+// relativeOverdueness returns how overdue a card is as a share of its interval, so a card
+// two days late on a two day interval comes before one two days late on a month
+func relativeOverdueness(card *Card, now time.Time) float64 {
+	overdue := now.Sub(card.NextReview).Hours() / 24
+	return overdue / float64(max(card.Interval, 1))
+}
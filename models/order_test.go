@@ -0,0 +1,72 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOrderCards(t *testing.T) {
+	start := date(2025, 3, 1, 12)
+	card := func(id string, dueDay, interval int) Card {
+		return Card{ID: id, NextReview: start.AddDate(0, 0, dueDay), Interval: interval}
+	}
+	// Cards a, b and e belong to one deck and c and d to another
+	cards := []Card{card("a", 0, 10), card("c", 3, 2), card("b", 1, 1), card("d", 4, 20), card("e", 2, 4)}
+	cardDecks := map[string]string{"a": "verbs", "b": "verbs", "e": "verbs", "c": "nouns", "d": "nouns"}
+	now := start.AddDate(0, 0, 10)
+
+	tests := []struct {
+		order ReviewOrder
+		want  []string
+	}{
+		{OrderByDue, []string{"a", "b", "e", "c", "d"}},
+		{OrderByDeck, []string{"a", "c", "b", "d", "e"}},
+		{OrderByOverdueness, []string{"b", "c", "e", "a", "d"}}, // 9, 3.5, 2, 1 and 0.3 intervals late
+		{"", []string{"a", "c", "b", "d", "e"}},
+		{"unknown", []string{"a", "c", "b", "d", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			ordered := slices.Clone(cards)
+			orderCards(ordered, tt.order, cardDecks, now)
+			if got := cardIDs(ordered); !slices.Equal(got, tt.want) {
+				t.Errorf("order %q = %v, want %v", tt.order, got, tt.want)
+			}
+		})
+	}
+
+	t.Run(string(OrderRandom), func(t *testing.T) {
+		ordered := slices.Clone(cards)
+		orderCards(ordered, OrderRandom, cardDecks, now)
+		got := cardIDs(ordered)
+		slices.Sort(got)
+		if want := []string{"a", "b", "c", "d", "e"}; !slices.Equal(got, want) {
+			t.Errorf("shuffled cards = %v, want a permutation of %v", got, want)
+		}
+	})
+}
+
+func TestOrderByDeckUnevenDecks(t *testing.T) {
+	due := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cards := []Card{
+		{ID: "n1", NextReview: due},
+		{ID: "v1", NextReview: due},
+		{ID: "v2", NextReview: due.Add(time.Hour)},
+		{ID: "v3", NextReview: due.Add(2 * time.Hour)},
+	}
+	cardDecks := map[string]string{"n1": "nouns", "v1": "verbs", "v2": "verbs", "v3": "verbs"}
+	orderCards(cards, OrderByDeck, cardDecks, due)
+	if got, want := cardIDs(cards), []string{"n1", "v1", "v2", "v3"}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+// cardIDs returns the IDs of cards in order
+func cardIDs(cards []Card) []string {
+	ids := make([]string, len(cards))
+	for i, card := range cards {
+		ids[i] = card.ID
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewType describes what kind of review a log entry records
+type ReviewType string
+
+const (
+	ReviewTypeLearn   ReviewType = "learn"   // First successful path through a new card
+	ReviewTypeReview  ReviewType = "review"  // Scheduled review of a previously learned card
+	ReviewTypeRelearn ReviewType = "relearn" // Relearning step of a forgotten card
+)
+
+// ReviewLog records a single rating given to a card
+type ReviewLog struct {
+	ID        string        `json:"id"`
+	CardID    string        `json:"card_id"`
+	DeckID    string        `json:"deck_id"`
+	Timestamp time.Time     `json:"timestamp"`
+	Rating    Rating        `json:"rating"`
+	Type      ReviewType    `json:"type"`
+	Mode      StudyMode     `json:"mode"`
+	TimeTaken time.Duration `json:"time_taken"` // Time spent before answering
+
+	// Scheduling state before and after the rating. Delays are set while the card is in
+	// learning steps, which are shorter than its interval in days
+	PrevInterval int           `json:"prev_interval"`
+	NewInterval  int           `json:"new_interval"`
+	PrevDelay    time.Duration `json:"prev_delay,omitempty"`
+	NewDelay     time.Duration `json:"new_delay,omitempty"`
+	PrevEase     float64       `json:"prev_ease"`
+	NewEase      float64       `json:"new_ease"`
+}
+
+// NewReviewLog creates a log entry from a card's state before and after it was rated
+func NewReviewLog(deckID string, before, after *Card, rating Rating, timeTaken time.Duration, mode StudyMode) *ReviewLog {
+	reviewType := ReviewTypeReview
+	switch {
+	case before.Learning == Relearning:
+		reviewType = ReviewTypeRelearn
+	case before.Learning == Learning || before.IsNew():
+		reviewType = ReviewTypeLearn
+	}
+
+	return &ReviewLog{
+		ID:           uuid.New().String(),
+		CardID:       after.ID,
+		DeckID:       deckID,
+		Timestamp:    after.LastReview,
+		Rating:       rating,
+		Type:         reviewType,
+		Mode:         mode,
+		TimeTaken:    timeTaken,
+		PrevInterval: before.Interval,
+		NewInterval:  after.Interval,
+		PrevDelay:    learningDelay(before),
+		NewDelay:     learningDelay(after),
+		PrevEase:     before.EaseFactor,
+		NewEase:      after.EaseFactor,
+	}
+}
+
+// learningDelay returns the delay of the learning step a card is on, or zero once it is
+// scheduled in days
+func learningDelay(card *Card) time.Duration {
+	if !card.InLearning() || card.LastReview.IsZero() {
+		return 0
+	}
+	return card.NextReview.Sub(card.LastReview)
+}
+
+// IsCorrect reports whether the card was recalled
+func (l *ReviewLog) IsCorrect() bool {
+	return l.Rating != Again
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchContext holds what searches need beyond the decks themselves
+type SearchContext struct {
+	Now          time.Time
+	Reviews      map[string][]*ReviewLog // Review history by card ID
+	RolloverHour int                     // Hour study days start at, for searches counting days
+}
+
+// NewSearchContext indexes review logs by card for searching
+func NewSearchContext(logs []*ReviewLog, rolloverHour int) *SearchContext {
+	reviews := make(map[string][]*ReviewLog)
+	for _, log := range logs {
+		reviews[log.CardID] = append(reviews[log.CardID], log)
+	}
+	return &SearchContext{Now: time.Now(), Reviews: reviews, RolloverHour: rolloverHour}
+}
+
+// SearchResult is a card found by a search, along with the deck it belongs to
+type SearchResult struct {
+	Deck *Deck
+	Card *Card
+}
+
+// searchItem is a card being matched against a query
+type searchItem struct {
+	deck *Deck
+	card *Card
+	ctx  *SearchContext
+}
+
+// Query is a parsed card search such as
+//
+//	deck:Leet* tag:dp is:due prop:ivl>30 front:"two pointer" added:7 rated:1:1
+//
+// Terms can be combined with AND, OR, NOT, a leading "-" and parentheses. Plain words
+// match the front or back of a card
+type Query struct {
+	text  string
+	match matcher[searchItem]
+}
+
+// ParseQuery parses a search. An empty search matches every card
+func ParseQuery(text string) (*Query, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return &Query{match: func(searchItem) bool { return true }}, nil
+	}
+	match, err := parseExpr(text, "search", parseSearchTerm)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{text: text, match: match}, nil
+}
+
+// String returns the search the query was parsed from
+func (q *Query) String() string {
+	return q.text
+}
+
+// Search returns the cards of decks that match the query, in deck order
+func (q *Query) Search(decks []*Deck, ctx *SearchContext) []SearchResult {
+	var results []SearchResult
+	for _, deck := range decks {
+		for i := range deck.Cards {
+			if q.match(searchItem{deck: deck, card: &deck.Cards[i], ctx: ctx}) {
+				results = append(results, SearchResult{Deck: deck, Card: &deck.Cards[i]})
+			}
+		}
+	}
+	return results
+}
+
+// parseSearchTerm turns one term of a search into a matcher
+func parseSearchTerm(token string) (matcher[searchItem], error) {
+	field, value, hasField := strings.Cut(unquote(token), ":")
+	if !hasField {
+		text := unquote(token)
+		return func(item searchItem) bool {
+			return containsText(item.card.Front, text) || containsText(item.card.Back, text)
+		}, nil
+	}
+
+	switch strings.ToLower(field) {
+	case "deck":
+		pattern := wildcardPattern(value)
+		return func(item searchItem) bool {
+			// A deck matches along with everything nested under it
+			for name := item.deck.Name; name != ""; name = ParentName(name) {
+				if pattern.MatchString(name) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "tag":
+		return func(item searchItem) bool { return item.card.HasTag(value) }, nil
+	case "front":
+		return textMatcher(value, func(card *Card) string { return card.Front }), nil
+	case "back":
+		return textMatcher(value, func(card *Card) string { return card.Back }), nil
+	case "is":
+		return parseStateTerm(value)
+	case "flag":
+		return parseFlagTerm(value)
+	case "prop":
+		return parsePropTerm(value)
+	case "added":
+		days, err := parseDays(token, value)
+		if err != nil {
+			return nil, err
+		}
+		return func(item searchItem) bool { return item.ctx.withinDays(item.card.Created, days) }, nil
+	case "edited":
+		days, err := parseDays(token, value)
+		if err != nil {
+			return nil, err
+		}
+		return func(item searchItem) bool { return item.ctx.withinDays(item.card.Modified, days) }, nil
+	case "rated":
+		return parseRatedTerm(token, value)
+	default:
+		return nil, fmt.Errorf("unknown search term %q", token)
+	}
+}
+
+// parseStateTerm handles is:new, is:due, is:learn, is:review, is:suspended, is:buried and
+// is:filtered
+func parseStateTerm(state string) (matcher[searchItem], error) {
+	switch strings.ToLower(state) {
+	case "new":
+		return func(item searchItem) bool { return item.card.IsNew() }, nil
+	case "due":
+		return func(item searchItem) bool {
+			card := item.card
+			return !card.IsNew() && card.IsAvailable(item.ctx.Now) && !card.NextReview.After(item.ctx.Now)
+		}, nil
+	case "learn":
+		return func(item searchItem) bool { return item.card.InLearning() }, nil
+	case "review":
+		return func(item searchItem) bool { return !item.card.IsNew() && !item.card.InLearning() }, nil
+	case "suspended":
+		return func(item searchItem) bool { return item.card.Suspended }, nil
+	case "buried":
+		return func(item searchItem) bool { return item.card.IsBuried(item.ctx.Now) }, nil
+	case "filtered":
+		return func(item searchItem) bool { return item.card.IsBorrowed() }, nil
+	default:
+		return nil, fmt.Errorf("unknown card state is:%s (use new, due, learn, review, suspended, buried or filtered)", state)
+	}
+}
+
+// parseFlagTerm handles flag:N and flag:color, where flag:0 or flag:none finds unflagged cards
+func parseFlagTerm(value string) (matcher[searchItem], error) {
+	for flag := NoFlag; flag <= BlueFlag; flag++ {
+		if value == strconv.Itoa(int(flag)) || strings.EqualFold(value, flag.String()) {
+			return func(item searchItem) bool { return item.card.Flag == flag }, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown flag %q (use 0-4, none, red, orange, green or blue)", value)
+}
+
+// propPattern splits prop:ivl>=30 into the property, comparison and number
+var propPattern = regexp.MustCompile(`^([a-z]+)(<=|>=|!=|<|>|=)(-?[0-9.]+)$`)
+
+// parsePropTerm handles comparisons like prop:ivl>30, prop:ease<2.0, prop:reps=0,
+// prop:lapses>=3 and prop:due<=1 (days until due, negative when overdue)
+func parsePropTerm(value string) (matcher[searchItem], error) {
+	parts := propPattern.FindStringSubmatch(strings.ToLower(value))
+	if parts == nil {
+		return nil, fmt.Errorf("invalid property search prop:%s (like prop:ivl>30)", value)
+	}
+	number, err := strconv.ParseFloat(parts[3], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number in prop:%s", value)
+	}
+
+	var property func(item searchItem) float64
+	switch parts[1] {
+	case "ivl":
+		property = func(item searchItem) float64 { return float64(item.card.Interval) }
+	case "ease":
+		property = func(item searchItem) float64 { return item.card.EaseFactor }
+	case "reps":
+		property = func(item searchItem) float64 { return float64(item.card.Repetition) }
+	case "lapses":
+		property = func(item searchItem) float64 { return float64(item.card.Lapses) }
+	case "due":
+		property = func(item searchItem) float64 {
+			return float64(StudyDaysBetween(item.ctx.Now, item.card.NextReview, item.ctx.RolloverHour))
+		}
+	default:
+		return nil, fmt.Errorf("unknown property %q (use ivl, ease, reps, lapses or due)", parts[1])
+	}
+
+	compare := parts[2]
+	return func(item searchItem) bool {
+		actual := property(item)
+		switch compare {
+		case "<":
+			return actual < number
+		case ">":
+			return actual > number
+		case "<=":
+			return actual <= number
+		case ">=":
+			return actual >= number
+		case "!=":
+			return actual != number
+		default:
+			return actual == number
+		}
+	}, nil
+}
+
+// parseRatedTerm handles rated:N, cards rated in the last N days, and rated:N:R, cards given
+// rating R there, where 1 is Again and 4 is Easy
+func parseRatedTerm(token, value string) (matcher[searchItem], error) {
+	daysText, ratingText, hasRating := strings.Cut(value, ":")
+	days, err := parseDays(token, daysText)
+	if err != nil {
+		return nil, err
+	}
+	rating := -1
+	if hasRating {
+		rating, err = strconv.Atoi(ratingText)
+		if err != nil || rating < 1 || rating > 4 {
+			return nil, fmt.Errorf("invalid rating in %q (use 1 to 4)", token)
+		}
+		rating-- // Ratings count from Again = 0
+	}
+
+	return func(item searchItem) bool {
+		for _, log := range item.ctx.Reviews[item.card.ID] {
+			if item.ctx.withinDays(log.Timestamp, days) && (rating < 0 || int(log.Rating) == rating) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// parseDays reads the day count of terms like added:7
+func parseDays(token, value string) (int, error) {
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("invalid number of days in %q", token)
+	}
+	return days, nil
+}
+
+// withinDays reports whether t falls within the last days study days, counting today as
+// the first
+func (ctx *SearchContext) withinDays(t time.Time, days int) bool {
+	return StudyDaysBetween(t, ctx.Now, ctx.RolloverHour) < days
+}
+
+// textMatcher matches a card field containing text, or the whole field against a pattern
+// with * wildcards
+func textMatcher(text string, field func(card *Card) string) matcher[searchItem] {
+	if strings.Contains(text, "*") {
+		pattern := wildcardPattern(text)
+		return func(item searchItem) bool { return pattern.MatchString(field(item.card)) }
+	}
+	return func(item searchItem) bool { return containsText(field(item.card), text) }
+}
+
+// containsText reports whether s contains text, ignoring case
+func containsText(s, text string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(text))
+}
+
+// wildcardPattern compiles a case-insensitive pattern where * matches any text
+func wildcardPattern(pattern string) *regexp.Regexp {
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	return regexp.MustCompile(`(?is)^` + quoted + `$`)
+}
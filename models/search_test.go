@@ -0,0 +1,156 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// searchFixture returns decks of cards in known states, with each card's front naming it,
+// and a search context at a fixed time with a 4am day rollover
+func searchFixture() ([]*Deck, *SearchContext) {
+	now := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	card := func(front, back string, setup func(card *Card)) Card {
+		c := NewCard(front, back)
+		c.ID = front
+		c.Created = now.AddDate(0, 0, -30)
+		c.Modified = c.Created
+		c.NextReview = c.Created
+		if setup != nil {
+			setup(c)
+		}
+		return *c
+	}
+	review := func(interval int, due time.Time) func(card *Card) {
+		return func(c *Card) {
+			c.Repetition = 3
+			c.Interval = interval
+			c.LastReview = due.AddDate(0, 0, -interval)
+			c.NextReview = due
+		}
+	}
+
+	spanish := NewDeck("Languages::Spanish", "")
+	spanish.Cards = []Card{
+		card("hola", "hello", func(c *Card) { c.Tags = []string{"greeting"} }),
+		card("correr", "to run", func(c *Card) {
+			review(45, now.Add(-time.Hour))(c)
+			c.Tags = []string{"verb::regular"}
+		}),
+		card("ser", "to be", func(c *Card) {
+			review(5, now.AddDate(0, 0, 2))(c)
+			c.Tags = []string{"verb::irregular"}
+			c.EaseFactor = 1.3
+			c.Lapses = 4
+		}),
+		card("ayer", "yesterday", func(c *Card) {
+			c.Created = now.Add(-7 * time.Hour) // 3am, before today's rollover
+		}),
+	}
+	leetcode := NewDeck("Leetcode", "")
+	leetcode.Cards = []Card{
+		card("two pointer sum", "sort, then walk in", func(c *Card) {
+			review(10, now.AddDate(0, 0, -1))(c)
+			c.Flag = RedFlag
+		}),
+		card("knapsack", "dp over capacity", func(c *Card) {
+			c.Suspended = true
+			c.Tags = []string{"dp"}
+			c.Created = now.Add(-time.Hour)
+		}),
+	}
+
+	ctx := NewSearchContext([]*ReviewLog{
+		{CardID: "correr", Rating: Again, Timestamp: now.Add(-2 * time.Hour)},
+		{CardID: "ser", Rating: Good, Timestamp: now.AddDate(0, 0, -3)},
+		{CardID: "two pointer sum", Rating: Good, Timestamp: now.Add(-8 * time.Hour)}, // 2am counts towards yesterday
+	}, 4)
+	ctx.Now = now
+	return []*Deck{spanish, leetcode}, ctx
+}
+
+func TestQuerySearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"hola", "correr", "ser", "ayer", "two pointer sum", "knapsack"}},
+		{"HELLO", []string{"hola"}},
+		{"deck:Languages", []string{"hola", "correr", "ser", "ayer"}},
+		{"deck:Lang*", []string{"hola", "correr", "ser", "ayer"}},
+		{"deck:spanish", nil},
+		{"tag:verb", []string{"correr", "ser"}},
+		{"tag:verb::irregular", []string{"ser"}},
+		{`front:"two pointer"`, []string{"two pointer sum"}},
+		{"front:*sack", []string{"knapsack"}},
+		{"back:to", []string{"correr", "ser"}},
+		{"is:new", []string{"hola", "ayer", "knapsack"}},
+		{"is:due", []string{"correr", "two pointer sum"}},
+		{"is:review", []string{"correr", "ser", "two pointer sum"}},
+		{"is:suspended", []string{"knapsack"}},
+		{"flag:red", []string{"two pointer sum"}},
+		{"flag:0 is:review", []string{"correr", "ser"}},
+		{"prop:ivl>30", []string{"correr"}},
+		{"prop:ivl<=10 is:review", []string{"ser", "two pointer sum"}},
+		{"prop:ease<2", []string{"ser"}},
+		{"prop:lapses>=3", []string{"ser"}},
+		{"prop:due=2", []string{"ser"}},
+		{"prop:due<0 is:review", []string{"two pointer sum"}},
+		{"added:1", []string{"knapsack"}},
+		{"added:2", []string{"ayer", "knapsack"}},
+		{"rated:1", []string{"correr"}},
+		{"rated:2", []string{"correr", "two pointer sum"}},
+		{"rated:7:3", []string{"ser", "two pointer sum"}},
+		{"rated:7:1", []string{"correr"}},
+		{"tag:verb OR tag:dp", []string{"correr", "ser", "knapsack"}},
+		{"tag:verb AND is:due", []string{"correr"}},
+		{"tag:verb is:due", []string{"correr"}},
+		{"tag:verb -is:due", []string{"ser"}},
+		{"tag:verb NOT is:due", []string{"ser"}},
+		{"(tag:dp OR tag:greeting) deck:Leetcode", []string{"knapsack"}},
+		{"tag:dp OR tag:greeting deck:Leetcode", []string{"knapsack"}},
+		{"is:new or is:suspended", []string{"hola", "ayer", "knapsack"}},
+	}
+
+	decks, ctx := searchFixture()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) failed: %v", tt.query, err)
+			}
+			var got []string
+			for _, result := range query.Search(decks, ctx) {
+				got = append(got, result.Card.Front)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []string{
+		"size:big",
+		"is:lost",
+		"flag:9",
+		"prop:ivl",
+		"prop:age>3",
+		"added:0",
+		"rated:x",
+		"rated:1:5",
+		"(tag:dp",
+		"tag:dp)",
+		"tag:dp OR",
+		"AND tag:dp",
+		"NOT",
+	}
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			if _, err := ParseQuery(query); err == nil {
+				t.Errorf("ParseQuery(%q) succeeded, want an error", query)
+			}
+		})
+	}
+}
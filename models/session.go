@@ -1,6 +1,8 @@
 package models
 
 import (
+	"slices"
+	"sort"
 	"time"
 )
 
@@ -8,8 +10,10 @@ import (
 type StudyMode int
 
 const (
-	ReviewMode   StudyMode = iota // Only cards due for review
-	PracticeMode                  // All cards regardless of due date
+	ReviewMode     StudyMode = iota // Only cards due for review
+	PracticeMode                    // All cards regardless of due date
+	TypeAnswerMode                  // Cards due for review, answered by typing
+	FilteredMode                    // Cards borrowed by a filtered deck
 )
 
 // StudySession represents an active study session for a deck
@@ -22,6 +26,10 @@ type StudySession struct {
 	SessionStart  time.Time `json:"session_start"`
 	CardsStudied  int       `json:"cards_studied"`
 	Mode          StudyMode `json:"mode"`
+	Learning      []Card    `json:"learning"` // Cards waiting for their next learning step, soonest first
+
+	Options   SessionOptions    `json:"-"` // Options the session was built with, for restarting it
+	CardDecks map[string]string `json:"-"` // Deck ID of each card, for sessions over several decks
 }
 
 // Rating represents how well the user knew a card
@@ -50,21 +58,119 @@ func (r Rating) String() string {
 	}
 }
 
+// SessionOptions controls which cards a study session takes from a deck
+type SessionOptions struct {
+	MaxCards     int
+	Mode         StudyMode
+	Limits       DailyLimits   // Per-day caps applied in ReviewMode and TypeAnswerMode
+	RolloverHour int           // Hour at which a new study day starts
+	LearnAhead   time.Duration // How early learning cards are shown when nothing else is left
+	Tags         *TagFilter    // Only cards matching this tag expression, or all cards when nil
+	Leech        LeechSettings // What happens to cards that keep being forgotten
+	Filtered     *Deck         // Filtered deck whose borrowed cards are studied in FilteredMode
+	Order        ReviewOrder   // How cards already seen are interleaved, or deck by deck when empty
+}
+
 // NewStudySession creates a new study session for the given deck
-func NewStudySession(deck *Deck, maxCards int, mode StudyMode) *StudySession {
-	var sessionCards []Card
+func NewStudySession(deck *Deck, opts SessionOptions) *StudySession {
+	return NewStudySessionForDecks([]*Deck{deck}, deck.Name, opts)
+}
+
+// NewStudySessionForDecks creates a study session over several decks, such as a parent deck
+// and its descendants. Each deck's daily limits apply to its own cards, and outside
+// PracticeMode all decks' cards already seen come before new cards, in opts.Order
+func NewStudySessionForDecks(decks []*Deck, name string, opts SessionOptions) *StudySession {
+	var sessionCards, newCards, learningCards []Card
+	cardDecks := make(map[string]string)
+	for _, deck := range decks {
+		cards, learning := deckSessionCards(deck, opts)
+		for _, card := range cards {
+			if opts.Mode != PracticeMode && card.IsNew() {
+				newCards = append(newCards, card)
+			} else {
+				sessionCards = append(sessionCards, card)
+			}
+			cardDecks[card.ID] = deck.ID
+		}
+		for _, card := range learning {
+			learningCards = append(learningCards, card)
+			cardDecks[card.ID] = deck.ID
+		}
+	}
+	orderCards(sessionCards, opts.Order, cardDecks, time.Now())
+	sessionCards = append(sessionCards, newCards...)
 
-	switch mode {
-	case ReviewMode:
-		// Only cards due for review + new cards
-		reviewCards := deck.GetReviewCards()
-		newCards := deck.GetNewCards()
+	// Limit total cards to maxCards
+	if len(sessionCards) > opts.MaxCards {
+		sessionCards = sessionCards[:opts.MaxCards]
+	}
+
+	deckID := ""
+	if len(decks) == 1 {
+		deckID = decks[0].ID
+	}
+	session := &StudySession{
+		DeckID:        deckID,
+		DeckName:      name,
+		Cards:         sessionCards,
+		CurrentIndex:  0,
+		ShowingAnswer: false,
+		SessionStart:  time.Now(),
+		CardsStudied:  0,
+		Mode:          opts.Mode,
+		Options:       opts,
+		CardDecks:     cardDecks,
+	}
+	for _, card := range learningCards {
+		session.Requeue(card)
+	}
+	if len(session.Cards) == 0 && len(session.Learning) > 0 {
+		// Only learning cards are left, so start with the soonest
+		session.Cards = append(session.Cards, session.Learning[0])
+		session.Learning = session.Learning[1:]
+	}
+	return session
+}
+
+// deckSessionCards picks a deck's cards for a session, along with learning cards due
+// within LearnAhead that wait in the learning queue
+func deckSessionCards(deck *Deck, opts SessionOptions) (sessionCards, learningCards []Card) {
+	day := StudyDay(time.Now(), opts.RolloverHour)
+
+	switch opts.Mode {
+	case FilteredMode:
+		// Everything the filtered deck borrowed, without daily limits
+		return filteredSessionCards(deck, opts.Filtered), nil
+
+	case ReviewMode, TypeAnswerMode:
+		// Only cards due for review + new cards, within what is left of today's limits
+		newLeft, reviewsLeft := deck.Remaining(opts.Limits, day)
+		var reviewCards []Card
+		newCards := opts.Tags.filterCards(deck.GetNewCards(opts.RolloverHour))
+
+		// Cards in learning are not limited, and those due soon wait in the learning queue
+		now := time.Now()
+		for _, card := range opts.Tags.filterCards(deck.Cards) {
+			if card.InLearning() && card.IsAvailable(now) && !card.IsBorrowed() && card.NextReview.After(now) && !card.NextReview.After(now.Add(opts.LearnAhead)) {
+				learningCards = append(learningCards, card)
+			}
+		}
+		for _, card := range opts.Tags.filterCards(deck.GetReviewCards(opts.RolloverHour)) {
+			if card.InLearning() {
+				sessionCards = append(sessionCards, card)
+			} else {
+				reviewCards = append(reviewCards, card)
+			}
+		}
 
 		// Combine review and new cards, prioritizing review cards
+		if len(reviewCards) > reviewsLeft {
+			reviewCards = reviewCards[:reviewsLeft]
+		}
 		sessionCards = append(sessionCards, reviewCards...)
 
 		// Add new cards up to the limit
-		remainingSlots := maxCards - len(reviewCards)
+		remainingSlots := min(opts.MaxCards-len(sessionCards), newLeft)
 		if remainingSlots > 0 && len(newCards) > 0 {
 			newCardsToAdd := remainingSlots
 			if newCardsToAdd > len(newCards) {
@@ -74,26 +180,81 @@ func NewStudySession(deck *Deck, maxCards int, mode StudyMode) *StudySession {
 		}
 
 	case PracticeMode:
-		// All cards in the deck
-		sessionCards = make([]Card, len(deck.Cards))
-		copy(sessionCards, deck.Cards)
+		// All cards in the deck that are not suspended
+		for _, card := range opts.Tags.filterCards(deck.Cards) {
+			if !card.Suspended {
+				sessionCards = append(sessionCards, card)
+			}
+		}
 	}
 
-	// Limit total cards to maxCards
-	if len(sessionCards) > maxCards {
-		sessionCards = sessionCards[:maxCards]
+	if opts.Mode != PracticeMode {
+		sessionCards = withoutSiblings(sessionCards)
 	}
+	return sessionCards, learningCards
+}
 
-	return &StudySession{
-		DeckID:        deck.ID,
-		DeckName:      deck.Name,
-		Cards:         sessionCards,
-		CurrentIndex:  0,
-		ShowingAnswer: false,
-		SessionStart:  time.Now(),
-		CardsStudied:  0,
-		Mode:          mode,
+// DeckIDFor returns the ID of the deck a session card belongs to
+func (s *StudySession) DeckIDFor(card *Card) string {
+	if deckID, ok := s.CardDecks[card.ID]; ok {
+		return deckID
+	}
+	return s.DeckID
+}
+
+// withoutSiblings keeps only the first card of each note, so siblings are not studied the same day
+func withoutSiblings(cards []Card) []Card {
+	seen := make(map[string]bool)
+	kept := cards[:0]
+	for _, card := range cards {
+		if card.NoteID != "" && !card.InLearning() {
+			if seen[card.NoteID] {
+				continue
+			}
+			seen[card.NoteID] = true
+		}
+		kept = append(kept, card)
+	}
+	return kept
+}
+
+// BurySiblings removes the cards of card's note still waiting later in the session
+func (s *StudySession) BurySiblings(card *Card) {
+	if card.NoteID == "" {
+		return
+	}
+	kept := s.Cards[:s.CurrentIndex+1]
+	for _, other := range s.Cards[s.CurrentIndex+1:] {
+		if other.NoteID == card.NoteID && other.ID != card.ID && !other.InLearning() {
+			continue
+		}
+		kept = append(kept, other)
+	}
+	s.Cards = kept
+}
+
+// RemoveCurrent takes the current card out of the session, such as after suspending or burying
+// it. The next card is reached with NextCard as usual, without counting the removed card
+func (s *StudySession) RemoveCurrent() {
+	if s.CurrentIndex < 0 || s.CurrentIndex >= len(s.Cards) {
+		return
 	}
+	s.Cards = slices.Delete(s.Cards, s.CurrentIndex, s.CurrentIndex+1)
+	s.CurrentIndex--
+	s.CardsStudied--
+}
+
+// Snapshot returns a copy of the session's progress that Restore can go back to
+func (s *StudySession) Snapshot() StudySession {
+	snapshot := *s
+	snapshot.Cards = slices.Clone(s.Cards)
+	snapshot.Learning = slices.Clone(s.Learning)
+	return snapshot
+}
+
+// Restore goes back to a snapshot of the session, such as after undoing a rating
+func (s *StudySession) Restore(snapshot StudySession) {
+	*s = snapshot
 }
 
 // GetCurrentCard returns the current card being studied
@@ -109,9 +270,39 @@ func (s *StudySession) ShowAnswer() {
 	s.ShowingAnswer = true
 }
 
-// NextCard moves to the next card
+// Requeue adds a card in learning to the session again, to be shown once its step is due
+func (s *StudySession) Requeue(card Card) {
+	s.Learning = append(s.Learning, card)
+	sort.SliceStable(s.Learning, func(i, j int) bool {
+		return s.Learning[i].NextReview.Before(s.Learning[j].NextReview)
+	})
+}
+
+// NextCard moves to the next card. Learning cards come first once due, and are shown up to
+// LearnAhead early when no other cards are left
 func (s *StudySession) NextCard() bool {
-	if s.CurrentIndex < len(s.Cards)-1 {
+	return s.advance(false)
+}
+
+// StudyAhead moves to the next learning card without waiting for it to be due
+func (s *StudySession) StudyAhead() bool {
+	return s.advance(true)
+}
+
+// advance moves to the next card, taking the soonest learning card if it is due or force is set
+func (s *StudySession) advance(force bool) bool {
+	hasMore := s.CurrentIndex < len(s.Cards)-1
+	if len(s.Learning) > 0 {
+		now := time.Now()
+		due := s.Learning[0].NextReview
+		if force || !due.After(now) || (!hasMore && !due.After(now.Add(s.Options.LearnAhead))) {
+			s.Cards = slices.Insert(s.Cards, s.CurrentIndex+1, s.Learning[0])
+			s.Learning = s.Learning[1:]
+			hasMore = true
+		}
+	}
+
+	if hasMore {
 		s.CurrentIndex++
 		s.ShowingAnswer = false
 		s.CardsStudied++
@@ -120,19 +311,27 @@ func (s *StudySession) NextCard() bool {
 	return false
 }
 
+// NextLearningDue returns when the soonest waiting learning card is due
+func (s *StudySession) NextLearningDue() (time.Time, bool) {
+	if len(s.Learning) == 0 {
+		return time.Time{}, false
+	}
+	return s.Learning[0].NextReview, true
+}
+
 // IsFinished returns true if all cards have been studied
 func (s *StudySession) IsFinished() bool {
-	return s.CurrentIndex >= len(s.Cards) || len(s.Cards) == 0
+	return (s.CurrentIndex >= len(s.Cards) || len(s.Cards) == 0) && len(s.Learning) == 0
 }
 
 // GetProgress returns current progress as (current, total)
 func (s *StudySession) GetProgress() (int, int) {
-	return s.CurrentIndex + 1, len(s.Cards)
+	return s.CurrentIndex + 1, len(s.Cards) + len(s.Learning)
 }
 
 // GetRemainingCards returns the number of cards left to study
 func (s *StudySession) GetRemainingCards() int {
-	remaining := len(s.Cards) - s.CurrentIndex - 1
+	remaining := len(s.Cards) + len(s.Learning) - s.CurrentIndex - 1
 	if remaining < 0 {
 		return 0
 	}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParseTags splits text on whitespace and commas into tags, dropping duplicates
+func ParseTags(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	return addTags(nil, fields)
+}
+
+// HasTag reports whether the card has tag, or a child of it like tag::sub, ignoring case.
+// The tag may contain * wildcards
+func (c *Card) HasTag(tag string) bool {
+	pattern := strings.ToLower(tag)
+	for _, cardTag := range c.Tags {
+		cardTag = strings.ToLower(cardTag)
+		if matched, _ := path.Match(pattern, cardTag); matched || strings.HasPrefix(cardTag, pattern+"::") {
+			return true
+		}
+	}
+	return false
+}
+
+// addTags appends the tags not already in tags, ignoring case
+func addTags(tags []string, add []string) []string {
+	for _, tag := range add {
+		if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// removeTags returns tags without the ones in remove, ignoring case
+func removeTags(tags []string, remove []string) []string {
+	var kept []string
+	for _, tag := range tags {
+		if !slices.ContainsFunc(remove, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// Tags returns every tag used in the deck, sorted
+func (d *Deck) Tags() []string {
+	var tags []string
+	for _, card := range d.Cards {
+		tags = addTags(tags, card.Tags)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+	return tags
+}
+
+// SetNoteTags replaces the tags of every card generated from a note
+func (d *Deck) SetNoteTags(noteID string, tags []string) {
+	for i := range d.Cards {
+		if d.Cards[i].NoteID == noteID {
+			d.Cards[i].Tags = slices.Clone(tags)
+			d.Cards[i].MarkModified()
+		}
+	}
+	d.MarkModified()
+}
+
+// TagCards adds and removes tags on the given cards. Tags belong to the note, so the
+// cards' siblings change along with them
+func (d *Deck) TagCards(cardIDs []string, add, remove []string) {
+	notes := make(map[string]bool)
+	for _, card := range d.Cards {
+		if slices.Contains(cardIDs, card.ID) && card.NoteID != "" {
+			notes[card.NoteID] = true
+		}
+	}
+
+	for i := range d.Cards {
+		card := &d.Cards[i]
+		if !slices.Contains(cardIDs, card.ID) && !notes[card.NoteID] {
+			continue
+		}
+		card.Tags = removeTags(addTags(card.Tags, add), remove)
+		card.MarkModified()
+	}
+	d.MarkModified()
+}
+
+// TagFilter selects cards with a boolean expression over their tags, such as
+// "graph AND NOT hard", "dp OR greedy", "(easy OR medium) -review" or "leetcode::*".
+// Terms next to each other without an operator must all match
+type TagFilter struct {
+	text  string
+	match matcher[*Card]
+}
+
+// ParseTagFilter parses a tag expression. An empty expression gives a nil filter, which
+// matches every card
+func ParseTagFilter(text string) (*TagFilter, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	match, err := parseExpr(text, "tag filter", func(token string) (matcher[*Card], error) {
+		tag := unquote(token)
+		if _, err := path.Match(strings.ToLower(tag), ""); err != nil {
+			return nil, fmt.Errorf("invalid tag pattern %q", tag)
+		}
+		return func(card *Card) bool { return card.HasTag(tag) }, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TagFilter{text: text, match: match}, nil
+}
+
+// Matches reports whether the card's tags satisfy the filter
+func (f *TagFilter) Matches(card *Card) bool {
+	return f == nil || f.match(card)
+}
+
+// String returns the expression the filter was parsed from
+func (f *TagFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.text
+}
+
+// filterCards returns the cards matching the filter
+func (f *TagFilter) filterCards(cards []Card) []Card {
+	if f == nil {
+		return cards
+	}
+	var kept []Card
+	for _, card := range cards {
+		if f.Matches(&card) {
+			kept = append(kept, card)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tempFilePattern names in-progress writes; the leading dot and suffix keep them out of *.json globs
+const tempFilePattern = ".%s.tmp-*"
+
+// writeFileAtomic replaces path with data so that readers see either the old or the new
+// contents, never a partial file. The data is written to a temporary file in the same
+// directory, flushed to disk, and renamed over the original
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf(tempFilePattern, filepath.Base(path)))
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temporary file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	committed = true
+
+	// Persist the rename itself; not every platform supports syncing a directory
+	if dirFile, err := os.Open(dir); err == nil {
+		dirFile.Sync()
+		dirFile.Close()
+	}
+
+	return nil
+}
+
+// removeStaleTempFiles deletes temporary files left behind by writes that were interrupted
+func removeStaleTempFiles(dir string) {
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	if err != nil {
+		return
+	}
+	for _, match := range matches {
+		if strings.Contains(filepath.Base(match), ".tmp-") {
+			os.Remove(match)
+		}
+	}
+}
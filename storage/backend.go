@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"anktui/config"
+	"fmt"
+	"time"
+)
+
+// New creates the storage backend selected by the storage_backend setting
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case config.StorageJSON, "":
+		return NewJSONStorage(cfg)
+	case config.StorageSQLite:
+		return NewSQLiteStorage(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// NewReadOnly opens the configured backend for reading while another instance may be running
+func NewReadOnly(cfg *config.Config) (Storage, error) {
+	if cfg.StorageBackend == config.StorageSQLite {
+		return NewReadOnlySQLiteStorage(cfg)
+	}
+	return NewReadOnlyJSONStorage(cfg)
+}
+
+// MigrationResult summarises a JSON to SQLite migration
+type MigrationResult struct {
+	Decks   int
+	Cards   int
+	Reviews int
+}
+
+// MigrateJSONToSQLite copies every deck and review log entry from the JSON data directory
+// into the SQLite database. Running it again overwrites decks and skips logs already copied
+func MigrateJSONToSQLite(src *JSONStorage, dst *SQLiteStorage) (*MigrationResult, error) {
+	decks, err := src.LoadAllDecks()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{}
+	for _, deck := range decks {
+		if err := dst.SaveDeck(deck); err != nil {
+			return result, fmt.Errorf("failed to migrate deck %s: %w", deck.Name, err)
+		}
+		result.Decks++
+		result.Cards += len(deck.Cards)
+	}
+
+	logs, err := src.GetReviewLogsInRange(time.Time{}, time.Now().AddDate(100, 0, 0))
+	if err != nil {
+		return result, err
+	}
+	if err := dst.ImportReviewLogs(logs); err != nil {
+		return result, err
+	}
+	result.Reviews = len(logs)
+
+	noteTypes, err := src.LoadNoteTypes()
+	if err != nil {
+		return result, err
+	}
+	if err := dst.SaveNoteTypes(noteTypes); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -8,11 +8,20 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // JSONStorage implements the Storage interface using JSON files
 type JSONStorage struct {
-	dataDir string
+	dataDir  string
+	lock     *dirLock
+	readOnly bool
+
+	// Deck files skipped by the last LoadAllDecks
+	mu       sync.Mutex
+	warnings []LoadWarning
+
+	logMu sync.Mutex // Keeps appends from being lost while an entry is removed
 }
 
 // NewJSONStorage creates a new JSON storage instance
@@ -27,7 +36,29 @@ func NewJSONStorage(cfg *config.Config) (*JSONStorage, error) {
 		return nil, err
 	}
 
-	return &JSONStorage{dataDir: dataDir}, nil
+	// Keep other instances from writing the same files
+	lock, err := acquireDirLock(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	removeStaleTempFiles(dataDir)
+
+	return &JSONStorage{dataDir: dataDir, lock: lock}, nil
+}
+
+// NewReadOnlyJSONStorage opens the data directory for reading without taking the lock,
+// so it can be used while another instance is running
+func NewReadOnlyJSONStorage(cfg *config.Config) (*JSONStorage, error) {
+	dataDir, err := cfg.GetExpandedDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return &JSONStorage{dataDir: dataDir, readOnly: true}, nil
+}
+
+// Close releases the data directory lock
+func (s *JSONStorage) Close() error {
+	return s.lock.release()
 }
 
 // getDeckFilePath returns the file path for a deck
@@ -37,6 +68,9 @@ func (s *JSONStorage) getDeckFilePath(deckID string) string {
 
 // SaveDeck saves a deck to a JSON file
 func (s *JSONStorage) SaveDeck(deck *models.Deck) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
 	filePath := s.getDeckFilePath(deck.ID)
 
 	// Marshal deck to JSON with proper indentation
@@ -45,8 +79,8 @@ func (s *JSONStorage) SaveDeck(deck *models.Deck) error {
 		return fmt.Errorf("failed to marshal deck: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	// Write to a temporary file and rename it into place so a crash cannot truncate the deck
+	if err := writeFileAtomic(filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write deck file: %w", err)
 	}
 
@@ -86,6 +120,7 @@ func (s *JSONStorage) LoadAllDecks() ([]*models.Deck, error) {
 	}
 
 	var decks []*models.Deck
+	var warnings []LoadWarning
 	for _, file := range files {
 		// Extract deck ID from filename
 		filename := filepath.Base(file)
@@ -94,18 +129,33 @@ func (s *JSONStorage) LoadAllDecks() ([]*models.Deck, error) {
 		// Load the deck
 		deck, err := s.LoadDeck(deckID)
 		if err != nil {
-			// Log error but continue with other decks
+			// Keep going with the other decks, but remember the file so it can be reported
+			warnings = append(warnings, LoadWarning{Path: file, Err: err})
 			continue
 		}
 
 		decks = append(decks, deck)
 	}
 
+	s.mu.Lock()
+	s.warnings = warnings
+	s.mu.Unlock()
+
 	return decks, nil
 }
 
+// LoadWarnings returns the deck files the last LoadAllDecks could not read
+func (s *JSONStorage) LoadWarnings() []LoadWarning {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.warnings
+}
+
 // DeleteDeck deletes a deck by ID
 func (s *JSONStorage) DeleteDeck(id string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
 	filePath := s.getDeckFilePath(id)
 
 	// Check if file exists
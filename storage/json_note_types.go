@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"anktui/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// noteTypesFileName holds the custom note types, one JSON object per line. It does not end
+// in .json so it is never mistaken for a deck
+const noteTypesFileName = "note_types.jsonl"
+
+// getNoteTypesFilePath returns the file path of the custom note types
+func (s *JSONStorage) getNoteTypesFilePath() string {
+	return filepath.Join(s.dataDir, noteTypesFileName)
+}
+
+// SaveNoteTypes replaces the stored custom note types
+func (s *JSONStorage) SaveNoteTypes(noteTypes []*models.CustomNoteType) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	var data []byte
+	for _, noteType := range noteTypes {
+		line, err := json.Marshal(noteType)
+		if err != nil {
+			return fmt.Errorf("failed to marshal note type: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	if err := writeFileAtomic(s.getNoteTypesFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write note types: %w", err)
+	}
+	return nil
+}
+
+// LoadNoteTypes returns the custom note types, sorted by name
+func (s *JSONStorage) LoadNoteTypes() ([]*models.CustomNoteType, error) {
+	data, err := os.ReadFile(s.getNoteTypesFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note types: %w", err)
+	}
+
+	var noteTypes []*models.CustomNoteType
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var noteType models.CustomNoteType
+		if err := json.Unmarshal([]byte(line), &noteType); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal note type: %w", err)
+		}
+		noteType.AssignOrds()
+		noteTypes = append(noteTypes, &noteType)
+	}
+
+	sort.Slice(noteTypes, func(i, j int) bool {
+		return noteTypes[i].Name < noteTypes[j].Name
+	})
+	return noteTypes, nil
+}
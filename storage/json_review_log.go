@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"anktui/models"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// reviewLogFileName is the append-only file holding one JSON review log entry per line
+const reviewLogFileName = "review_log.jsonl"
+
+// getReviewLogFilePath returns the file path of the review log
+func (s *JSONStorage) getReviewLogFilePath() string {
+	return filepath.Join(s.dataDir, reviewLogFileName)
+}
+
+// RecordReview saves the deck and appends the review log entry
+func (s *JSONStorage) RecordReview(deck *models.Deck, log *models.ReviewLog) error {
+	if err := s.SaveDeck(deck); err != nil {
+		return err
+	}
+	return s.appendReviewLog(log)
+}
+
+// appendReviewLog writes a single entry to the end of the review log
+func (s *JSONStorage) appendReviewLog(log *models.ReviewLog) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review log: %w", err)
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	file, err := os.OpenFile(s.getReviewLogFilePath(), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open review log: %w", err)
+	}
+	defer file.Close()
+
+	// Finish a partially written last line first, so the new entry starts a line of its own
+	terminated, err := endsWithNewline(file)
+	if err != nil {
+		return fmt.Errorf("failed to read review log: %w", err)
+	}
+	if !terminated {
+		data = append([]byte{'\n'}, data...)
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write review log: %w", err)
+	}
+
+	// Flush so a crash cannot lose a review that was reported as saved
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to write review log: %w", err)
+	}
+
+	return nil
+}
+
+// endsWithNewline reports whether a file is empty or ends with a newline
+func endsWithNewline(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return true, nil
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] == '\n', nil
+}
+
+// UndoReview saves the deck and removes the review log entry with the given ID
+func (s *JSONStorage) UndoReview(deck *models.Deck, logID string) error {
+	if err := s.SaveDeck(deck); err != nil {
+		return err
+	}
+	return s.removeReviewLog(logID)
+}
+
+// removeReviewLog rewrites the review log without the entry with the given ID
+func (s *JSONStorage) removeReviewLog(logID string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	data, err := os.ReadFile(s.getReviewLogFilePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read review log: %w", err)
+	}
+
+	var kept []byte
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var log models.ReviewLog
+		if json.Unmarshal(bytes.TrimSpace(line), &log) == nil && log.ID == logID {
+			continue
+		}
+		kept = append(kept, line...)
+		// A broken last line is kept for repair, but ended so later entries don't join it
+		if len(line) > 0 && line[len(line)-1] != '\n' {
+			kept = append(kept, '\n')
+		}
+	}
+	if err := writeFileAtomic(s.getReviewLogFilePath(), kept, 0644); err != nil {
+		return fmt.Errorf("failed to write review log: %w", err)
+	}
+	return nil
+}
+
+// GetReviewLogsByCard returns all review log entries for a card
+func (s *JSONStorage) GetReviewLogsByCard(cardID string) ([]*models.ReviewLog, error) {
+	return s.readReviewLogs(func(log *models.ReviewLog) bool {
+		return log.CardID == cardID
+	})
+}
+
+// GetReviewLogsByDeck returns all review log entries for a deck
+func (s *JSONStorage) GetReviewLogsByDeck(deckID string) ([]*models.ReviewLog, error) {
+	return s.readReviewLogs(func(log *models.ReviewLog) bool {
+		return log.DeckID == deckID
+	})
+}
+
+// GetReviewLogsInRange returns review log entries with start <= timestamp < end
+func (s *JSONStorage) GetReviewLogsInRange(start, end time.Time) ([]*models.ReviewLog, error) {
+	return s.readReviewLogs(func(log *models.ReviewLog) bool {
+		return !log.Timestamp.Before(start) && log.Timestamp.Before(end)
+	})
+}
+
+// readReviewLogs scans the review log and returns matching entries sorted by timestamp
+func (s *JSONStorage) readReviewLogs(match func(*models.ReviewLog) bool) ([]*models.ReviewLog, error) {
+	file, err := os.Open(s.getReviewLogFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open review log: %w", err)
+	}
+	defer file.Close()
+
+	var logs []*models.ReviewLog
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var log models.ReviewLog
+		if err := json.Unmarshal(line, &log); err != nil {
+			// Skip a partially written trailing line rather than losing the whole history
+			continue
+		}
+		if match(&log) {
+			logs = append(logs, &log)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+
+	return logs, nil
+}
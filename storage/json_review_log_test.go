@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"anktui/models"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReviewLogPartialLineRepair(t *testing.T) {
+	const (
+		first  = `{"id":"first","card_id":"a","timestamp":"2025-03-01T12:00:00Z"}`
+		second = `{"id":"second","card_id":"b","timestamp":"2025-03-01T12:01:00Z"}`
+		broken = `{"id":"broken","card_i`
+	)
+	appendLog := func(id string) func(s *JSONStorage) error {
+		return func(s *JSONStorage) error {
+			return s.appendReviewLog(&models.ReviewLog{ID: id, CardID: "c", Timestamp: time.Date(2025, 3, 1, 13, 0, 0, 0, time.UTC)})
+		}
+	}
+	removeLog := func(id string) func(s *JSONStorage) error {
+		return func(s *JSONStorage) error { return s.removeReviewLog(id) }
+	}
+	tests := []struct {
+		name      string
+		initial   string
+		ops       []func(s *JSONStorage) error
+		wantIDs   []string
+		wantLines int
+	}{
+		{"append to a new log", "", []func(s *JSONStorage) error{appendLog("new")}, []string{"new"}, 1},
+		{"append after a complete line", first + "\n", []func(s *JSONStorage) error{appendLog("new")}, []string{"first", "new"}, 2},
+		{"append after a partial line", first + "\n" + broken, []func(s *JSONStorage) error{appendLog("new")}, []string{"first", "new"}, 3},
+		{"remove keeps a partial line ended", first + "\n" + broken, []func(s *JSONStorage) error{removeLog("first")}, nil, 1},
+		{
+			"append after removing next to a partial line",
+			first + "\n" + second + "\n" + broken,
+			[]func(s *JSONStorage) error{removeLog("second"), appendLog("new")},
+			[]string{"first", "new"}, 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &JSONStorage{dataDir: t.TempDir()}
+			if tt.initial != "" {
+				if err := os.WriteFile(s.getReviewLogFilePath(), []byte(tt.initial), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, op := range tt.ops {
+				if err := op(s); err != nil {
+					t.Fatalf("review log write failed: %v", err)
+				}
+			}
+
+			logs, err := s.GetReviewLogsInRange(time.Time{}, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, log := range logs {
+				ids = append(ids, log.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("review log holds %v, want %v", ids, tt.wantIDs)
+			}
+
+			data, err := os.ReadFile(s.getReviewLogFilePath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(string(data), "\n") {
+				t.Errorf("review log does not end with a newline: %q", data)
+			}
+			if lines := strings.Count(string(data), "\n"); lines != tt.wantLines {
+				t.Errorf("review log has %d lines, want %d: %q", lines, tt.wantLines, data)
+			}
+		})
+	}
+}
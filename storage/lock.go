@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// lockFileName is the file in the data directory that an instance holds a lock on
+const lockFileName = ".lock"
+
+// ErrLocked is returned when another anktui instance is using the data directory
+var ErrLocked = errors.New("data directory is in use by another anktui instance")
+
+// ErrReadOnly is returned when writing through storage opened for reading only
+var ErrReadOnly = errors.New("storage was opened read-only")
+
+// dirLock is an advisory lock on a data directory, held until released or the process exits
+type dirLock struct {
+	file *os.File
+}
+
+// acquireDirLock takes the lock on dir without blocking, returning ErrLocked if it is held
+func acquireDirLock(dir string) (*dirLock, error) {
+	file, err := lockFile(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, err
+	}
+	return &dirLock{file: file}, nil
+}
+
+// release gives up the lock
+func (l *dirLock) release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	l.file = nil
+	return err
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is ERROR_SHARING_VIOLATION, returned when another process has the file open
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile opens path without sharing, so a second instance cannot open it while we hold it
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := syscall.CreateFile(name,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0, // No sharing
+		nil,
+		syscall.OPEN_ALWAYS,
+		syscall.FILE_ATTRIBUTE_NORMAL,
+		0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("failed to lock data directory: %w", err)
+	}
+
+	return os.NewFile(uintptr(handle), path), nil
+}
+
+// unlockFile closes the file, releasing the lock
+func unlockFile(file *os.File) error {
+	return file.Close()
+}
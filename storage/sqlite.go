@@ -0,0 +1,693 @@
+package storage
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver registered as "sqlite"
+)
+
+// sqliteFileName is the database file created inside the data directory
+const sqliteFileName = "anktui.db"
+
+// sqliteSchema creates the tables on first use. Each row keeps the full model as JSON
+// in data, with the columns needed for lookups and ordering pulled out alongside it
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS decks (
+	id       TEXT PRIMARY KEY,
+	name     TEXT NOT NULL,
+	modified INTEGER NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS cards (
+	id          TEXT PRIMARY KEY,
+	deck_id     TEXT NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+	position    INTEGER NOT NULL,
+	next_review INTEGER NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cards_deck ON cards (deck_id, position);
+CREATE INDEX IF NOT EXISTS idx_cards_due ON cards (next_review);
+CREATE TABLE IF NOT EXISTS review_logs (
+	id        TEXT PRIMARY KEY,
+	card_id   TEXT NOT NULL,
+	deck_id   TEXT NOT NULL,
+	timestamp INTEGER NOT NULL,
+	data      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_review_logs_card ON review_logs (card_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_review_logs_deck ON review_logs (deck_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_review_logs_time ON review_logs (timestamp);
+CREATE TABLE IF NOT EXISTS note_types (
+	id   TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	data TEXT NOT NULL
+);
+`
+
+// SQLiteStorage implements the Storage interface using a SQLite database
+type SQLiteStorage struct {
+	db       *sql.DB
+	path     string
+	readOnly bool
+	lock     *dirLock // Held on the data directory when opened through the config
+
+	// Decks skipped by the last LoadAllDecks, and the IDs of the cards of each deck this
+	// instance has loaded or saved. Only those are deleted when a save leaves them out
+	mu       sync.Mutex
+	warnings []LoadWarning
+	known    map[string]map[string]bool
+}
+
+// NewSQLiteStorage opens (creating if needed) the database in the configured data directory,
+// locking the directory against other instances
+func NewSQLiteStorage(cfg *config.Config) (*SQLiteStorage, error) {
+	dataDir, err := cfg.GetExpandedDataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure data directory exists
+	if err := cfg.EnsureDataDir(); err != nil {
+		return nil, err
+	}
+
+	// Keep other instances from writing the same database
+	lock, err := acquireDirLock(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	s, err := OpenSQLiteStorage(SQLitePath(dataDir))
+	if err != nil {
+		lock.release()
+		return nil, err
+	}
+	s.lock = lock
+	return s, nil
+}
+
+// SQLitePath returns the path of the database in dataDir
+func SQLitePath(dataDir string) string {
+	return filepath.Join(dataDir, sqliteFileName)
+}
+
+// OpenSQLiteStorage opens the database at path and creates any missing tables
+func OpenSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path, "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// A single connection keeps writes serialized and pragmas applied
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	return &SQLiteStorage{db: db, path: path}, nil
+}
+
+// sqliteDSN builds the URI for the database at path with the given query, escaping
+// characters such as ? and # that would otherwise end the file name
+func sqliteDSN(path, query string) string {
+	path = filepath.ToSlash(path)
+	if filepath.VolumeName(path) != "" {
+		// Windows drive letters follow the slash of an absolute URI path
+		path = "/" + path
+	}
+	dsn := url.URL{Scheme: "file", Path: path, RawQuery: query}
+	return dsn.String()
+}
+
+// NewReadOnlySQLiteStorage opens the database in the configured data directory for reading,
+// so it can be used while another instance is writing to it
+func NewReadOnlySQLiteStorage(cfg *config.Config) (*SQLiteStorage, error) {
+	dataDir, err := cfg.GetExpandedDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenReadOnlySQLiteStorage(SQLitePath(dataDir))
+}
+
+// OpenReadOnlySQLiteStorage opens an existing database at path for reading. It neither
+// creates the file nor touches the schema
+func OpenReadOnlySQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path, "mode=ro&_pragma=busy_timeout(5000)"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	// Connections are opened lazily, so check the database is there now
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return &SQLiteStorage{db: db, path: path, readOnly: true}, nil
+}
+
+// Close closes the database and releases the data directory lock
+func (s *SQLiteStorage) Close() error {
+	err := s.db.Close()
+	if lockErr := s.lock.release(); err == nil {
+		err = lockErr
+	}
+	return err
+}
+
+// SaveDeck saves a deck and all of its cards in one transaction
+func (s *SQLiteStorage) SaveDeck(deck *models.Deck) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveDeckTx(tx, deck, s.knownCards(deck.ID)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save deck: %w", err)
+	}
+	s.rememberCards(deck.ID, deck.Cards)
+	return nil
+}
+
+// saveDeckTx writes the deck along with the cards that changed since it was stored, and
+// deletes the known cards it no longer holds
+func saveDeckTx(tx *sql.Tx, deck *models.Deck, known map[string]bool) error {
+	if err := saveDeckRow(tx, deck); err != nil {
+		return err
+	}
+
+	stored, err := storedCards(tx, deck.ID)
+	if err != nil {
+		return err
+	}
+	for i := range deck.Cards {
+		card := &deck.Cards[i]
+		data, err := json.Marshal(card)
+		if err != nil {
+			return fmt.Errorf("failed to marshal card: %w", err)
+		}
+		row, ok := stored[card.ID]
+		delete(stored, card.ID)
+		if ok && row.position == i && row.data == string(data) {
+			continue
+		}
+		if err := writeCardRow(tx, deck.ID, i, card, data); err != nil {
+			return err
+		}
+	}
+
+	// Only cards the deck was loaded or saved with were removed from it. Others were
+	// written since by someone else, or could not be decoded and are kept for the user to
+	// repair
+	for id := range stored {
+		if !known[id] {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM cards WHERE id = ? AND deck_id = ?`, id, deck.ID); err != nil {
+			return fmt.Errorf("failed to delete card: %w", err)
+		}
+	}
+	return nil
+}
+
+// storedCard is a card row as it is in the database
+type storedCard struct {
+	position int
+	data     string
+}
+
+// storedCards returns the deck's card rows keyed by card ID
+func storedCards(tx *sql.Tx, deckID string) (map[string]storedCard, error) {
+	rows, err := tx.Query(`SELECT id, position, data FROM cards WHERE deck_id = ?`, deckID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cards: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]storedCard)
+	for rows.Next() {
+		var id string
+		var row storedCard
+		if err := rows.Scan(&id, &row.position, &row.data); err != nil {
+			return nil, fmt.Errorf("failed to read card: %w", err)
+		}
+		stored[id] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cards: %w", err)
+	}
+	return stored, nil
+}
+
+// saveDeckRow writes the deck's own fields, leaving its cards to the cards table
+func saveDeckRow(tx *sql.Tx, deck *models.Deck) error {
+	meta := *deck
+	meta.Cards = nil
+	data, err := json.Marshal(&meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deck: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO decks (id, name, modified, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, modified = excluded.modified, data = excluded.data`,
+		deck.ID, deck.Name, deck.Modified.UnixMilli(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write deck: %w", err)
+	}
+	return nil
+}
+
+// saveCardRow inserts or replaces a single card
+func saveCardRow(tx *sql.Tx, deckID string, position int, card *models.Card) error {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return fmt.Errorf("failed to marshal card: %w", err)
+	}
+	return writeCardRow(tx, deckID, position, card, data)
+}
+
+// writeCardRow inserts or replaces a card already marshalled to data
+func writeCardRow(tx *sql.Tx, deckID string, position int, card *models.Card, data []byte) error {
+	_, err := tx.Exec(`INSERT INTO cards (id, deck_id, position, next_review, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET deck_id = excluded.deck_id, position = excluded.position,
+			next_review = excluded.next_review, data = excluded.data`,
+		card.ID, deckID, position, card.NextReview.Unix(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write card: %w", err)
+	}
+	return nil
+}
+
+// LoadDeck loads a deck by ID along with its cards
+func (s *SQLiteStorage) LoadDeck(id string) (*models.Deck, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM decks WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("deck with ID %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deck: %w", err)
+	}
+
+	var deck models.Deck
+	if err := json.Unmarshal([]byte(data), &deck); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deck: %w", err)
+	}
+
+	// Cards that can't be decoded are left out, and reported by LoadAllDecks
+	cards, _, err := s.loadCards(`SELECT id, deck_id, data FROM cards WHERE deck_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	deck.Cards = cards[id]
+	if deck.Cards == nil {
+		deck.Cards = make([]models.Card, 0)
+	}
+	s.rememberCards(deck.ID, deck.Cards)
+
+	return &deck, nil
+}
+
+// LoadAllDecks loads all decks with two queries rather than one per deck
+func (s *SQLiteStorage) LoadAllDecks() ([]*models.Deck, error) {
+	rows, err := s.db.Query(`SELECT id, name, data FROM decks ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list decks: %w", err)
+	}
+	defer rows.Close()
+
+	var decks []*models.Deck
+	var warnings []LoadWarning
+	for rows.Next() {
+		var id, name, data string
+		if err := rows.Scan(&id, &name, &data); err != nil {
+			return nil, fmt.Errorf("failed to read deck: %w", err)
+		}
+
+		var deck models.Deck
+		if err := json.Unmarshal([]byte(data), &deck); err != nil {
+			// Keep going with the other decks, as the JSON backend does, but remember the
+			// row so it can be reported
+			warnings = append(warnings, LoadWarning{
+				Path: fmt.Sprintf("%s, deck %s", s.path, id),
+				Err:  fmt.Errorf("%s: %w", name, err),
+			})
+			continue
+		}
+		decks = append(decks, &deck)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list decks: %w", err)
+	}
+
+	cards, cardWarnings, err := s.loadCards(`SELECT id, deck_id, data FROM cards ORDER BY deck_id, position`)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, cardWarnings...)
+	for _, deck := range decks {
+		deck.Cards = cards[deck.ID]
+		if deck.Cards == nil {
+			deck.Cards = make([]models.Card, 0)
+		}
+		s.rememberCards(deck.ID, deck.Cards)
+	}
+
+	s.mu.Lock()
+	s.warnings = warnings
+	s.mu.Unlock()
+
+	return decks, nil
+}
+
+// LoadWarnings returns the decks and cards the last LoadAllDecks could not decode
+func (s *SQLiteStorage) LoadWarnings() []LoadWarning {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.warnings
+}
+
+// knownCards returns the IDs of the deck's cards this instance has loaded or saved
+func (s *SQLiteStorage) knownCards(deckID string) map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.known[deckID]
+}
+
+// rememberCards records the cards a deck was loaded or saved with
+func (s *SQLiteStorage) rememberCards(deckID string, cards []models.Card) {
+	ids := make(map[string]bool, len(cards))
+	for _, card := range cards {
+		ids[card.ID] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.known == nil {
+		s.known = make(map[string]map[string]bool)
+	}
+	s.known[deckID] = ids
+}
+
+// loadCards runs a query returning id, deck_id and data columns, grouping the cards by deck.
+// Cards that can't be decoded are skipped and returned as warnings
+func (s *SQLiteStorage) loadCards(query string, args ...any) (map[string][]models.Card, []LoadWarning, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read cards: %w", err)
+	}
+	defer rows.Close()
+
+	cards := make(map[string][]models.Card)
+	var warnings []LoadWarning
+	for rows.Next() {
+		var id, deckID, data string
+		if err := rows.Scan(&id, &deckID, &data); err != nil {
+			return nil, nil, fmt.Errorf("failed to read card: %w", err)
+		}
+
+		var card models.Card
+		if err := json.Unmarshal([]byte(data), &card); err != nil {
+			warnings = append(warnings, LoadWarning{
+				Path: fmt.Sprintf("%s, card %s", s.path, id),
+				Err:  fmt.Errorf("deck %s: %w", deckID, err),
+			})
+			continue
+		}
+		cards[deckID] = append(cards[deckID], card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read cards: %w", err)
+	}
+
+	return cards, warnings, nil
+}
+
+// DeleteDeck deletes a deck and its cards
+func (s *SQLiteStorage) DeleteDeck(id string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	result, err := s.db.Exec(`DELETE FROM decks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete deck: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("deck with ID %s not found", id)
+	}
+	s.mu.Lock()
+	delete(s.known, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// DeckExists checks if a deck exists
+func (s *SQLiteStorage) DeckExists(id string) bool {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM decks WHERE id = ?)`, id).Scan(&exists)
+	return err == nil && exists
+}
+
+// ListDeckIDs returns a list of all deck IDs
+func (s *SQLiteStorage) ListDeckIDs() ([]string, error) {
+	rows, err := s.db.Query(`SELECT id FROM decks ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list decks: %w", err)
+	}
+	defer rows.Close()
+
+	var deckIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to read deck ID: %w", err)
+		}
+		deckIDs = append(deckIDs, id)
+	}
+
+	return deckIDs, rows.Err()
+}
+
+// RecordReview updates only the rated card and the deck row, and inserts the log entry,
+// all in one transaction
+func (s *SQLiteStorage) RecordReview(deck *models.Deck, log *models.ReviewLog) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	position := -1
+	for i := range deck.Cards {
+		if deck.Cards[i].ID == log.CardID {
+			position = i
+			break
+		}
+	}
+	if position < 0 {
+		return fmt.Errorf("card with ID %s not found in deck %s", log.CardID, deck.ID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveDeckRow(tx, deck); err != nil {
+		return err
+	}
+	if err := saveCardRow(tx, deck.ID, position, &deck.Cards[position]); err != nil {
+		return err
+	}
+	if err := insertReviewLog(tx, log); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record review: %w", err)
+	}
+	return nil
+}
+
+// UndoReview saves the deck and deletes the review log entry in one transaction
+func (s *SQLiteStorage) UndoReview(deck *models.Deck, logID string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveDeckTx(tx, deck, s.knownCards(deck.ID)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM review_logs WHERE id = ?`, logID); err != nil {
+		return fmt.Errorf("failed to delete review log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to undo review: %w", err)
+	}
+	s.rememberCards(deck.ID, deck.Cards)
+	return nil
+}
+
+// ImportReviewLogs inserts review log entries, ignoring ones already present
+func (s *SQLiteStorage) ImportReviewLogs(logs []*models.ReviewLog) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, log := range logs {
+		if err := insertReviewLog(tx, log); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to import review logs: %w", err)
+	}
+	return nil
+}
+
+// insertReviewLog writes a review log entry unless its ID already exists
+func insertReviewLog(tx *sql.Tx, log *models.ReviewLog) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review log: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO review_logs (id, card_id, deck_id, timestamp, data) VALUES (?, ?, ?, ?, ?)`,
+		log.ID, log.CardID, log.DeckID, log.Timestamp.UnixMilli(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write review log: %w", err)
+	}
+	return nil
+}
+
+// GetReviewLogsByCard returns all review log entries for a card
+func (s *SQLiteStorage) GetReviewLogsByCard(cardID string) ([]*models.ReviewLog, error) {
+	return s.queryReviewLogs(`SELECT data FROM review_logs WHERE card_id = ? ORDER BY timestamp`, cardID)
+}
+
+// GetReviewLogsByDeck returns all review log entries for a deck
+func (s *SQLiteStorage) GetReviewLogsByDeck(deckID string) ([]*models.ReviewLog, error) {
+	return s.queryReviewLogs(`SELECT data FROM review_logs WHERE deck_id = ? ORDER BY timestamp`, deckID)
+}
+
+// GetReviewLogsInRange returns review log entries with start <= timestamp < end
+func (s *SQLiteStorage) GetReviewLogsInRange(start, end time.Time) ([]*models.ReviewLog, error) {
+	return s.queryReviewLogs(`SELECT data FROM review_logs WHERE timestamp >= ? AND timestamp < ? ORDER BY timestamp`,
+		start.UnixMilli(), end.UnixMilli())
+}
+
+// queryReviewLogs decodes the review log entries returned by a query on the data column
+func (s *SQLiteStorage) queryReviewLogs(query string, args ...any) ([]*models.ReviewLog, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*models.ReviewLog
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+
+		var log models.ReviewLog
+		if err := json.Unmarshal([]byte(data), &log); err != nil {
+			continue
+		}
+		logs = append(logs, &log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+
+	return logs, nil
+}
+
+// SaveNoteTypes replaces the stored custom note types in one transaction
+func (s *SQLiteStorage) SaveNoteTypes(noteTypes []*models.CustomNoteType) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM note_types`); err != nil {
+		return fmt.Errorf("failed to clear note types: %w", err)
+	}
+	for _, noteType := range noteTypes {
+		data, err := json.Marshal(noteType)
+		if err != nil {
+			return fmt.Errorf("failed to marshal note type: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO note_types (id, name, data) VALUES (?, ?, ?)`,
+			string(noteType.ID), noteType.Name, string(data)); err != nil {
+			return fmt.Errorf("failed to write note type: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save note types: %w", err)
+	}
+	return nil
+}
+
+// LoadNoteTypes returns the custom note types, sorted by name
+func (s *SQLiteStorage) LoadNoteTypes() ([]*models.CustomNoteType, error) {
+	rows, err := s.db.Query(`SELECT data FROM note_types ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note types: %w", err)
+	}
+	defer rows.Close()
+
+	var noteTypes []*models.CustomNoteType
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read note types: %w", err)
+		}
+
+		var noteType models.CustomNoteType
+		if err := json.Unmarshal([]byte(data), &noteType); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal note type: %w", err)
+		}
+		noteType.AssignOrds()
+		noteTypes = append(noteTypes, &noteType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read note types: %w", err)
+	}
+
+	return noteTypes, nil
+}
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSQLiteSaveDeck(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(deck *models.Deck)
+		want   []string // Card fronts in deck order
+	}{
+		{"unchanged", func(deck *models.Deck) {}, []string{"perro", "gato", "pez"}},
+		{"card edited", func(deck *models.Deck) { deck.Cards[1].Front = "gata" }, []string{"perro", "gata", "pez"}},
+		{"card removed", func(deck *models.Deck) { deck.Cards = slices.Delete(deck.Cards, 0, 1) }, []string{"gato", "pez"}},
+		{"card added", func(deck *models.Deck) { deck.Cards = append(deck.Cards, *models.NewCard("pato", "duck")) }, []string{"perro", "gato", "pez", "pato"}},
+		{"cards reordered", func(deck *models.Deck) { slices.Reverse(deck.Cards) }, []string{"pez", "gato", "perro"}},
+		{"all removed", func(deck *models.Deck) { deck.Cards = deck.Cards[:0] }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestSQLite(t)
+			deck := testDeck("Spanish", "perro", "gato", "pez")
+			other := testDeck("French", "chien")
+			for _, d := range []*models.Deck{deck, other} {
+				if err := s.SaveDeck(d); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tt.change(deck)
+			if err := s.SaveDeck(deck); err != nil {
+				t.Fatalf("SaveDeck failed: %v", err)
+			}
+
+			loaded, err := s.LoadDeck(deck.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cardFronts(loaded); !slices.Equal(got, tt.want) {
+				t.Errorf("loaded cards %v, want %v", got, tt.want)
+			}
+			// Saving one deck leaves the others alone
+			if loaded, err := s.LoadDeck(other.ID); err != nil || len(loaded.Cards) != 1 {
+				t.Errorf("other deck lost its cards: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteUnreadableCard(t *testing.T) {
+	s := openTestSQLite(t)
+	deck := testDeck("Spanish", "perro", "gato")
+	if err := s.SaveDeck(deck); err != nil {
+		t.Fatal(err)
+	}
+	broken := deck.Cards[1].ID
+	if _, err := s.db.Exec(`UPDATE cards SET data = '{"id":' WHERE id = ?`, broken); err != nil {
+		t.Fatal(err)
+	}
+
+	decks, err := s.LoadAllDecks()
+	if err != nil {
+		t.Fatalf("LoadAllDecks failed on an unreadable card: %v", err)
+	}
+	if len(decks) != 1 || !slices.Equal(cardFronts(decks[0]), []string{"perro"}) {
+		t.Fatalf("loaded %d decks, want the deck without its unreadable card", len(decks))
+	}
+	warnings := s.LoadWarnings()
+	if len(warnings) != 1 || warnings[0].Path != s.path+", card "+broken {
+		t.Errorf("warnings = %v, want one for card %s", warnings, broken)
+	}
+
+	// Saving the deck as loaded keeps the unreadable row for repair
+	if err := s.SaveDeck(decks[0]); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM cards WHERE id = ?`, broken).Scan(&count); err != nil || count != 1 {
+		t.Errorf("unreadable card was deleted by saving its deck")
+	}
+}
+
+func TestSQLiteStaleSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sqliteFileName)
+	first, err := OpenSQLiteStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	if err := first.SaveDeck(testDeck("Spanish", "perro", "gato")); err != nil {
+		t.Fatal(err)
+	}
+	decks, err := first.LoadAllDecks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := decks[0]
+
+	// Another writer adds a card after the deck was loaded
+	second, err := OpenSQLiteStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	fresh, err := second.LoadDeck(stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh.Cards = append(fresh.Cards, *models.NewCard("pato", "duck"))
+	if err := second.SaveDeck(fresh); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving the stale copy removes the card it dropped, but not the one it never saw
+	stale.Cards = slices.Delete(stale.Cards, 0, 1)
+	if err := first.SaveDeck(stale); err != nil {
+		t.Fatalf("SaveDeck failed: %v", err)
+	}
+	loaded, err := first.LoadDeck(stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cardFronts(loaded); !slices.Equal(got, []string{"gato", "pato"}) {
+		t.Errorf("loaded cards %v, want [gato pato]", got)
+	}
+}
+
+func TestSQLiteReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), sqliteFileName)
+	if _, err := OpenReadOnlySQLiteStorage(path); err == nil {
+		t.Errorf("opening a missing database read-only succeeded")
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Errorf("opening a missing database read-only created it")
+	}
+
+	s, err := OpenSQLiteStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck := testDeck("Spanish", "perro")
+	if err := s.SaveDeck(deck); err != nil {
+		t.Fatal(err)
+	}
+
+	// The writer stays open, as the TUI does while the CLI reads
+	defer s.Close()
+	reader, err := OpenReadOnlySQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnlySQLiteStorage failed: %v", err)
+	}
+	defer reader.Close()
+
+	decks, err := reader.LoadAllDecks()
+	if err != nil || len(decks) != 1 || len(decks[0].Cards) != 1 {
+		t.Errorf("read-only load = %d decks, %v", len(decks), err)
+	}
+	if err := reader.SaveDeck(deck); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SaveDeck = %v, want %v", err, ErrReadOnly)
+	}
+	if err := reader.RecordReview(deck, &models.ReviewLog{ID: "log", CardID: deck.Cards[0].ID}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("RecordReview = %v, want %v", err, ErrReadOnly)
+	}
+}
+
+// openTestSQLite opens a database in a temporary directory, closed when the test ends
+func openTestSQLite(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	s, err := OpenSQLiteStorage(filepath.Join(t.TempDir(), sqliteFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// testDeck returns a deck holding a card for each front
+func testDeck(name string, fronts ...string) *models.Deck {
+	deck := models.NewDeck(name, "")
+	for _, front := range fronts {
+		deck.Cards = append(deck.Cards, *models.NewCard(front, front))
+	}
+	return deck
+}
+
+// cardFronts returns the fronts of the deck's cards in order
+func cardFronts(deck *models.Deck) []string {
+	var fronts []string
+	for _, card := range deck.Cards {
+		fronts = append(fronts, card.Front)
+	}
+	return fronts
+}
+
+func TestSQLitePathCharacters(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{"question mark", "what?"},
+		{"hash", "deck#1"},
+		{"percent", "100%25"},
+		{"space", "my decks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), tt.dir)
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, sqliteFileName)
+			s, err := OpenSQLiteStorage(path)
+			if err != nil {
+				t.Fatalf("OpenSQLiteStorage failed: %v", err)
+			}
+			defer s.Close()
+			if err := s.SaveDeck(testDeck("Spanish", "perro")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("database was not created at its path: %v", err)
+			}
+
+			reader, err := OpenReadOnlySQLiteStorage(path)
+			if err != nil {
+				t.Fatalf("OpenReadOnlySQLiteStorage failed: %v", err)
+			}
+			defer reader.Close()
+			if decks, err := reader.LoadAllDecks(); err != nil || len(decks) != 1 {
+				t.Errorf("read-only load = %d decks, %v", len(decks), err)
+			}
+		})
+	}
+}
+
+func TestSQLiteLock(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DataDirectory = t.TempDir()
+
+	first, err := NewSQLiteStorage(cfg)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage failed: %v", err)
+	}
+	if _, err := NewSQLiteStorage(cfg); !errors.Is(err, ErrLocked) {
+		t.Errorf("second NewSQLiteStorage = %v, want %v", err, ErrLocked)
+	}
+
+	// Readers don't take the lock
+	reader, err := NewReadOnlySQLiteStorage(cfg)
+	if err != nil {
+		t.Fatalf("NewReadOnlySQLiteStorage failed: %v", err)
+	}
+	reader.Close()
+
+	// Closing releases the lock for the next instance
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewSQLiteStorage(cfg)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage after Close failed: %v", err)
+	}
+	second.Close()
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"anktui/models"
+	"time"
 )
 
 // Storage interface defines the methods for persisting and retrieving deck data
@@ -23,4 +24,38 @@ type Storage interface {
 
 	// ListDeckIDs returns a list of all deck IDs in storage
 	ListDeckIDs() ([]string, error)
+
+	// RecordReview saves the deck containing a rated card and appends the review log entry
+	RecordReview(deck *models.Deck, log *models.ReviewLog) error
+
+	// UndoReview saves the deck of a card whose rating was taken back and removes the
+	// rating's review log entry
+	UndoReview(deck *models.Deck, logID string) error
+
+	// GetReviewLogsByCard returns all review log entries for a card, oldest first
+	GetReviewLogsByCard(cardID string) ([]*models.ReviewLog, error)
+
+	// GetReviewLogsByDeck returns all review log entries for a deck, oldest first
+	GetReviewLogsByDeck(deckID string) ([]*models.ReviewLog, error)
+
+	// GetReviewLogsInRange returns review log entries with start <= timestamp < end, oldest first
+	GetReviewLogsInRange(start, end time.Time) ([]*models.ReviewLog, error)
+
+	// SaveNoteTypes replaces the stored custom note types
+	SaveNoteTypes(noteTypes []*models.CustomNoteType) error
+
+	// LoadNoteTypes returns the custom note types, sorted by name
+	LoadNoteTypes() ([]*models.CustomNoteType, error)
+}
+
+// LoadWarning describes stored data that could not be read and was skipped
+type LoadWarning struct {
+	Path string
+	Err  error
+}
+
+// WarningReporter is implemented by backends that skip unreadable decks rather than failing
+type WarningReporter interface {
+	// LoadWarnings returns the problems found by the last LoadAllDecks
+	LoadWarnings() []LoadWarning
 }
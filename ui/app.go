@@ -1,12 +1,23 @@
 package ui
 
 import (
+	"anktui/algorithms"
+	"anktui/backup"
 	"anktui/config"
+	"anktui/interop"
 	"anktui/models"
 	"anktui/storage"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // Screen represents different screens in the application
@@ -18,6 +29,11 @@ const (
 	StudyScreen
 	DeckManagerScreen
 	CardEditorScreen
+	StatsScreen
+	BackupScreen
+	BrowserScreen
+	NoteTypesScreen
+	CustomStudyScreen
 )
 
 // App represents the main application model
@@ -36,14 +52,42 @@ type App struct {
 	study       *StudyModel
 	deckManager *DeckManagerModel
 	cardEditor  *CardEditorModel
+	stats       *StatsModel
+	backups     *BackupsModel
+	browser     *BrowserModel
+	noteTypes   *NoteTypesModel
+	customStudy *CustomStudyModel
 
 	// Data
 	decks          []*models.Deck
 	currentDeck    *models.Deck
 	currentSession *models.StudySession
+	customTypes    []*models.CustomNoteType
 
 	// Error state
 	errorMessage string
+	fatalErr     error // Made the app quit, as storage could not be opened again
+
+	// Edits of decks and cards that can be undone, and the notice saying what was undone
+	history editHistory
+	toast   string
+	toastID int // Tells the latest toast from expired ones
+
+	// Storage writes running in commands, which a restore waits for
+	writes sync.WaitGroup
+
+	// Ratings being saved, by review log ID. Rating and undo messages are sent from separate
+	// commands and may arrive in either order, so an undo waits for its rating to be saved
+	reviews  map[string]*pendingReview
+	reviewMu sync.Mutex // Keeps review writes from overlapping
+	studied  bool       // A card was rated since the study screen was opened
+}
+
+// pendingReview is a rating being saved. Its undo waits until done is closed
+type pendingReview struct {
+	done      chan struct{}
+	undone    bool // The undo arrived first, so the rating is never recorded
+	forgotten bool // The rating can no longer be undone, so it is dropped once saved
 }
 
 // NewApp creates a new application instance
@@ -53,12 +97,56 @@ func NewApp(cfg *config.Config, store storage.Storage) *App {
 		storage:       store,
 		currentScreen: MenuScreen,
 		menu:          NewMenuModel(),
+		reviews:       make(map[string]*pendingReview),
 	}
 }
 
+// Close releases the storage the app ends with, which a restore may have replaced
+func (a *App) Close() error {
+	if closer, ok := a.storage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Err returns the error that made the app quit, if any
+func (a *App) Err() error {
+	return a.fatalErr
+}
+
 // Init implements tea.Model
 func (a *App) Init() tea.Cmd {
-	// Load all decks on startup
+	// Load all decks and note types on startup, taking a backup alongside when enabled
+	if a.config.BackupEnabled {
+		return tea.Batch(a.loadDecks(), a.loadNoteTypes(), a.createBackup())
+	}
+	return tea.Batch(a.loadDecks(), a.loadNoteTypes())
+}
+
+// createBackup snapshots the data directory and applies the retention rules
+func (a *App) createBackup() tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		snapshot, err := backup.Run(a.config)
+		return BackupCreatedMsg{Snapshot: snapshot, Err: err}
+	})
+}
+
+// leaveStudy backs up the data directory when the study screen is left after a review,
+// once the session's ratings are saved
+func (a *App) leaveStudy() tea.Cmd {
+	studied := a.studied
+	a.studied = false
+	if !studied || !a.config.BackupEnabled {
+		return nil
+	}
+	return tea.Cmd(func() tea.Msg {
+		a.writes.Wait()
+		return a.createBackup()()
+	})
+}
+
+// loadDecks reads every deck from storage
+func (a *App) loadDecks() tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
 		decks, err := a.storage.LoadAllDecks()
 		if err != nil {
@@ -68,6 +156,78 @@ func (a *App) Init() tea.Cmd {
 	})
 }
 
+// write runs a command that writes to storage, counting it as pending until it returns
+func (a *App) write(cmd func() tea.Msg) tea.Cmd {
+	a.writes.Add(1)
+	return tea.Cmd(func() tea.Msg {
+		defer a.writes.Done()
+		return cmd()
+	})
+}
+
+// restoreBackup replaces the data directory with a snapshot once pending writes finish,
+// closing the storage while the files are swapped and opening it again on the restored data.
+// The new storage is handed back to the update loop in BackupRestoredMsg
+func (a *App) restoreBackup(snapshot backup.Snapshot) tea.Cmd {
+	old := a.storage
+	return tea.Cmd(func() tea.Msg {
+		// Let pending writes finish so none of them lands in the restored data
+		a.writes.Wait()
+		if closer, ok := old.(io.Closer); ok {
+			closer.Close()
+		}
+		safety, restoreErr := backup.RestoreSnapshot(a.config, &snapshot)
+
+		store, err := storage.New(a.config)
+		if err != nil {
+			// Put back the data that was replaced rather than carry on with closed storage
+			err = fmt.Errorf("failed to open storage after restore: %w", err)
+			if safety == nil {
+				return BackupRestoredMsg{Err: err}
+			}
+			store, revertErr := a.revertRestore(safety)
+			if revertErr != nil {
+				return BackupRestoredMsg{Err: err}
+			}
+			return BackupRestoredMsg{Store: store, Err: err}
+		}
+		if restoreErr != nil {
+			return BackupRestoredMsg{Store: store, Err: restoreErr}
+		}
+
+		decks, err := store.LoadAllDecks()
+		return BackupRestoredMsg{Restored: snapshot, Safety: safety, Decks: decks, Store: store, Err: err}
+	})
+}
+
+// revertRestore restores the snapshot taken of the data a restore replaced and opens the
+// storage on it again
+func (a *App) revertRestore(safety *backup.Snapshot) (storage.Storage, error) {
+	dataDir, err := a.config.GetExpandedDataDir()
+	if err != nil {
+		return nil, err
+	}
+	backupDir, err := a.config.GetBackupDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := backup.Restore(safety.Path, dataDir, backupDir); err != nil {
+		return nil, err
+	}
+	return storage.New(a.config)
+}
+
+// loadNoteTypes reads the custom note types from storage
+func (a *App) loadNoteTypes() tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		noteTypes, err := a.storage.LoadNoteTypes()
+		if err != nil {
+			return ErrorMsg{err}
+		}
+		return NoteTypesLoadedMsg{noteTypes}
+	})
+}
+
 // Update implements tea.Model
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
@@ -93,23 +253,51 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if a.cardEditor != nil {
 			a.cardEditor.SetSize(msg.Width, msg.Height)
 		}
+		if a.stats != nil {
+			a.stats.SetSize(msg.Width, msg.Height)
+		}
+		if a.backups != nil {
+			a.backups.SetSize(msg.Width, msg.Height)
+		}
+		if a.browser != nil {
+			a.browser.SetSize(msg.Width, msg.Height)
+		}
+		if a.noteTypes != nil {
+			a.noteTypes.SetSize(msg.Width, msg.Height)
+		}
+		if a.customStudy != nil {
+			a.customStudy.SetSize(msg.Width, msg.Height)
+		}
 
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			if msg.String() == "q" && a.capturingText() {
+				// Let the screen treat it as typed text
+				break
+			}
 			if a.currentScreen == MenuScreen {
 				return a, tea.Quit
 			}
 			// For other screens, go back to menu
+			var cmd tea.Cmd
+			if a.currentScreen == StudyScreen {
+				cmd = a.leaveStudy()
+			}
 			a.currentScreen = MenuScreen
 			a.errorMessage = ""
-			return a, nil
+			return a, cmd
 		}
 
 	case DecksLoadedMsg:
 		a.decks = msg.Decks
 		a.errorMessage = ""
 
+		// Report deck files that were skipped instead of silently dropping them
+		if reporter, ok := a.storage.(storage.WarningReporter); ok {
+			a.menu.SetWarnings(reporter.LoadWarnings())
+		}
+
 		// Update any existing screen models with fresh data
 		if a.deckList != nil {
 			a.deckList.UpdateDecks(msg.Decks)
@@ -119,76 +307,464 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			newModel, _ := a.deckManager.Update(msg)
 			a.deckManager = newModel.(*DeckManagerModel)
 		}
+		if a.stats != nil {
+			a.stats.UpdateDecks(msg.Decks)
+		}
+
+	case NoteTypesLoadedMsg:
+		a.customTypes = msg.NoteTypes
+		if a.noteTypes != nil {
+			a.noteTypes.UpdateNoteTypes(msg.NoteTypes)
+		}
 
 	case ErrorMsg:
 		a.errorMessage = msg.Error.Error()
 
+	case ReloadDecksMsg:
+		return a, a.loadDecks()
+
 	case NavigateMsg:
+		if a.currentScreen == StudyScreen && msg.Screen != StudyScreen {
+			leaveCmd := a.leaveStudy()
+			model, cmd := a.handleNavigation(msg)
+			return model, tea.Batch(leaveCmd, cmd)
+		}
 		return a.handleNavigation(msg)
 
 	case SaveDeckMsg:
 		// Save the deck to storage
-		return a, tea.Cmd(func() tea.Msg {
+		return a, a.write(func() tea.Msg {
 			if err := a.storage.SaveDeck(msg.Deck); err != nil {
 				return ErrorMsg{err}
 			}
 			return nil
 		})
 
-	case CreateDeckMsg:
-		// Create a new deck
-		return a, tea.Cmd(func() tea.Msg {
-			// Create new deck with proper initialization
-			newDeck := models.NewDeck(msg.Deck.Name, msg.Deck.Description)
-			if err := a.storage.SaveDeck(newDeck); err != nil {
+	case ReviewCardMsg:
+		// Save the rated card and append it to the review log
+		review, ok := a.reviews[msg.Log.ID]
+		if ok && review.undone {
+			// The rating was taken back before it was saved, so only its undo is written
+			delete(a.reviews, msg.Log.ID)
+			close(review.done)
+			return a, nil
+		}
+		review = &pendingReview{done: make(chan struct{}), forgotten: ok && review.forgotten}
+		a.reviews[msg.Log.ID] = review
+		a.studied = true
+		return a, a.write(func() tea.Msg {
+			a.reviewMu.Lock()
+			err := a.storage.RecordReview(msg.Deck, msg.Log)
+			a.reviewMu.Unlock()
+			close(review.done)
+			if err != nil {
 				return ErrorMsg{err}
 			}
-			// Reload decks to refresh the list
-			decks, err := a.storage.LoadAllDecks()
-			if err != nil {
+			return ReviewSavedMsg{LogID: msg.Log.ID}
+		})
+
+	case ReviewSavedMsg:
+		if review, ok := a.reviews[msg.LogID]; ok && review.forgotten {
+			delete(a.reviews, msg.LogID)
+		}
+
+	case ForgetReviewsMsg:
+		// Saved ratings are dropped now, the others once they are saved. A rating whose
+		// message has not arrived yet is marked ahead of it
+		for _, logID := range msg.LogIDs {
+			review, ok := a.reviews[logID]
+			if !ok {
+				a.reviews[logID] = &pendingReview{done: make(chan struct{}), forgotten: true}
+				continue
+			}
+			select {
+			case <-review.done:
+				delete(a.reviews, logID)
+			default:
+				review.forgotten = true
+			}
+		}
+
+	case UndoReviewMsg:
+		// Save the card as it was before the rating and drop the rating from the review log,
+		// once the rating itself has been saved
+		review, ok := a.reviews[msg.LogID]
+		if ok {
+			delete(a.reviews, msg.LogID)
+		} else {
+			review = &pendingReview{done: make(chan struct{}), undone: true}
+			a.reviews[msg.LogID] = review
+		}
+		undo := func() tea.Msg {
+			<-review.done
+			a.reviewMu.Lock()
+			defer a.reviewMu.Unlock()
+			if err := a.storage.UndoReview(msg.Deck, msg.LogID); err != nil {
 				return ErrorMsg{err}
 			}
-			return DecksLoadedMsg{decks}
+			return nil
+		}
+		return a, a.write(undo)
+
+	case ListBackupsMsg:
+		return a, tea.Cmd(func() tea.Msg {
+			backupDir, err := a.config.GetBackupDir()
+			if err != nil {
+				return BackupsLoadedMsg{Err: err}
+			}
+			snapshots, err := backup.List(backupDir)
+			return BackupsLoadedMsg{Snapshots: snapshots, Err: err}
 		})
 
-	case UpdateDeckMsg:
-		// Update existing deck
+	case InspectBackupMsg:
 		return a, tea.Cmd(func() tea.Msg {
-			msg.Deck.MarkModified()
-			if err := a.storage.SaveDeck(msg.Deck); err != nil {
-				return ErrorMsg{err}
+			summary, err := backup.Inspect(msg.Path)
+			return BackupInspectedMsg{Path: msg.Path, Summary: summary, Err: err}
+		})
+
+	case CreateBackupMsg:
+		return a, a.createBackup()
+
+	case BackupCreatedMsg:
+		// Automatic backups report failures wherever the user is
+		if msg.Err != nil && a.currentScreen != BackupScreen {
+			a.errorMessage = fmt.Sprintf("backup failed: %v", msg.Err)
+		}
+
+	case RestoreBackupMsg:
+		return a, a.restoreBackup(msg.Snapshot)
+
+	case BackupRestoredMsg:
+		if msg.Store == nil {
+			// Neither the restored data nor the data it replaced could be opened
+			a.fatalErr = msg.Err
+			return a, tea.Quit
+		}
+		a.storage = msg.Store
+		if msg.Err == nil {
+			// Nothing held from before the restore matches the data any more, edits to undo
+			// included
+			a.decks = msg.Decks
+			a.history = editHistory{}
+			a.deckList = nil
+			a.deckManager = nil
+			a.study = nil
+			a.cardEditor = nil
+			a.noteTypes = nil
+			if a.backups != nil {
+				newModel, newCmd := a.backups.Update(msg)
+				a.backups = newModel.(*BackupsModel)
+				cmd = newCmd
+			}
+			return a, tea.Batch(cmd, a.loadNoteTypes())
+		}
+
+	case ImportAPKGMsg:
+		// Import an Anki package and save the resulting decks
+		return a, a.write(func() tea.Msg {
+			path, err := config.ExpandPath(msg.Path)
+			if err != nil {
+				return ImportCompleteMsg{Err: err}
+			}
+			mediaDir, err := a.config.GetMediaDir()
+			if err != nil {
+				return ImportCompleteMsg{Err: err}
+			}
+			result, err := interop.ImportAPKG(path, mediaDir, a.config.StudySession.DayRolloverHour)
+			if err != nil {
+				return ImportCompleteMsg{Err: err}
+			}
+			for _, deck := range result.Decks {
+				if err := a.storage.SaveDeck(deck); err != nil {
+					return ImportCompleteMsg{Result: result, Err: err}
+				}
 			}
-			// Reload decks to refresh the list
 			decks, err := a.storage.LoadAllDecks()
 			if err != nil {
-				return ErrorMsg{err}
+				return ImportCompleteMsg{Result: result, Err: err}
 			}
-			return DecksLoadedMsg{decks}
+			return ImportCompleteMsg{Result: result, Decks: decks}
 		})
 
-	case DeleteDeckMsg:
-		// Delete deck
+	case ExportAPKGMsg:
+		// Export decks with their review history to an Anki package
 		return a, tea.Cmd(func() tea.Msg {
-			if err := a.storage.DeleteDeck(msg.Deck.ID); err != nil {
-				return ErrorMsg{err}
+			path, err := config.ExpandPath(msg.Path)
+			if err != nil {
+				return ExportCompleteMsg{Err: err}
+			}
+			mediaDir, err := a.config.GetMediaDir()
+			if err != nil {
+				return ExportCompleteMsg{Err: err}
+			}
+			var logs []*models.ReviewLog
+			for _, deck := range msg.Decks {
+				deckLogs, err := a.storage.GetReviewLogsByDeck(deck.ID)
+				if err != nil {
+					return ExportCompleteMsg{Err: err}
+				}
+				logs = append(logs, deckLogs...)
+			}
+			learningSteps, err := algorithms.ParseSteps(a.config.Scheduler.LearningSteps)
+			if err != nil {
+				return ExportCompleteMsg{Err: err}
+			}
+			relearningSteps, err := algorithms.ParseSteps(a.config.Scheduler.RelearningSteps)
+			if err != nil {
+				return ExportCompleteMsg{Err: err}
+			}
+			result, err := interop.ExportAPKG(path, msg.Decks, logs, mediaDir, a.config.StudySession.DayRolloverHour, learningSteps, relearningSteps)
+			return ExportCompleteMsg{Result: result, Path: path, Err: err}
+		})
+
+	case LoadDelimitedMsg:
+		// Read a delimited text file for the import dialog
+		return a, tea.Cmd(func() tea.Msg {
+			path, err := config.ExpandPath(msg.Path)
+			if err != nil {
+				return DelimitedFileLoadedMsg{Path: msg.Path, Err: err}
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return DelimitedFileLoadedMsg{Path: path, Err: fmt.Errorf("failed to read file: %w", err)}
+			}
+			return DelimitedFileLoadedMsg{Path: path, Text: string(data)}
+		})
+
+	case ImportDelimitedMsg:
+		// Add the rows to the target deck and save it
+		return a, a.write(func() tea.Msg {
+			result := interop.ImportDelimited(msg.Deck, msg.Rows, msg.Options)
+			if result.Added > 0 || result.Updated > 0 {
+				msg.Deck.MarkModified()
+				if err := a.storage.SaveDeck(msg.Deck); err != nil {
+					return DelimitedImportCompleteMsg{Result: result, DeckName: msg.Deck.Name, Err: err}
+				}
 			}
-			// Reload decks to refresh the list
 			decks, err := a.storage.LoadAllDecks()
 			if err != nil {
-				return ErrorMsg{err}
+				return DelimitedImportCompleteMsg{Result: result, DeckName: msg.Deck.Name, Err: err}
 			}
-			return DecksLoadedMsg{decks}
+			return DelimitedImportCompleteMsg{Result: result, DeckName: msg.Deck.Name, Decks: decks}
+		})
+
+	case ExportDelimitedMsg:
+		// Export the cards of decks as delimited text
+		return a, tea.Cmd(func() tea.Msg {
+			path, err := config.ExpandPath(msg.Path)
+			if err != nil {
+				return ExportCompleteMsg{Err: err}
+			}
+			file, err := os.Create(path)
+			if err != nil {
+				return ExportCompleteMsg{Err: fmt.Errorf("failed to create file: %w", err)}
+			}
+			defer file.Close()
+
+			count, err := interop.ExportDelimited(file, msg.Decks, interop.DelimiterForPath(path))
+			if err != nil {
+				return ExportCompleteMsg{Err: fmt.Errorf("failed to write file: %w", err)}
+			}
+			result := &interop.ExportResult{Decks: len(msg.Decks), Cards: count}
+			return ExportCompleteMsg{Result: result, Path: path}
+		})
+
+	case DelimitedImportCompleteMsg:
+		// Refresh deck data, then let the deck manager show the summary
+		if msg.Decks != nil {
+			a.decks = msg.Decks
+			if a.deckList != nil {
+				a.deckList.UpdateDecks(msg.Decks)
+			}
+		}
+
+	case ImportCompleteMsg:
+		// Refresh deck data, then let the deck manager show the summary
+		if msg.Decks != nil {
+			a.decks = msg.Decks
+			if a.deckList != nil {
+				a.deckList.UpdateDecks(msg.Decks)
+			}
+		}
+
+	case CreateDeckMsg:
+		// Create a new deck
+		return a, a.recordEdit("creating deck "+msg.Deck.Name, nil, func() ([]string, error) {
+			// Create new deck with proper initialization
+			newDeck := models.NewDeck(msg.Deck.Name, msg.Deck.Description)
+			if err := a.storage.SaveDeck(newDeck); err != nil {
+				return nil, err
+			}
+			return []string{newDeck.ID}, nil
+		})
+
+	case UpdateDeckMsg:
+		// Update existing deck
+		var touched []*models.Deck
+		if deck := deckWithID(a.decks, msg.Deck.ID); deck != nil {
+			touched = append(touched, deck)
+		}
+		return a, a.recordEdit("editing deck "+msg.Deck.Name, touched, func() ([]string, error) {
+			msg.Deck.MarkModified()
+			return nil, a.storage.SaveDeck(msg.Deck)
+		})
+
+	case DeleteDeckMsg:
+		// Delete deck
+		decks := a.decks
+		touched := []*models.Deck{msg.Deck}
+		if msg.Deck.IsFiltered() {
+			touched = append(touched, msg.Deck.BorrowedFrom(decks)...)
+		}
+		return a, a.recordEdit("deleting deck "+msg.Deck.Name, touched, func() ([]string, error) {
+			// A filtered deck gives its cards back first
+			if msg.Deck.IsFiltered() {
+				for _, deck := range msg.Deck.EmptyFiltered(decks) {
+					if err := a.storage.SaveDeck(deck); err != nil {
+						return nil, err
+					}
+				}
+			}
+			return nil, a.storage.DeleteDeck(msg.Deck.ID)
 		})
 
 	case CreateCardMsg:
 		// Create a new card
-		return a, tea.Cmd(func() tea.Msg {
-			// Create new card with proper initialization
-			newCard := models.NewCard(msg.Card.Front, msg.Card.Back)
-			msg.Deck.AddCard(newCard)
-			if err := a.storage.SaveDeck(msg.Deck); err != nil {
+		custom := models.FindNoteType(a.customTypes, msg.Note.Type)
+		return a, a.recordEdit("adding a card to "+msg.Deck.Name, []*models.Deck{msg.Deck}, func() ([]string, error) {
+			// Add the note along with the cards it generates
+			msg.Deck.AddNote(msg.Note, custom)
+			msg.Deck.SetNoteTags(msg.Note.ID, msg.Tags)
+			return nil, a.storage.SaveDeck(msg.Deck)
+		})
+
+	case UpdateCardMsg:
+		if msg.Note == nil {
+			// Change one card of an imported note, whose cards can't be regenerated
+			cardID, noteID := msg.Card.ID, msg.Card.NoteID
+			return a, a.recordEdit("editing a card in "+msg.Deck.Name, []*models.Deck{msg.Deck}, func() ([]string, error) {
+				msg.Deck.UpdateCardContent(cardID, msg.Front, msg.Back)
+				msg.Deck.SetNoteTags(noteID, msg.Tags)
+				return nil, a.storage.SaveDeck(msg.Deck)
+			})
+		}
+
+		// Update existing card
+		custom := models.FindNoteType(a.customTypes, msg.Note.Type)
+		return a, a.recordEdit("editing a card in "+msg.Deck.Name, []*models.Deck{msg.Deck}, func() ([]string, error) {
+			// Regenerate the note's cards, keeping the progress of those that remain
+			msg.Deck.UpdateNote(msg.Note, custom)
+			msg.Deck.SetNoteTags(msg.Note.ID, msg.Tags)
+			return nil, a.storage.SaveDeck(msg.Deck)
+		})
+
+	case TagCardsMsg:
+		// Add or remove tags on several cards at once
+		description := fmt.Sprintf("tagging %s in %s", cardCount(len(msg.CardIDs)), msg.Deck.Name)
+		return a, a.recordEdit(description, []*models.Deck{msg.Deck}, func() ([]string, error) {
+			msg.Deck.TagCards(msg.CardIDs, msg.Add, msg.Remove)
+			return nil, a.storage.SaveDeck(msg.Deck)
+		})
+
+	case MoveCardsMsg:
+		// Move cards to another deck, saving both
+		description := fmt.Sprintf("moving %s to %s", cardCount(len(msg.CardIDs)), msg.To.Name)
+		return a, a.recordEdit(description, []*models.Deck{msg.From, msg.To}, func() ([]string, error) {
+			msg.From.MoveCards(msg.CardIDs, msg.To)
+			if err := a.storage.SaveDeck(msg.To); err != nil {
+				return nil, err
+			}
+			return nil, a.storage.SaveDeck(msg.From)
+		})
+
+	case BrowserActionMsg:
+		// Apply a browser action to cards from any number of decks
+		var touched []*models.Deck
+		for _, result := range msg.Cards {
+			if !slices.Contains(touched, result.Deck) {
+				touched = append(touched, result.Deck)
+			}
+		}
+		if msg.Action == BrowserMove && !slices.Contains(touched, msg.To) {
+			touched = append(touched, msg.To)
+		}
+		return a, a.recordEdit(describeBrowserAction(msg), touched, func() ([]string, error) {
+			for _, deck := range a.applyBrowserAction(msg) {
+				if err := a.storage.SaveDeck(deck); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+
+	case EditRecordedMsg:
+		// The edit is saved, so it can be undone from now on
+		a.history.record(msg.Edit)
+		return a.Update(DecksLoadedMsg{msg.Decks})
+
+	case UndoEditMsg:
+		return a, a.replayEdit(true)
+
+	case RedoEditMsg:
+		return a, a.replayEdit(false)
+
+	case EditReplayedMsg:
+		a.history.replaying = false
+		var toast tea.Cmd
+		if msg.Err != nil {
+			// Part of the edit may have been saved, so it can't be replayed again
+			toast = a.showToast(fmt.Sprintf("Can't %s %s: %s", replayVerb(msg.Undo), msg.Edit.description, msg.Err))
+		} else if msg.Undo {
+			a.history.push(msg.Edit, true)
+			toast = a.showToast("Undid " + msg.Edit.description)
+		} else {
+			a.history.push(msg.Edit, false)
+			toast = a.showToast("Redid " + msg.Edit.description)
+		}
+		if msg.Decks == nil {
+			return a, toast
+		}
+		model, cmd := a.Update(DecksLoadedMsg{msg.Decks})
+		return model, tea.Batch(cmd, toast)
+
+	case ToastExpiredMsg:
+		if msg.ID == a.toastID {
+			a.toast = ""
+		}
+		return a, nil
+
+	case SaveNoteTypeMsg:
+		// Save the note type, then render the cards of its notes again
+		old := models.FindNoteType(a.customTypes, msg.NoteType.ID)
+		noteTypes := make([]*models.CustomNoteType, 0, len(a.customTypes)+1)
+		for _, noteType := range a.customTypes {
+			if noteType.ID != msg.NoteType.ID {
+				noteTypes = append(noteTypes, noteType)
+			}
+		}
+		noteTypes = append(noteTypes, msg.NoteType)
+		sort.Slice(noteTypes, func(i, j int) bool {
+			return noteTypes[i].Name < noteTypes[j].Name
+		})
+		a.customTypes = noteTypes
+		if a.noteTypes != nil {
+			a.noteTypes.UpdateNoteTypes(noteTypes)
+		}
+		decks := a.decks
+		return a, a.write(func() tea.Msg {
+			if err := a.storage.SaveNoteTypes(noteTypes); err != nil {
 				return ErrorMsg{err}
 			}
+			if old != nil {
+				for _, deck := range decks {
+					if deck.ApplyNoteType(old, msg.NoteType, msg.Renames) == 0 {
+						continue
+					}
+					if err := a.storage.SaveDeck(deck); err != nil {
+						return ErrorMsg{err}
+					}
+				}
+			}
 			// Reload decks to refresh the data
 			decks, err := a.storage.LoadAllDecks()
 			if err != nil {
@@ -197,11 +773,20 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return DecksLoadedMsg{decks}
 		})
 
-	case UpdateCardMsg:
-		// Update existing card
-		return a, tea.Cmd(func() tea.Msg {
-			msg.Card.MarkModified()
-			if err := a.storage.SaveDeck(msg.Deck); err != nil {
+	case DeleteNoteTypeMsg:
+		// Delete a note type no note uses any more
+		var noteTypes []*models.CustomNoteType
+		for _, noteType := range a.customTypes {
+			if noteType.ID != msg.ID {
+				noteTypes = append(noteTypes, noteType)
+			}
+		}
+		a.customTypes = noteTypes
+		if a.noteTypes != nil {
+			a.noteTypes.UpdateNoteTypes(noteTypes)
+		}
+		return a, a.write(func() tea.Msg {
+			if err := a.storage.SaveNoteTypes(noteTypes); err != nil {
 				return ErrorMsg{err}
 			}
 			// Reload decks to refresh the data
@@ -212,12 +797,53 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return DecksLoadedMsg{decks}
 		})
 
-	case DeleteCardMsg:
-		// Delete card
-		return a, tea.Cmd(func() tea.Msg {
-			msg.Deck.RemoveCard(msg.Card.ID)
-			if err := a.storage.SaveDeck(msg.Deck); err != nil {
-				return ErrorMsg{err}
+	case BuildFilteredDeckMsg:
+		// Borrow the cards matching a filtered deck's filter, then save it with their home decks
+		decks := a.decks
+		saved := slices.ContainsFunc(decks, func(deck *models.Deck) bool { return deck.ID == msg.Deck.ID })
+		return a, a.write(func() tea.Msg {
+			// Forgotten cards are found through the review history
+			logs, err := a.storage.GetReviewLogsInRange(time.Time{}, time.Now().Add(24*time.Hour))
+			if err != nil {
+				return FilteredDeckBuiltMsg{Deck: msg.Deck, Err: err}
+			}
+			changed, borrowed, err := msg.Deck.RebuildFiltered(decks, models.NewSearchContext(logs, a.config.StudySession.DayRolloverHour))
+			if err != nil {
+				return FilteredDeckBuiltMsg{Deck: msg.Deck, Err: err}
+			}
+			if borrowed == 0 && !saved {
+				// Nothing to study, so don't keep a new empty deck
+				return FilteredDeckBuiltMsg{Deck: msg.Deck}
+			}
+			for _, deck := range append(changed, msg.Deck) {
+				if err := a.storage.SaveDeck(deck); err != nil {
+					return FilteredDeckBuiltMsg{Deck: msg.Deck, Err: err}
+				}
+			}
+			decks, err := a.storage.LoadAllDecks()
+			return FilteredDeckBuiltMsg{Deck: msg.Deck, Borrowed: borrowed, Decks: decks, Err: err}
+		})
+
+	case FilteredDeckBuiltMsg:
+		// Refresh deck data, then let the custom study screen start the session
+		if msg.Decks != nil {
+			a.decks = msg.Decks
+			if a.deckList != nil {
+				a.deckList.UpdateDecks(msg.Decks)
+			}
+		}
+		if msg.Err != nil && a.currentScreen != CustomStudyScreen {
+			a.errorMessage = msg.Err.Error()
+		}
+
+	case EmptyFilteredDeckMsg:
+		// Return a filtered deck's cards to their home decks
+		decks := a.decks
+		return a, a.write(func() tea.Msg {
+			for _, deck := range msg.Deck.EmptyFiltered(decks) {
+				if err := a.storage.SaveDeck(deck); err != nil {
+					return ErrorMsg{err}
+				}
 			}
 			// Reload decks to refresh the data
 			decks, err := a.storage.LoadAllDecks()
@@ -226,6 +852,13 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return DecksLoadedMsg{decks}
 		})
+
+	case DeleteCardMsg:
+		// Delete card
+		return a, a.recordEdit("deleting a card from "+msg.Deck.Name, []*models.Deck{msg.Deck}, func() ([]string, error) {
+			msg.Deck.RemoveCard(msg.Card.ID)
+			return nil, a.storage.SaveDeck(msg.Deck)
+		})
 	}
 
 	// Route update to current screen
@@ -239,7 +872,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case DeckListScreen:
 		if a.deckList == nil {
-			a.deckList = NewDeckListModel(a.decks)
+			a.deckList = NewDeckListModel(a.decks, a.sessionOptions(models.ReviewMode))
 			a.deckList.SetSize(a.width, a.height)
 		}
 		newModel, newCmd := a.deckList.Update(msg)
@@ -268,6 +901,41 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.cardEditor = newModel.(*CardEditorModel)
 			cmd = newCmd
 		}
+
+	case BackupScreen:
+		if a.backups != nil {
+			newModel, newCmd := a.backups.Update(msg)
+			a.backups = newModel.(*BackupsModel)
+			cmd = newCmd
+		}
+
+	case StatsScreen:
+		if a.stats != nil {
+			newModel, newCmd := a.stats.Update(msg)
+			a.stats = newModel.(*StatsModel)
+			cmd = newCmd
+		}
+
+	case BrowserScreen:
+		if a.browser != nil {
+			newModel, newCmd := a.browser.Update(msg)
+			a.browser = newModel.(*BrowserModel)
+			cmd = newCmd
+		}
+
+	case NoteTypesScreen:
+		if a.noteTypes != nil {
+			newModel, newCmd := a.noteTypes.Update(msg)
+			a.noteTypes = newModel.(*NoteTypesModel)
+			cmd = newCmd
+		}
+
+	case CustomStudyScreen:
+		if a.customStudy != nil {
+			newModel, newCmd := a.customStudy.Update(msg)
+			a.customStudy = newModel.(*CustomStudyModel)
+			cmd = newCmd
+		}
 	}
 
 	return a, cmd
@@ -314,11 +982,149 @@ func (a *App) View() string {
 		if a.cardEditor != nil {
 			content = a.cardEditor.View()
 		}
+
+	case BackupScreen:
+		if a.backups != nil {
+			content = a.backups.View()
+		}
+
+	case StatsScreen:
+		if a.stats != nil {
+			content = a.stats.View()
+		}
+
+	case BrowserScreen:
+		if a.browser != nil {
+			content = a.browser.View()
+		}
+
+	case NoteTypesScreen:
+		if a.noteTypes != nil {
+			content = a.noteTypes.View()
+		}
+
+	case CustomStudyScreen:
+		if a.customStudy != nil {
+			content = a.customStudy.View()
+		}
 	default:
 		content = "Screen not implemented yet"
 	}
 
-	return content
+	return a.withToast(content)
+}
+
+// withToast puts the current toast over the bottom line of the screen, which the centered
+// screens leave empty
+func (a *App) withToast(content string) string {
+	if a.toast == "" {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	toast := lipgloss.NewStyle().
+		Foreground(backgroundColor).
+		Background(accentColor).
+		Bold(true).
+		Padding(0, 1).
+		Render(a.toast)
+	lines[len(lines)-1] = lipgloss.PlaceHorizontal(a.width, lipgloss.Center, toast)
+	return strings.Join(lines, "\n")
+}
+
+// capturingText reports whether the current screen is editing text, so "q" must not navigate away
+func (a *App) capturingText() bool {
+	switch a.currentScreen {
+	case DeckListScreen:
+		return a.deckList != nil && a.deckList.CapturingText()
+	case DeckManagerScreen:
+		return a.deckManager != nil && a.deckManager.CapturingText()
+	case CardEditorScreen:
+		return a.cardEditor != nil && a.cardEditor.CapturingText()
+	case StudyScreen:
+		return a.study != nil && a.study.CapturingText()
+	case BrowserScreen:
+		return a.browser != nil && a.browser.CapturingText()
+	case NoteTypesScreen:
+		return a.noteTypes != nil && a.noteTypes.CapturingText()
+	case CustomStudyScreen:
+		return a.customStudy != nil && a.customStudy.CapturingText()
+	}
+	return false
+}
+
+// forgetSavedReviews drops the ratings that have been saved. A new session can't undo the
+// ratings of the last one, so only ratings whose undo is still waiting need to be kept
+func (a *App) forgetSavedReviews() {
+	for logID, review := range a.reviews {
+		select {
+		case <-review.done:
+			delete(a.reviews, logID)
+		default:
+		}
+	}
+}
+
+// applyBrowserAction applies a browser action to the cards it lists and returns the decks
+// that changed
+func (a *App) applyBrowserAction(msg BrowserActionMsg) []*models.Deck {
+	// Group the cards by deck
+	var decks []*models.Deck
+	cardIDs := make(map[*models.Deck][]string)
+	for _, result := range msg.Cards {
+		if _, ok := cardIDs[result.Deck]; !ok {
+			decks = append(decks, result.Deck)
+		}
+		cardIDs[result.Deck] = append(cardIDs[result.Deck], result.Card.ID)
+	}
+
+	changed := decks
+	if msg.Action == BrowserMove {
+		// Save the target first so moved cards are never missing from both decks
+		changed = append([]*models.Deck{msg.To}, decks...)
+	}
+	for _, deck := range decks {
+		ids := cardIDs[deck]
+		switch msg.Action {
+		case BrowserMove:
+			if deck != msg.To {
+				deck.MoveCards(ids, msg.To)
+			}
+		case BrowserTag:
+			deck.TagCards(ids, msg.AddTags, msg.RemoveTags)
+		case BrowserSuspend:
+			deck.SetSuspended(ids, msg.Suspend)
+		case BrowserReset:
+			deck.ResetCards(ids)
+		case BrowserDelete:
+			for _, id := range ids {
+				deck.RemoveCard(id)
+			}
+		}
+	}
+	return changed
+}
+
+// sessionOptions returns the study session settings from the config
+func (a *App) sessionOptions(mode models.StudyMode) models.SessionOptions {
+	return models.SessionOptions{
+		MaxCards: a.config.StudySession.CardsPerSession,
+		Mode:     mode,
+		Limits: models.DailyLimits{
+			NewCards: a.config.StudySession.NewCardsPerDay,
+			Reviews:  a.config.StudySession.ReviewsPerDay,
+		},
+		RolloverHour: a.config.StudySession.DayRolloverHour,
+		LearnAhead:   time.Duration(a.config.StudySession.LearnAheadMins) * time.Minute,
+		Leech: models.LeechSettings{
+			Threshold: a.config.StudySession.LeechThreshold,
+			Action:    a.config.StudySession.LeechAction,
+		},
+	}
+}
+
+// schedulerFor returns the spaced repetition algorithm configured for a deck
+func (a *App) schedulerFor(deckID string) algorithms.Scheduler {
+	return algorithms.SchedulerForDeck(a.config, deckID)
 }
 
 // handleNavigation handles navigation messages between screens
@@ -327,7 +1133,7 @@ func (a *App) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 	case DeckListScreen:
 		a.currentScreen = DeckListScreen
 		if a.deckList == nil {
-			a.deckList = NewDeckListModel(a.decks)
+			a.deckList = NewDeckListModel(a.decks, a.sessionOptions(models.ReviewMode))
 			a.deckList.SetSize(a.width, a.height)
 		}
 
@@ -336,14 +1142,32 @@ func (a *App) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 
 	case StudyScreen:
 		a.currentScreen = StudyScreen
+		a.forgetSavedReviews()
 		if req, ok := msg.Data.(*StudyRequest); ok {
-			a.currentDeck = req.Deck
-			a.study = NewStudyModel(req.Deck, a.config.StudySession.CardsPerSession, req.Mode)
+			if req.All {
+				// Every deck's cards, interleaved in the configured order
+				req.Decks = nil
+				for _, deck := range a.decks {
+					if !deck.IsFiltered() {
+						req.Decks = append(req.Decks, deck)
+					}
+				}
+			}
+			if len(req.Decks) == 1 {
+				a.currentDeck = req.Decks[0]
+			}
+			opts := a.sessionOptions(req.Mode)
+			opts.Tags = req.Tags
+			opts.Filtered = req.Filtered
+			if req.All {
+				opts.Order = models.ReviewOrder(a.config.StudySession.ReviewOrder)
+			}
+			a.study = NewStudyModel(req.Decks, req.Name, opts, a.customTypes, a.schedulerFor)
 			a.study.SetSize(a.width, a.height)
 		} else if deck, ok := msg.Data.(*models.Deck); ok {
 			// Backward compatibility - default to ReviewMode
 			a.currentDeck = deck
-			a.study = NewStudyModel(deck, a.config.StudySession.CardsPerSession, models.ReviewMode)
+			a.study = NewStudyModel([]*models.Deck{deck}, deck.Name, a.sessionOptions(models.ReviewMode), a.customTypes, a.schedulerFor)
 			a.study.SetSize(a.width, a.height)
 		}
 
@@ -359,9 +1183,59 @@ func (a *App) handleNavigation(msg NavigateMsg) (tea.Model, tea.Cmd) {
 	case CardEditorScreen:
 		a.currentScreen = CardEditorScreen
 		if deck, ok := msg.Data.(*models.Deck); ok {
-			a.cardEditor = NewCardEditorModel(deck)
+			a.cardEditor = NewCardEditorModel(deck, a.decks, a.customTypes)
+			a.cardEditor.SetSize(a.width, a.height)
+		} else if req, ok := msg.Data.(*CardEditRequest); ok {
+			a.cardEditor = NewCardEditorModel(req.Deck, a.decks, a.customTypes)
+			a.cardEditor.SelectCard(req.CardID)
 			a.cardEditor.SetSize(a.width, a.height)
 		}
+
+	case BackupScreen:
+		a.currentScreen = BackupScreen
+		a.backups = NewBackupsModel()
+		a.backups.SetSize(a.width, a.height)
+		return a, listBackups
+
+	case BrowserScreen:
+		a.currentScreen = BrowserScreen
+		a.browser = NewBrowserModel(a.decks, a.config.StudySession.DayRolloverHour)
+		a.browser.SetSize(a.width, a.height)
+		// Load the review history for rated: searches
+		return a, func() tea.Msg {
+			logs, err := a.storage.GetReviewLogsInRange(time.Time{}, time.Now().Add(24*time.Hour))
+			if err != nil {
+				return ErrorMsg{err}
+			}
+			return BrowserLogsLoadedMsg{logs}
+		}
+
+	case CustomStudyScreen:
+		a.currentScreen = CustomStudyScreen
+		deckName := ""
+		if node, ok := msg.Data.(*models.DeckNode); ok && node != nil {
+			deckName = node.Name
+		}
+		a.customStudy = NewCustomStudyModel(a.decks, deckName)
+		a.customStudy.SetSize(a.width, a.height)
+
+	case NoteTypesScreen:
+		a.currentScreen = NoteTypesScreen
+		a.noteTypes = NewNoteTypesModel(a.customTypes, a.decks)
+		a.noteTypes.SetSize(a.width, a.height)
+
+	case StatsScreen:
+		a.currentScreen = StatsScreen
+		a.stats = NewStatsModel(a.decks, a.config.StudySession.LeechThreshold, a.config.StudySession.DayRolloverHour)
+		a.stats.SetSize(a.width, a.height)
+		// Load the full review history for the statistics
+		return a, func() tea.Msg {
+			logs, err := a.storage.GetReviewLogsInRange(time.Time{}, time.Now().Add(24*time.Hour))
+			if err != nil {
+				return ErrorMsg{err}
+			}
+			return StatsLoadedMsg{logs}
+		}
 	}
 
 	return a, nil
@@ -372,6 +1246,10 @@ type DecksLoadedMsg struct {
 	Decks []*models.Deck
 }
 
+type NoteTypesLoadedMsg struct {
+	NoteTypes []*models.CustomNoteType
+}
+
 type ErrorMsg struct {
 	Error error
 }
@@ -381,8 +1259,19 @@ type NavigateMsg struct {
 	Data   interface{}
 }
 
-// StudyRequest contains deck and study mode for starting study sessions
+// CardEditRequest opens the card editor on a deck with one of its cards selected
+type CardEditRequest struct {
+	Deck   *models.Deck
+	CardID string
+}
+
+// StudyRequest contains the decks and study mode for starting study sessions
 type StudyRequest struct {
-	Deck *models.Deck
-	Mode models.StudyMode
+	Name  string         // Deck, or parent deck, being studied
+	Decks []*models.Deck // The deck and its descendants
+	Mode  models.StudyMode
+	Tags  *models.TagFilter // Only cards matching this expression, or all when nil
+
+	Filtered *models.Deck // Filtered deck being studied in FilteredMode
+	All      bool         // Study every deck at once instead of Decks
 }
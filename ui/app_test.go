@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"anktui/config"
+	"anktui/models"
+	"anktui/storage"
+	"slices"
+	"sync"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reviewStore records the review log writes it is asked for
+type reviewStore struct {
+	storage.Storage
+	mu     sync.Mutex
+	writes []string
+}
+
+func (s *reviewStore) RecordReview(deck *models.Deck, log *models.ReviewLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, "record "+log.ID)
+	return nil
+}
+
+func (s *reviewStore) UndoReview(deck *models.Deck, logID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, "undo "+logID)
+	return nil
+}
+
+func TestRatingUndoHandshake(t *testing.T) {
+	tests := []struct {
+		name      string
+		undoFirst bool // Whether the undo reaches the app before the rating it takes back
+		want      []string
+	}{
+		{"rating then undo", false, []string{"record log", "undo log"}},
+		{"undo before its rating", true, []string{"undo log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &reviewStore{}
+			app := NewApp(config.DefaultConfig(), store)
+			deck := models.NewDeck("Spanish", "")
+			review := ReviewCardMsg{Deck: deck, Log: &models.ReviewLog{ID: "log"}}
+			undo := UndoReviewMsg{Deck: deck, LogID: "log"}
+
+			// Each write runs on its own goroutine, as tea runs commands
+			var wg sync.WaitGroup
+			run := func(msg tea.Msg) {
+				_, cmd := app.Update(msg)
+				if cmd == nil {
+					return
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					cmd()
+				}()
+			}
+			if tt.undoFirst {
+				run(undo)
+				run(review)
+			} else {
+				run(review)
+				run(undo)
+			}
+			wg.Wait()
+			app.writes.Wait()
+
+			if !slices.Equal(store.writes, tt.want) {
+				t.Errorf("storage writes = %v, want %v", store.writes, tt.want)
+			}
+			if len(app.reviews) != 0 {
+				t.Errorf("%d reviews are still pending", len(app.reviews))
+			}
+		})
+	}
+}
+
+func TestBackupAfterStudy(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		rated      bool
+		leave      tea.Msg
+		wantBackup bool
+	}{
+		{"leaving with esc after a rating", true, true, NavigateMsg{Screen: DeckListScreen}, true},
+		{"leaving with q after a rating", true, true, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}, true},
+		{"leaving without a rating", true, false, NavigateMsg{Screen: DeckListScreen}, false},
+		{"backups disabled", false, true, NavigateMsg{Screen: DeckListScreen}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.DataDirectory = t.TempDir()
+			cfg.BackupDirectory = t.TempDir()
+			cfg.BackupEnabled = tt.enabled
+			app := NewApp(cfg, &reviewStore{})
+			app.currentScreen = StudyScreen
+
+			if tt.rated {
+				_, cmd := app.Update(ReviewCardMsg{Deck: models.NewDeck("Spanish", ""), Log: &models.ReviewLog{ID: "log"}})
+				cmd()
+			}
+			_, cmd := app.Update(tt.leave)
+
+			var created bool
+			var collect func(cmd tea.Cmd)
+			collect = func(cmd tea.Cmd) {
+				if cmd == nil {
+					return
+				}
+				switch msg := cmd().(type) {
+				case tea.BatchMsg:
+					for _, cmd := range msg {
+						collect(cmd)
+					}
+				case BackupCreatedMsg:
+					if msg.Err != nil {
+						t.Errorf("backup failed: %v", msg.Err)
+					}
+					created = true
+				}
+			}
+			collect(cmd)
+			if created != tt.wantBackup {
+				t.Errorf("backup created = %v, want %v", created, tt.wantBackup)
+			}
+		})
+	}
+}
+
+func TestForgetReviews(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []string // "rate" sends the rating, "save" runs its write, "forget" drops it from the undo stack
+		wantPending bool
+	}{
+		{"saved rating still undoable", []string{"rate", "save"}, true},
+		{"forgotten after it was saved", []string{"rate", "save", "forget"}, false},
+		{"forgotten before it was saved", []string{"rate", "forget", "save"}, false},
+		{"forgotten before its rating arrived", []string{"forget", "rate", "save"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp(config.DefaultConfig(), &reviewStore{})
+			deck := models.NewDeck("Spanish", "")
+			var write tea.Cmd
+			for _, event := range tt.events {
+				switch event {
+				case "rate":
+					_, write = app.Update(ReviewCardMsg{Deck: deck, Log: &models.ReviewLog{ID: "log"}})
+				case "save":
+					app.Update(write())
+				case "forget":
+					app.Update(ForgetReviewsMsg{LogIDs: []string{"log"}})
+				}
+			}
+			if _, pending := app.reviews["log"]; pending != tt.wantPending {
+				t.Errorf("rating kept = %v, want %v", pending, tt.wantPending)
+			}
+		})
+	}
+}
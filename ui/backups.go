@@ -0,0 +1,356 @@
+package ui
+
+import (
+	"anktui/backup"
+	"anktui/models"
+	"anktui/storage"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// backupListSize is the number of snapshots shown at once
+const backupListSize = 12
+
+// BackupsModel represents the backup and restore screen
+type BackupsModel struct {
+	snapshots  []backup.Snapshot
+	summaries  map[string]*backup.Summary // Keyed by snapshot path
+	inspectErr map[string]error
+	selected   int
+	loaded     bool
+
+	confirming bool   // Waiting for confirmation to restore the selected snapshot
+	busy       string // Description of a running backup or restore
+	status     string
+	err        error
+
+	width  int
+	height int
+}
+
+// NewBackupsModel creates a new backups model
+func NewBackupsModel() *BackupsModel {
+	return &BackupsModel{
+		summaries:  make(map[string]*backup.Summary),
+		inspectErr: make(map[string]error),
+	}
+}
+
+// SetSize sets the terminal size
+func (m *BackupsModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// Init implements tea.Model
+func (m *BackupsModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *BackupsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case BackupsLoadedMsg:
+		m.loaded = true
+		m.snapshots = msg.Snapshots
+		if msg.Err != nil {
+			m.err = msg.Err
+		}
+		if m.selected >= len(m.snapshots) {
+			m.selected = max(len(m.snapshots)-1, 0)
+		}
+		return m, m.inspectSelected()
+
+	case BackupInspectedMsg:
+		if msg.Err != nil {
+			m.inspectErr[msg.Path] = msg.Err
+		} else {
+			m.summaries[msg.Path] = msg.Summary
+		}
+
+	case BackupCreatedMsg:
+		m.busy = ""
+		m.err = msg.Err
+		if msg.Err == nil {
+			m.status = "Created " + msg.Snapshot.Name
+			m.selected = 0
+		}
+		return m, listBackups
+
+	case BackupRestoredMsg:
+		m.busy = ""
+		m.err = msg.Err
+		if msg.Err == nil {
+			m.status = fmt.Sprintf("Restored %s (previous data saved as %s)", msg.Restored.Name, msg.Safety.Name)
+			m.selected = 0
+		}
+		return m, listBackups
+
+	case tea.KeyMsg:
+		if m.busy != "" {
+			return m, nil
+		}
+		if m.confirming {
+			return m.updateConfirm(msg)
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+				return m, m.inspectSelected()
+			}
+		case "down", "j":
+			if m.selected < len(m.snapshots)-1 {
+				m.selected++
+				return m, m.inspectSelected()
+			}
+		case "c":
+			// Snapshot the data directory now
+			m.busy = "Creating backup..."
+			m.status = ""
+			m.err = nil
+			return m, func() tea.Msg {
+				return CreateBackupMsg{}
+			}
+		case "enter", "r":
+			if len(m.snapshots) > 0 {
+				m.confirming = true
+				m.status = ""
+				m.err = nil
+			}
+		case "esc":
+			return m, func() tea.Msg {
+				return NavigateMsg{Screen: MenuScreen}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// updateConfirm handles the restore confirmation
+func (m *BackupsModel) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.confirming = false
+		m.busy = "Restoring..."
+		snapshot := m.snapshots[m.selected]
+		return m, func() tea.Msg {
+			return RestoreBackupMsg{Snapshot: snapshot}
+		}
+	case "n", "N", "esc":
+		m.confirming = false
+	}
+	return m, nil
+}
+
+// inspectSelected requests the contents of the selected snapshot unless already known
+func (m *BackupsModel) inspectSelected() tea.Cmd {
+	if len(m.snapshots) == 0 {
+		return nil
+	}
+	path := m.snapshots[m.selected].Path
+	if _, ok := m.summaries[path]; ok {
+		return nil
+	}
+	if _, ok := m.inspectErr[path]; ok {
+		return nil
+	}
+	return func() tea.Msg {
+		return InspectBackupMsg{Path: path}
+	}
+}
+
+// View implements tea.Model
+func (m *BackupsModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return "Loading..."
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render("Backups")
+
+	var body string
+	switch {
+	case !m.loaded:
+		body = mutedTextStyle.Render("Loading backups...")
+	case len(m.snapshots) == 0:
+		body = lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Italic(true).
+			Render("No backups yet. Press 'c' to create one.")
+	default:
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.viewList(), m.viewPreview())
+	}
+
+	// Status line
+	var status string
+	switch {
+	case m.busy != "":
+		status = emphasisStyle.Render(m.busy)
+	case m.err != nil:
+		status = errorStyle.Render(m.err.Error())
+	case m.confirming:
+		status = emphasisStyle.Render(fmt.Sprintf(
+			"Replace all current data with %s? The current data is backed up first. (y/n)",
+			m.snapshots[m.selected].Name))
+	case m.status != "":
+		status = successStyle.Render(m.status)
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(2).
+		Render("↑/↓: select • Enter/r: restore • c: create backup • Esc: back")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		body,
+		lipgloss.NewStyle().PaddingTop(1).Render(status),
+		help,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewList renders the snapshots around the selection
+func (m *BackupsModel) viewList() string {
+	start := 0
+	if m.selected >= backupListSize {
+		start = m.selected - backupListSize + 1
+	}
+	end := min(start+backupListSize, len(m.snapshots))
+
+	var lines []string
+	for i := start; i < end; i++ {
+		snapshot := m.snapshots[i]
+		line := fmt.Sprintf("%s  %8s", snapshot.Created.Format("2006-01-02 15:04:05"), formatSize(snapshot.Size))
+		if i == m.selected {
+			line = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render("▶ " + line)
+		} else {
+			line = textStyle.Render("  " + line)
+		}
+		lines = append(lines, line)
+	}
+	if len(m.snapshots) > backupListSize {
+		lines = append(lines, "", mutedTextStyle.Render(fmt.Sprintf("%d of %d", m.selected+1, len(m.snapshots))))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(1, 2).
+		Margin(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// viewPreview renders the deck and card counts of the selected snapshot
+func (m *BackupsModel) viewPreview() string {
+	path := m.snapshots[m.selected].Path
+
+	var lines []string
+	if err, ok := m.inspectErr[path]; ok {
+		lines = append(lines, errorStyle.Render(err.Error()))
+	} else if summary, ok := m.summaries[path]; ok {
+		lines = append(lines,
+			emphasisStyle.Render(fmt.Sprintf("%d decks • %d cards • %d reviews",
+				len(summary.Decks), summary.TotalCards(), summary.Reviews)),
+			"")
+		for i, deck := range summary.Decks {
+			if i == backupListSize {
+				lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("...and %d more", len(summary.Decks)-i)))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("%s %s",
+				textStyle.Render(truncateText(deck.Name, 28)),
+				mutedTextStyle.Render(fmt.Sprintf("(%d cards)", deck.Cards))))
+		}
+	} else {
+		lines = append(lines, mutedTextStyle.Render("Reading backup..."))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(1, 2).
+		Margin(0, 1).
+		Width(46).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// formatSize renders a byte count in KB or MB
+func formatSize(bytes int64) string {
+	if bytes < 1024*1024 {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
+
+// truncateText shortens text to width runes with an ellipsis
+func truncateText(text string, width int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= width {
+		return string(runes)
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// listBackups requests the list of snapshots
+func listBackups() tea.Msg {
+	return ListBackupsMsg{}
+}
+
+// ListBackupsMsg requests the list of snapshots
+type ListBackupsMsg struct{}
+
+// BackupsLoadedMsg carries the snapshots, newest first
+type BackupsLoadedMsg struct {
+	Snapshots []backup.Snapshot
+	Err       error
+}
+
+// InspectBackupMsg requests the contents of the snapshot at Path
+type InspectBackupMsg struct {
+	Path string
+}
+
+// BackupInspectedMsg carries the contents of a snapshot
+type BackupInspectedMsg struct {
+	Path    string
+	Summary *backup.Summary
+	Err     error
+}
+
+// CreateBackupMsg requests a snapshot of the data directory
+type CreateBackupMsg struct{}
+
+// BackupCreatedMsg reports a new snapshot
+type BackupCreatedMsg struct {
+	Snapshot *backup.Snapshot
+	Err      error
+}
+
+// RestoreBackupMsg requests that the data directory be replaced with Snapshot
+type RestoreBackupMsg struct {
+	Snapshot backup.Snapshot
+}
+
+// BackupRestoredMsg reports a restore along with the restored decks
+type BackupRestoredMsg struct {
+	Restored backup.Snapshot
+	Safety   *backup.Snapshot // Snapshot of the data that was replaced
+	Decks    []*models.Deck
+	Store    storage.Storage // Storage opened again after the restore, nil when it couldn't be
+	Err      error
+}
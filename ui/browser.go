@@ -0,0 +1,695 @@
+package ui
+
+import (
+	"anktui/algorithms"
+	"anktui/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BrowserState represents the current state of the card browser
+type BrowserState int
+
+const (
+	BrowsingCards BrowserState = iota
+	EditingQuery
+	BrowserTagPrompt
+	BrowserMoveSelect
+	BrowserConfirm
+)
+
+// BrowserAction is a change applied to the selected cards of the browser
+type BrowserAction int
+
+const (
+	BrowserMove BrowserAction = iota
+	BrowserTag
+	BrowserSuspend
+	BrowserReset
+	BrowserDelete
+)
+
+// browserColumn is a column of the card table
+type browserColumn struct {
+	title string
+	width int
+	value func(m *BrowserModel, result models.SearchResult) string
+	less  func(m *BrowserModel, a, b models.SearchResult) bool // nil when the column cannot be sorted
+}
+
+// browserColumns lists the table columns, the sortable ones in the order o cycles through
+var browserColumns = []browserColumn{
+	{"Question", 30,
+		func(m *BrowserModel, r models.SearchResult) string { return r.Card.Question() },
+		nil},
+	{"Deck", 16,
+		func(m *BrowserModel, r models.SearchResult) string { return r.Deck.Name },
+		nil},
+	{"Due", 11,
+		func(m *BrowserModel, r models.SearchResult) string {
+			if r.Card.Suspended {
+				return "suspended"
+			}
+			if r.Card.IsBuried(m.ctx.Now) {
+				return "buried"
+			}
+			if r.Card.IsBorrowed() {
+				return "filtered"
+			}
+			if r.Card.IsNew() {
+				return "new"
+			}
+			return r.Card.NextReview.Format("2006-01-02")
+		},
+		func(m *BrowserModel, a, b models.SearchResult) bool {
+			return a.Card.NextReview.Before(b.Card.NextReview)
+		}},
+	{"Interval", 9,
+		func(m *BrowserModel, r models.SearchResult) string {
+			if r.Card.IsNew() {
+				return "-"
+			}
+			return algorithms.FormatInterval(time.Duration(r.Card.Interval) * 24 * time.Hour)
+		},
+		func(m *BrowserModel, a, b models.SearchResult) bool { return a.Card.Interval < b.Card.Interval }},
+	{"Ease", 6,
+		func(m *BrowserModel, r models.SearchResult) string {
+			return fmt.Sprintf("%.0f%%", r.Card.EaseFactor*100)
+		},
+		func(m *BrowserModel, a, b models.SearchResult) bool { return a.Card.EaseFactor < b.Card.EaseFactor }},
+	{"Reps", 5,
+		func(m *BrowserModel, r models.SearchResult) string { return fmt.Sprint(r.Card.Repetition) },
+		func(m *BrowserModel, a, b models.SearchResult) bool { return a.Card.Repetition < b.Card.Repetition }},
+	{"Lapses", 7,
+		func(m *BrowserModel, r models.SearchResult) string { return fmt.Sprint(r.Card.Lapses) },
+		func(m *BrowserModel, a, b models.SearchResult) bool { return a.Card.Lapses < b.Card.Lapses }},
+	{"Modified", 11,
+		func(m *BrowserModel, r models.SearchResult) string { return r.Card.Modified.Format("2006-01-02") },
+		func(m *BrowserModel, a, b models.SearchResult) bool { return a.Card.Modified.Before(b.Card.Modified) }},
+}
+
+// BrowserModel represents the card browser, which searches the cards of every deck
+type BrowserModel struct {
+	decks        []*models.Deck
+	ctx          *models.SearchContext
+	rolloverHour int // Hour study days start at, for searches counting days
+	query        *models.Query
+	queryInput   textinput.Model
+	queryError   string
+	results      []models.SearchResult
+	selected     int
+	marked       map[string]bool // IDs of the cards selected with space
+	sortColumn   int             // Index into browserColumns, -1 for deck order
+	sortDesc     bool
+	state        BrowserState
+
+	// Actions
+	tagInput     textinput.Model
+	removingTags bool
+	moveTarget   int
+	confirming   BrowserAction // Reset or delete waiting for confirmation
+
+	width  int
+	height int
+}
+
+// NewBrowserModel creates a new card browser over all decks. Searches counting days count
+// study days starting at rolloverHour
+func NewBrowserModel(decks []*models.Deck, rolloverHour int) *BrowserModel {
+	queryInput := textinput.New()
+	queryInput.Placeholder = `deck:Leet* tag:dp is:due prop:ivl>30 front:"two pointer" added:7 rated:1:1`
+	queryInput.Width = 70
+
+	tagInput := textinput.New()
+	tagInput.Placeholder = "Tags..."
+	tagInput.Width = 40
+
+	query, _ := models.ParseQuery("")
+	m := &BrowserModel{
+		decks:        decks,
+		ctx:          models.NewSearchContext(nil, rolloverHour),
+		rolloverHour: rolloverHour,
+		query:        query,
+		queryInput:   queryInput,
+		marked:       make(map[string]bool),
+		sortColumn:   -1,
+		state:        BrowsingCards,
+		tagInput:     tagInput,
+	}
+	m.search()
+	return m
+}
+
+// SetSize sets the terminal size
+func (m *BrowserModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// CapturingText reports whether keystrokes are going into a text field
+func (m *BrowserModel) CapturingText() bool {
+	return m.state == EditingQuery || m.state == BrowserTagPrompt
+}
+
+// Init implements tea.Model
+func (m *BrowserModel) Init() tea.Cmd {
+	return nil
+}
+
+// search runs the query and sorts the results, dropping marks on cards no longer listed
+func (m *BrowserModel) search() {
+	m.results = m.query.Search(m.decks, m.ctx)
+	if m.sortColumn >= 0 {
+		column := browserColumns[m.sortColumn]
+		sort.SliceStable(m.results, func(i, j int) bool {
+			if m.sortDesc {
+				return column.less(m, m.results[j], m.results[i])
+			}
+			return column.less(m, m.results[i], m.results[j])
+		})
+	}
+
+	listed := make(map[string]bool)
+	for _, result := range m.results {
+		listed[result.Card.ID] = true
+	}
+	for id := range m.marked {
+		if !listed[id] {
+			delete(m.marked, id)
+		}
+	}
+	if m.selected >= len(m.results) {
+		m.selected = max(len(m.results)-1, 0)
+	}
+}
+
+// targets returns the marked cards, or the selected card when none are marked
+func (m *BrowserModel) targets() []models.SearchResult {
+	var targets []models.SearchResult
+	for _, result := range m.results {
+		if m.marked[result.Card.ID] {
+			targets = append(targets, result)
+		}
+	}
+	if len(targets) == 0 && len(m.results) > 0 {
+		targets = append(targets, m.results[m.selected])
+	}
+	return targets
+}
+
+// action returns a command applying an action to the target cards
+func (m *BrowserModel) action(actionMsg BrowserActionMsg) tea.Cmd {
+	actionMsg.Cards = m.targets()
+	return func() tea.Msg {
+		return actionMsg
+	}
+}
+
+// Update implements tea.Model
+func (m *BrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case BrowserLogsLoadedMsg:
+		m.ctx = models.NewSearchContext(msg.Logs, m.rolloverHour)
+		m.search()
+
+	case DecksLoadedMsg:
+		// Search the fresh data again after an action
+		m.decks = msg.Decks
+		if m.state != EditingQuery {
+			m.state = BrowsingCards
+		}
+		m.marked = make(map[string]bool)
+		m.search()
+
+	case tea.KeyMsg:
+		switch m.state {
+		case BrowsingCards:
+			return m.updateBrowsing(msg)
+		case EditingQuery:
+			return m.updateQuery(msg)
+		case BrowserTagPrompt:
+			return m.updateTagPrompt(msg)
+		case BrowserMoveSelect:
+			return m.updateMove(msg)
+		case BrowserConfirm:
+			switch msg.String() {
+			case "y", "Y":
+				return m, m.action(BrowserActionMsg{Action: m.confirming})
+			case "n", "N", "esc":
+				m.state = BrowsingCards
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// updateBrowsing handles navigating the table and starting actions
+func (m *BrowserModel) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j":
+		if m.selected < len(m.results)-1 {
+			m.selected++
+		}
+	case "pgup":
+		m.selected = max(m.selected-m.visibleRows(), 0)
+	case "pgdown":
+		m.selected = max(min(m.selected+m.visibleRows(), len(m.results)-1), 0)
+	case "/":
+		m.state = EditingQuery
+		m.queryInput.Focus()
+	case " ":
+		if len(m.results) > 0 {
+			id := m.results[m.selected].Card.ID
+			if m.marked[id] {
+				delete(m.marked, id)
+			} else {
+				m.marked[id] = true
+			}
+			if m.selected < len(m.results)-1 {
+				m.selected++
+			}
+		}
+	case "a":
+		// Mark every listed card, or clear the marks when all are marked
+		if len(m.marked) == len(m.results) {
+			m.marked = make(map[string]bool)
+		} else {
+			for _, result := range m.results {
+				m.marked[result.Card.ID] = true
+			}
+		}
+	case "o":
+		// Sort by the next sortable column, going back to deck order after the last
+		m.sortColumn++
+		for m.sortColumn < len(browserColumns) && browserColumns[m.sortColumn].less == nil {
+			m.sortColumn++
+		}
+		if m.sortColumn >= len(browserColumns) {
+			m.sortColumn = -1
+		}
+		m.search()
+	case "O":
+		m.sortDesc = !m.sortDesc
+		m.search()
+	}
+
+	if len(m.results) == 0 {
+		if msg.String() == "esc" {
+			return m, func() tea.Msg {
+				return NavigateMsg{Screen: MenuScreen}
+			}
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "t", "T":
+		m.state = BrowserTagPrompt
+		m.removingTags = msg.String() == "T"
+		m.tagInput.SetValue("")
+		m.tagInput.Focus()
+	case "m":
+		if len(m.decks) > 1 {
+			m.state = BrowserMoveSelect
+			m.moveTarget = 0
+		}
+	case "s":
+		// Suspend the cards, or unsuspend them when all are already suspended
+		suspend := false
+		for _, target := range m.targets() {
+			if !target.Card.Suspended {
+				suspend = true
+			}
+		}
+		return m, m.action(BrowserActionMsg{Action: BrowserSuspend, Suspend: suspend})
+	case "r":
+		m.state = BrowserConfirm
+		m.confirming = BrowserReset
+	case "d":
+		m.state = BrowserConfirm
+		m.confirming = BrowserDelete
+	case "esc":
+		if len(m.marked) > 0 {
+			m.marked = make(map[string]bool)
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			return NavigateMsg{Screen: MenuScreen}
+		}
+	}
+	return m, nil
+}
+
+// updateQuery handles typing the search
+func (m *BrowserModel) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		query, err := models.ParseQuery(m.queryInput.Value())
+		if err != nil {
+			m.queryError = err.Error()
+			return m, nil
+		}
+		m.query = query
+		m.queryError = ""
+		m.selected = 0
+		m.state = BrowsingCards
+		m.queryInput.Blur()
+		m.search()
+		return m, nil
+	case "esc":
+		m.queryInput.SetValue(m.query.String())
+		m.queryError = ""
+		m.state = BrowsingCards
+		m.queryInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.queryInput, cmd = m.queryInput.Update(msg)
+	return m, cmd
+}
+
+// updateTagPrompt handles entering tags to add to or remove from the cards
+func (m *BrowserModel) updateTagPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		tags := models.ParseTags(m.tagInput.Value())
+		if len(tags) == 0 {
+			m.state = BrowsingCards
+			return m, nil
+		}
+		tagMsg := BrowserActionMsg{Action: BrowserTag}
+		if m.removingTags {
+			tagMsg.RemoveTags = tags
+		} else {
+			tagMsg.AddTags = tags
+		}
+		return m, m.action(tagMsg)
+	case "esc":
+		m.state = BrowsingCards
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.tagInput, cmd = m.tagInput.Update(msg)
+	return m, cmd
+}
+
+// moveTargets returns the decks cards can be moved to, sorted by name. Filtered decks only
+// borrow cards, so they are left out
+func (m *BrowserModel) moveTargets() []*models.Deck {
+	var targets []*models.Deck
+	for _, deck := range m.decks {
+		if !deck.IsFiltered() {
+			targets = append(targets, deck)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Name < targets[j].Name
+	})
+	return targets
+}
+
+// updateMove handles choosing the deck to move the cards to
+func (m *BrowserModel) updateMove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	targets := m.moveTargets()
+	switch msg.String() {
+	case "up", "k":
+		if m.moveTarget > 0 {
+			m.moveTarget--
+		}
+	case "down", "j":
+		if m.moveTarget < len(targets)-1 {
+			m.moveTarget++
+		}
+	case "enter":
+		if m.moveTarget < len(targets) {
+			return m, m.action(BrowserActionMsg{Action: BrowserMove, To: targets[m.moveTarget]})
+		}
+	case "esc":
+		m.state = BrowsingCards
+	}
+	return m, nil
+}
+
+// visibleRows returns how many table rows fit on screen
+func (m *BrowserModel) visibleRows() int {
+	return max(m.height-14, 5)
+}
+
+// View implements tea.Model
+func (m *BrowserModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return "Loading..."
+	}
+
+	switch m.state {
+	case BrowserMoveSelect:
+		return m.viewMove()
+	case BrowserConfirm:
+		return m.viewConfirm()
+	default:
+		return m.viewTable()
+	}
+}
+
+// viewTable renders the search field and the table of matching cards
+func (m *BrowserModel) viewTable() string {
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Width(tableWidth()).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render("Browse Cards")
+
+	// Search field
+	queryBorder := mutedColor
+	if m.state == EditingQuery {
+		queryBorder = secondaryColor
+	}
+	queryText := m.queryInput.View()
+	if m.state != EditingQuery && m.query.String() == "" {
+		queryText = mutedTextStyle.Render("All cards - press / to search")
+	}
+	queryBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(queryBorder).
+		Padding(0, 1).
+		Width(tableWidth()).
+		Render("Search: " + queryText)
+
+	lines := []string{title, queryBox}
+	if m.queryError != "" {
+		lines = append(lines, errorStyle.Render(m.queryError))
+	}
+
+	summary := fmt.Sprintf("%d cards", len(m.results))
+	if len(m.marked) > 0 {
+		summary += fmt.Sprintf(" • %d marked", len(m.marked))
+	}
+	if m.sortColumn >= 0 {
+		order := "ascending"
+		if m.sortDesc {
+			order = "descending"
+		}
+		summary += fmt.Sprintf(" • sorted by %s, %s", strings.ToLower(browserColumns[m.sortColumn].title), order)
+	}
+	lines = append(lines, mutedTextStyle.Render(summary), "")
+
+	// Header
+	var header []string
+	for i, column := range browserColumns {
+		name := column.title
+		if i == m.sortColumn {
+			if m.sortDesc {
+				name += " ▼"
+			} else {
+				name += " ▲"
+			}
+		}
+		header = append(header, browserCell(name, column.width))
+	}
+	lines = append(lines, lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render("  "+strings.Join(header, " ")))
+
+	// Rows, scrolled to keep the selection in view
+	visible := m.visibleRows()
+	start := 0
+	if m.selected >= visible {
+		start = m.selected - visible + 1
+	}
+	end := min(start+visible, len(m.results))
+	for i := start; i < end; i++ {
+		result := m.results[i]
+		var cells []string
+		for _, column := range browserColumns {
+			cells = append(cells, browserCell(column.value(m, result), column.width))
+		}
+		marker := "  "
+		if m.marked[result.Card.ID] {
+			marker = "✓ "
+		}
+
+		style := lipgloss.NewStyle().Foreground(textColor)
+		switch {
+		case i == m.selected:
+			style = style.Foreground(backgroundColor).Background(secondaryColor)
+		case result.Card.Suspended:
+			style = style.Foreground(accentColor)
+		case result.Card.Flag != models.NoFlag:
+			style = style.Foreground(flagColor(result.Card.Flag))
+		}
+		lines = append(lines, style.Render(marker+strings.Join(cells, " ")))
+	}
+	if len(m.results) == 0 {
+		lines = append(lines, mutedTextStyle.Render("No cards match the search."))
+	}
+
+	// Help text
+	helpText := "/: search • ↑/↓: navigate • Space: mark • a: mark all • o/O: sort column/order • t/T: add/remove tags • m: move • s: suspend • r: reset • d: delete • Esc: back"
+	switch m.state {
+	case EditingQuery:
+		helpText = "deck: tag: is:new|due|learn|review|suspended|buried|filtered flag:0-4 prop:ivl|ease|reps|lapses|due>N front: back: added:N edited:N rated:N[:1-4] • AND OR NOT - ( ) • Enter: search • Esc: cancel"
+	case BrowserTagPrompt:
+		action := "add to"
+		if m.removingTags {
+			action = "remove from"
+		}
+		lines = append(lines, "", lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(secondaryColor).
+			Padding(0, 1).
+			Width(60).
+			Render(fmt.Sprintf("Tags to %s %d cards: %s", action, len(m.targets()), m.tagInput.View())))
+		helpText = "Enter: apply • Esc: cancel"
+	}
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Width(tableWidth()).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render(helpText)
+	lines = append(lines, help)
+
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewMove renders the list of decks to move the cards to
+func (m *BrowserModel) viewMove() string {
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render("Move Cards")
+
+	target := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render(fmt.Sprintf("%d cards and their siblings to:", len(m.targets())))
+
+	var items []string
+	for i, deck := range m.moveTargets() {
+		style := lipgloss.NewStyle().Foreground(textColor).PaddingLeft(2)
+		prefix := "  "
+		if i == m.moveTarget {
+			style = style.Foreground(secondaryColor).Bold(true)
+			prefix = "▶ "
+		}
+		items = append(items, style.Render(prefix+deck.Name))
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(2).
+		Render("↑/↓: choose deck • Enter: move • Esc: cancel")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		target,
+		lipgloss.JoinVertical(lipgloss.Left, items...),
+		help,
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewConfirm renders the confirmation for resetting or deleting cards
+func (m *BrowserModel) viewConfirm() string {
+	action := "Reset the progress of"
+	if m.confirming == BrowserDelete {
+		action = "Delete"
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render("⚠️  Confirm")
+
+	warning := lipgloss.NewStyle().
+		Foreground(textColor).
+		Align(lipgloss.Center).
+		PaddingBottom(3).
+		Render(fmt.Sprintf("%s %d cards?", action, len(m.targets())))
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		Render("Y: confirm • N/Esc: cancel")
+
+	content := lipgloss.JoinVertical(lipgloss.Center, title, warning, help)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// tableWidth returns the width of the card table
+func tableWidth() int {
+	width := 2
+	for _, column := range browserColumns {
+		width += column.width + 1
+	}
+	return width
+}
+
+// browserCell pads or truncates text to a column width
+func browserCell(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return text + strings.Repeat(" ", width-len(runes))
+}
+
+// BrowserLogsLoadedMsg carries the review history used for rated: searches
+type BrowserLogsLoadedMsg struct {
+	Logs []*models.ReviewLog
+}
+
+// BrowserActionMsg applies an action to cards found in the browser
+type BrowserActionMsg struct {
+	Action     BrowserAction
+	Cards      []models.SearchResult
+	To         *models.Deck // Deck to move the cards to
+	AddTags    []string
+	RemoveTags []string
+	Suspend    bool // Whether to suspend or unsuspend
+}
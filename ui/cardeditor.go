@@ -3,9 +3,12 @@ package ui
 import (
 	"anktui/models"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -17,20 +20,36 @@ const (
 	CardListView CardEditorState = iota
 	CardForm
 	CardDeleteConfirm
+	CardTagPrompt
+	CardMoveSelect
 )
 
 // CardEditorModel represents the card editor screen
 type CardEditorModel struct {
 	deck         *models.Deck
+	decks        []*models.Deck           // All decks, as targets for moving cards
+	noteTypes    []*models.CustomNoteType // Note types offered besides the built-in ones
 	state        CardEditorState
 	selectedCard int
 	editingCard  *models.Card
+	editingNote  *models.Note // Note of the edited card, nil for a new card
+	noteType     models.NoteType
 	isNewCard    bool
+	formError    string
 
-	// Form fields
-	frontTextarea textarea.Model
-	backTextarea  textarea.Model
-	currentField  int
+	// Form fields, one per field of the note type followed by the tags
+	fieldInputs  []textarea.Model
+	fieldLabels  []string
+	tagsInput    textinput.Model
+	currentField int
+
+	// Bulk tagging
+	marked       map[string]bool // IDs of the cards selected with space
+	tagPrompt    textinput.Model
+	removingTags bool
+
+	// Moving cards
+	moveTarget int
 
 	// Confirmation
 	confirmingDelete bool
@@ -40,26 +59,27 @@ type CardEditorModel struct {
 }
 
 // NewCardEditorModel creates a new card editor model
-func NewCardEditorModel(deck *models.Deck) *CardEditorModel {
-	// Initialize textarea models
-	frontTA := textarea.New()
-	frontTA.Placeholder = "Enter the front of the card (question)..."
-	frontTA.SetWidth(60)
-	frontTA.SetHeight(3)
-	frontTA.Focus()
-
-	backTA := textarea.New()
-	backTA.Placeholder = "Enter the back of the card (answer) - supports markdown..."
-	backTA.SetWidth(60)
-	backTA.SetHeight(3)
-
-	return &CardEditorModel{
-		deck:          deck,
-		state:         CardListView,
-		selectedCard:  0,
-		frontTextarea: frontTA,
-		backTextarea:  backTA,
+func NewCardEditorModel(deck *models.Deck, decks []*models.Deck, noteTypes []*models.CustomNoteType) *CardEditorModel {
+	tagsInput := textinput.New()
+	tagsInput.Placeholder = "Space separated tags, like graph dp::knapsack"
+	tagsInput.Width = 58
+
+	tagPrompt := textinput.New()
+	tagPrompt.Placeholder = "Tags..."
+	tagPrompt.Width = 40
+
+	m := &CardEditorModel{
+		deck:         deck,
+		decks:        decks,
+		noteTypes:    noteTypes,
+		state:        CardListView,
+		selectedCard: 0,
+		tagsInput:    tagsInput,
+		marked:       make(map[string]bool),
+		tagPrompt:    tagPrompt,
 	}
+	m.setNoteType(models.BasicNote, nil)
+	return m
 }
 
 // SetSize sets the terminal size
@@ -84,28 +104,42 @@ func (m *CardEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateForm(msg)
 		case CardDeleteConfirm:
 			return m.updateDelete(msg)
+		case CardTagPrompt:
+			return m.updateTagPrompt(msg)
+		case CardMoveSelect:
+			return m.updateMove(msg)
 		}
 	case DecksLoadedMsg:
 		// Update deck data with fresh information
-		for _, deck := range msg.Decks {
-			if deck.ID == m.deck.ID {
-				m.deck = deck
-				break
+		m.decks = msg.Decks
+		deck := deckWithID(msg.Decks, m.deck.ID)
+		if deck == nil {
+			// Undoing the deck's creation removed it
+			return m, func() tea.Msg {
+				return NavigateMsg{Screen: DeckManagerScreen}
 			}
 		}
+		m.deck = deck
 
 		// If we were creating or editing a card, return to list view
 		if m.state == CardForm {
 			m.state = CardListView
-			m.frontTextarea.SetValue("")
-			m.backTextarea.SetValue("")
-			m.frontTextarea.Focus()
-			m.backTextarea.Blur()
-			m.currentField = 0
+			m.setNoteType(m.noteType, nil)
+			m.tagsInput.SetValue("")
 			m.editingCard = nil
+			m.editingNote = nil
 			m.isNewCard = false
 		}
 
+		// Tags were applied to, or the deck changed for, the marked cards
+		if m.state == CardTagPrompt || m.state == CardMoveSelect {
+			m.state = CardListView
+			m.marked = make(map[string]bool)
+			if m.selectedCard >= len(m.deck.Cards) {
+				m.selectedCard = max(len(m.deck.Cards)-1, 0)
+			}
+		}
+
 		// If we were deleting, also return to list view
 		if m.state == CardDeleteConfirm {
 			m.state = CardListView
@@ -117,6 +151,11 @@ func (m *CardEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.selectedCard = 0
 			}
 		}
+
+		// An undo or redo may have removed cards from the end of the list
+		if m.selectedCard >= len(m.deck.Cards) {
+			m.selectedCard = max(len(m.deck.Cards)-1, 0)
+		}
 	}
 
 	return m, nil
@@ -137,31 +176,85 @@ func (m *CardEditorModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Create new card
 		m.state = CardForm
 		m.editingCard = &models.Card{}
-		m.frontTextarea.SetValue("")
-		m.backTextarea.SetValue("")
-		m.frontTextarea.Focus()
-		m.backTextarea.Blur()
-		m.currentField = 0
+		m.editingNote = nil
+		m.formError = ""
+		m.setNoteType(m.noteType, nil) // Keep the last used note type
+		m.tagsInput.SetValue("")
 		m.isNewCard = true
 	case "e", "enter":
 		if len(m.deck.Cards) > 0 {
-			// Edit selected card
+			// Edit the note the selected card was generated from
 			selectedCard := &m.deck.Cards[m.selectedCard]
+			note := m.deck.NoteFor(selectedCard)
 			m.state = CardForm
 			m.editingCard = selectedCard
-			m.frontTextarea.SetValue(selectedCard.Front)
-			m.backTextarea.SetValue(selectedCard.Back)
-			m.frontTextarea.Focus()
-			m.backTextarea.Blur()
-			m.currentField = 0
+			m.editingNote = note
+			m.formError = ""
+			values := []string{note.Front, note.Back}
+			if custom := models.FindNoteType(m.noteTypes, note.Type); custom != nil {
+				values = nil
+				for _, field := range custom.Fields {
+					values = append(values, note.Fields[field])
+				}
+			} else if note.Type.IsImported() {
+				// Anktui can't regenerate the cards of imported notes, so edit the card itself
+				values = []string{selectedCard.Front, selectedCard.Back}
+			} else if !note.Type.IsBuiltIn() {
+				// The note's type is gone, so edit the card's text as a basic note
+				values = []string{selectedCard.Front, selectedCard.Back}
+			}
+			m.setNoteType(note.Type, values)
+			m.tagsInput.SetValue(strings.Join(selectedCard.Tags, " "))
 			m.isNewCard = false
 		}
+	case " ":
+		if len(m.deck.Cards) > 0 {
+			// Mark the card for bulk tagging and move on
+			id := m.deck.Cards[m.selectedCard].ID
+			if m.marked[id] {
+				delete(m.marked, id)
+			} else {
+				m.marked[id] = true
+			}
+			if m.selectedCard < len(m.deck.Cards)-1 {
+				m.selectedCard++
+			}
+		}
+	case "t", "T":
+		if len(m.deck.Cards) > 0 {
+			// Add (t) or remove (T) tags on the marked cards, or the selected one
+			m.state = CardTagPrompt
+			m.removingTags = msg.String() == "T"
+			m.tagPrompt.SetValue("")
+			m.tagPrompt.Focus()
+		}
+	case "m":
+		if len(m.deck.Cards) > 0 && len(m.moveTargets()) > 0 {
+			// Move the marked cards, or the selected one, to another deck
+			m.state = CardMoveSelect
+			m.moveTarget = 0
+		}
 	case "d":
 		if len(m.deck.Cards) > 0 {
 			// Delete selected card
 			m.state = CardDeleteConfirm
 		}
+	case "u", "ctrl+z":
+		// Undo the latest edit of decks and cards
+		return m, func() tea.Msg {
+			return UndoEditMsg{}
+		}
+	case "ctrl+r", "ctrl+y":
+		// Redo the latest undone edit
+		return m, func() tea.Msg {
+			return RedoEditMsg{}
+		}
 	case "esc":
+		if len(m.marked) > 0 {
+			// Clear the marks first
+			m.marked = make(map[string]bool)
+			return m, nil
+		}
 		return m, func() tea.Msg {
 			return NavigateMsg{Screen: DeckManagerScreen}
 		}
@@ -170,44 +263,284 @@ func (m *CardEditorModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// SelectCard moves the selection to the card with the given ID
+func (m *CardEditorModel) SelectCard(cardID string) {
+	for i, card := range m.deck.Cards {
+		if card.ID == cardID {
+			m.selectedCard = i
+			return
+		}
+	}
+}
+
+// CapturingText reports whether keystrokes are going into a text field
+func (m *CardEditorModel) CapturingText() bool {
+	return m.state == CardForm || m.state == CardTagPrompt
+}
+
+// builtInFieldLabels label the front and back fields of the built-in note types
+var builtInFieldLabels = []string{
+	"Front (Question, or cloze text like {{c1::answer::hint}}):",
+	"Back (Answer, or extra text for cloze - supports markdown):",
+}
+
+// customNoteType returns the custom note type being edited, or nil for a built-in one
+func (m *CardEditorModel) customNoteType() *models.CustomNoteType {
+	return models.FindNoteType(m.noteTypes, m.noteType)
+}
+
+// noteTypeOf returns the custom note type of a note, or nil
+func (m *CardEditorModel) noteTypeOf(note *models.Note) *models.CustomNoteType {
+	if note == nil {
+		return nil
+	}
+	return models.FindNoteType(m.noteTypes, note.Type)
+}
+
+// noteTypeName returns the name of the note type being edited
+func (m *CardEditorModel) noteTypeName() string {
+	if custom := m.customNoteType(); custom != nil {
+		return custom.Name
+	}
+	return m.noteType.String()
+}
+
+// noteTypeChoices lists the built-in note types followed by the custom ones
+func (m *CardEditorModel) noteTypeChoices() []models.NoteType {
+	choices := slices.Clone(models.NoteTypes)
+	for _, custom := range m.noteTypes {
+		choices = append(choices, custom.ID)
+	}
+	return choices
+}
+
+// setNoteType builds the form fields of a note type, filling them with values in order
+func (m *CardEditorModel) setNoteType(noteType models.NoteType, values []string) {
+	m.noteType = noteType
+	labels := builtInFieldLabels
+	placeholders := []string{
+		"Enter the front of the card (question)...",
+		"Enter the back of the card (answer) - supports markdown...",
+	}
+	if custom := m.customNoteType(); custom != nil {
+		labels, placeholders = nil, nil
+		for _, field := range custom.Fields {
+			labels = append(labels, field+":")
+			placeholders = append(placeholders, "Enter the "+strings.ToLower(field)+" - supports markdown...")
+		}
+	} else if !noteType.IsBuiltIn() && !noteType.IsImported() {
+		m.noteType = models.BasicNote
+	}
+
+	// Keep forms with many fields on screen
+	height := 3
+	if len(labels) > 2 {
+		height = 2
+	}
+
+	m.fieldLabels = labels
+	m.fieldInputs = make([]textarea.Model, len(labels))
+	for i := range labels {
+		input := textarea.New()
+		input.Placeholder = placeholders[i]
+		input.SetWidth(60)
+		input.SetHeight(height)
+		if i < len(values) {
+			input.SetValue(values[i])
+		}
+		m.fieldInputs[i] = input
+	}
+	m.focusField(0)
+}
+
+// fieldValues returns the trimmed values of the note's fields
+func (m *CardEditorModel) fieldValues() []string {
+	values := make([]string, len(m.fieldInputs))
+	for i, input := range m.fieldInputs {
+		values[i] = strings.TrimSpace(input.Value())
+	}
+	return values
+}
+
+// focusField moves the cursor to a form field: the note's fields in order, then the tags
+func (m *CardEditorModel) focusField(field int) {
+	m.currentField = field
+	for i := range m.fieldInputs {
+		m.fieldInputs[i].Blur()
+	}
+	m.tagsInput.Blur()
+	if field < len(m.fieldInputs) {
+		m.fieldInputs[field].Focus()
+	} else {
+		m.tagsInput.Focus()
+	}
+}
+
+// tagTargets returns the IDs of the marked cards, or of the selected card when none are marked
+func (m *CardEditorModel) tagTargets() []string {
+	var ids []string
+	for _, card := range m.deck.Cards {
+		if m.marked[card.ID] {
+			ids = append(ids, card.ID)
+		}
+	}
+	if len(ids) == 0 && len(m.deck.Cards) > 0 {
+		ids = append(ids, m.deck.Cards[m.selectedCard].ID)
+	}
+	return ids
+}
+
+// moveTargets returns the decks cards can be moved to, sorted by name. Filtered decks only
+// borrow cards, so they are left out
+func (m *CardEditorModel) moveTargets() []*models.Deck {
+	var targets []*models.Deck
+	for _, deck := range m.decks {
+		if deck.ID != m.deck.ID && !deck.IsFiltered() {
+			targets = append(targets, deck)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Name < targets[j].Name
+	})
+	return targets
+}
+
+// updateMove handles choosing the deck to move cards to
+func (m *CardEditorModel) updateMove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	targets := m.moveTargets()
+	switch msg.String() {
+	case "up", "k":
+		if m.moveTarget > 0 {
+			m.moveTarget--
+		}
+	case "down", "j":
+		if m.moveTarget < len(targets)-1 {
+			m.moveTarget++
+		}
+	case "enter":
+		if m.moveTarget < len(targets) {
+			moveMsg := MoveCardsMsg{From: m.deck, To: targets[m.moveTarget], CardIDs: m.tagTargets()}
+			return m, func() tea.Msg {
+				return moveMsg
+			}
+		}
+	case "esc":
+		m.state = CardListView
+	}
+	return m, nil
+}
+
+// updateTagPrompt handles entering tags to add to or remove from cards
+func (m *CardEditorModel) updateTagPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		tags := models.ParseTags(m.tagPrompt.Value())
+		if len(tags) == 0 {
+			m.state = CardListView
+			return m, nil
+		}
+		tagMsg := TagCardsMsg{Deck: m.deck, CardIDs: m.tagTargets()}
+		if m.removingTags {
+			tagMsg.Remove = tags
+		} else {
+			tagMsg.Add = tags
+		}
+		return m, func() tea.Msg {
+			return tagMsg
+		}
+	case "esc":
+		m.state = CardListView
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.tagPrompt, cmd = m.tagPrompt.Update(msg)
+	return m, cmd
+}
+
 // updateForm handles card creation/editing form
 func (m *CardEditorModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg.String() {
 	case "tab":
-		if m.currentField < 1 {
-			m.currentField++
-			m.frontTextarea.Blur()
-			m.backTextarea.Focus()
+		if m.currentField < len(m.fieldInputs) {
+			m.focusField(m.currentField + 1)
 		}
+		return m, nil
 	case "shift+tab":
 		if m.currentField > 0 {
-			m.currentField--
-			m.backTextarea.Blur()
-			m.frontTextarea.Focus()
+			m.focusField(m.currentField - 1)
 		}
+		return m, nil
+	case "ctrl+t":
+		if m.noteType.IsImported() {
+			m.formError = "Cards imported from Anki keep their note type"
+			return m, nil
+		}
+		// Cycle through the note types, carrying the text over field by field
+		choices := m.noteTypeChoices()
+		values := make([]string, len(m.fieldInputs))
+		for i, input := range m.fieldInputs {
+			values[i] = input.Value()
+		}
+		for i, noteType := range choices {
+			if noteType == m.noteType {
+				m.setNoteType(choices[(i+1)%len(choices)], values)
+				break
+			}
+		}
+		m.formError = ""
+		return m, nil
 	case "ctrl+s":
 		// Save card
-		frontValue := strings.TrimSpace(m.frontTextarea.Value())
-		backValue := strings.TrimSpace(m.backTextarea.Value())
-
-		if frontValue == "" || backValue == "" {
+		values := m.fieldValues()
+		tags := models.ParseTags(m.tagsInput.Value())
+
+		var fields map[string]string
+		custom := m.customNoteType()
+		if custom != nil {
+			fields = make(map[string]string)
+			for i, field := range custom.Fields {
+				fields[field] = values[i]
+			}
+			if !custom.GeneratesCards(fields) {
+				m.formError = "Fill in the fields used on the front of at least one card template"
+				return m, nil
+			}
+		} else if m.noteType == models.ClozeNote {
+			if !models.HasCloze(values[0]) {
+				m.formError = "Cloze text needs at least one deletion like {{c1::answer}}"
+				return m, nil
+			}
+		} else if values[0] == "" || values[1] == "" {
 			return m, nil // Don't save without both sides
 		}
 
-		m.editingCard.Front = frontValue
-		m.editingCard.Back = backValue
-
 		if m.isNewCard {
-			// Create new card
+			// Create the note and its cards
+			note := models.NewNote(m.noteType, values[0], values[1])
+			if custom != nil {
+				note = models.NewCustomNote(custom, fields)
+			}
 			return m, func() tea.Msg {
-				return CreateCardMsg{Deck: m.deck, Card: m.editingCard}
+				return CreateCardMsg{Deck: m.deck, Note: note, Tags: tags}
+			}
+		} else if m.noteType.IsImported() {
+			// Change only the edited card of the imported note
+			return m, func() tea.Msg {
+				return UpdateCardMsg{Deck: m.deck, Card: m.editingCard, Front: values[0], Back: values[1], Tags: tags}
 			}
 		} else {
-			// Update existing card
+			// Update the note and regenerate its cards
+			note := *m.editingNote
+			note.Type = m.noteType
+			note.Front, note.Back, note.Fields = "", "", fields
+			if custom == nil {
+				note.Front, note.Back = values[0], values[1]
+			}
 			return m, func() tea.Msg {
-				return UpdateCardMsg{Deck: m.deck, Card: m.editingCard}
+				return UpdateCardMsg{Deck: m.deck, Card: m.editingCard, Note: &note, Tags: tags}
 			}
 		}
 	case "esc":
@@ -216,16 +549,14 @@ func (m *CardEditorModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Update the active textarea
-	if m.currentField == 0 {
-		var cmd tea.Cmd
-		m.frontTextarea, cmd = m.frontTextarea.Update(msg)
-		cmds = append(cmds, cmd)
+	// Update the active field
+	var cmd tea.Cmd
+	if m.currentField < len(m.fieldInputs) {
+		m.fieldInputs[m.currentField], cmd = m.fieldInputs[m.currentField].Update(msg)
 	} else {
-		var cmd tea.Cmd
-		m.backTextarea, cmd = m.backTextarea.Update(msg)
-		cmds = append(cmds, cmd)
+		m.tagsInput, cmd = m.tagsInput.Update(msg)
 	}
+	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
@@ -260,6 +591,10 @@ func (m *CardEditorModel) View() string {
 		return m.viewForm()
 	case CardDeleteConfirm:
 		return m.viewDelete()
+	case CardTagPrompt:
+		return m.viewTagPrompt()
+	case CardMoveSelect:
+		return m.viewMove()
 	default:
 		return "Unknown state"
 	}
@@ -280,7 +615,7 @@ func (m *CardEditorModel) viewList() string {
 		Foreground(mutedColor).
 		Align(lipgloss.Center).
 		PaddingBottom(2).
-		Render(fmt.Sprintf("Total: %d cards", len(m.deck.Cards)))
+		Render(m.listStats())
 
 	// Create card list
 	var cardItems []string
@@ -296,6 +631,15 @@ func (m *CardEditorModel) viewList() string {
 		for i, card := range m.deck.Cards {
 			// Truncate long text
 			front := card.Front
+			note := m.deck.GetNote(card.NoteID)
+			if card.Type == models.ClozeCard {
+				front = fmt.Sprintf("[c%d] %s", card.Ord, card.Question())
+			} else if note != nil && note.Type == models.ReversedNote && card.Ord == 2 {
+				front = "[reverse] " + front
+			} else if custom := m.noteTypeOf(note); custom != nil && len(custom.Templates) > 1 && custom.Template(card.Ord) != nil {
+				// Tell the cards of a note apart by their template
+				front = fmt.Sprintf("[%s] %s", custom.Template(card.Ord).Name, front)
+			}
 			if len(front) > 40 {
 				front = front[:37] + "..."
 			}
@@ -320,11 +664,18 @@ func (m *CardEditorModel) viewList() string {
 					BorderForeground(secondaryColor)
 			}
 
-			cardContent := lipgloss.JoinVertical(
-				lipgloss.Left,
-				lipgloss.NewStyle().Bold(true).Foreground(textColor).Render("Q: "+front),
-				lipgloss.NewStyle().Foreground(mutedColor).Render("A: "+back),
-			)
+			question := "Q: " + front
+			if m.marked[card.ID] {
+				question = "✓ " + question
+			}
+			lines := []string{
+				lipgloss.NewStyle().Bold(true).Foreground(textColor).Render(question),
+				lipgloss.NewStyle().Foreground(mutedColor).Render("A: " + back),
+			}
+			if len(card.Tags) > 0 {
+				lines = append(lines, lipgloss.NewStyle().Foreground(accentColor).Render("# "+strings.Join(card.Tags, " ")))
+			}
+			cardContent := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 			cardItems = append(cardItems, itemStyle.Render(cardContent))
 		}
@@ -355,9 +706,9 @@ func (m *CardEditorModel) viewList() string {
 	// Help text
 	var helpText string
 	if len(m.deck.Cards) > 0 {
-		helpText = "↑/↓: navigate • Enter/e: edit • d: delete • n: new card • Esc: back"
+		helpText = "↑/↓: navigate • Enter/e: edit • d: delete • n: new card • Space: mark • t/T: add/remove tags • m: move • u/Ctrl+R: undo/redo • Esc: back"
 	} else {
-		helpText = "n: create new card • Esc: back to deck manager"
+		helpText = "n: create new card • u/Ctrl+R: undo/redo • Esc: back to deck manager"
 	}
 
 	help := lipgloss.NewStyle().
@@ -396,35 +747,46 @@ func (m *CardEditorModel) viewForm() string {
 		PaddingBottom(2).
 		Render(titleText)
 
-	// Front field
-	frontLabel := lipgloss.NewStyle().
+	// Note type selector
+	typeLabel := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(textColor).
 		PaddingBottom(1).
-		Render("Front (Question):")
-
-	frontValue := m.frontTextarea.View()
+		Render("Type: " + lipgloss.NewStyle().Foreground(secondaryColor).Render("◀ "+m.noteTypeName()+" ▶"))
 
-	frontField := lipgloss.JoinVertical(
-		lipgloss.Left,
-		frontLabel,
-		frontValue,
-	)
+	// One field per field of the note type, spaced closer when there are many
+	formFields := []string{typeLabel}
+	spacing := 2
+	if len(m.fieldInputs) > 2 {
+		spacing = 1
+	}
+	for i, input := range m.fieldInputs {
+		label := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(textColor).
+			PaddingBottom(1)
+		if i > 0 {
+			label = label.PaddingTop(spacing)
+		}
+		formFields = append(formFields, lipgloss.JoinVertical(
+			lipgloss.Left,
+			label.Render(m.fieldLabels[i]),
+			input.View(),
+		))
+	}
 
-	// Back field
-	backLabel := lipgloss.NewStyle().
+	// Tags field
+	tagsLabel := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(textColor).
 		PaddingBottom(1).
-		PaddingTop(2).
-		Render("Back (Answer - supports markdown):")
-
-	backValue := m.backTextarea.View()
+		PaddingTop(spacing).
+		Render("Tags (shared by all cards of the note):")
 
-	backField := lipgloss.JoinVertical(
+	tagsField := lipgloss.JoinVertical(
 		lipgloss.Left,
-		backLabel,
-		backValue,
+		tagsLabel,
+		m.tagsInput.View(),
 	)
 
 	// Help text
@@ -433,14 +795,14 @@ func (m *CardEditorModel) viewForm() string {
 		Italic(true).
 		Align(lipgloss.Center).
 		PaddingTop(3).
-		Render("Tab: switch fields • Ctrl+S: save • Esc: cancel • Arrow keys: navigate text")
+		Render("Tab: switch fields • Ctrl+T: card type • Ctrl+S: save • Esc: cancel • Arrow keys: navigate text")
 
 	// Combine all elements
-	form := lipgloss.JoinVertical(
-		lipgloss.Left,
-		frontField,
-		backField,
-	)
+	formFields = append(formFields, tagsField)
+	if m.formError != "" {
+		formFields = append(formFields, errorStyle.PaddingTop(1).Render(m.formError))
+	}
+	form := lipgloss.JoinVertical(lipgloss.Left, formFields...)
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -505,6 +867,111 @@ func (m *CardEditorModel) viewDelete() string {
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
 
+// listStats summarises the deck's cards, tags and marked cards
+func (m *CardEditorModel) listStats() string {
+	stats := fmt.Sprintf("Total: %d cards", len(m.deck.Cards))
+	if tags := m.deck.Tags(); len(tags) > 0 {
+		stats += fmt.Sprintf(" • %d tags", len(tags))
+	}
+	if len(m.marked) > 0 {
+		stats += fmt.Sprintf(" • %d marked", len(m.marked))
+	}
+	return stats
+}
+
+// viewTagPrompt renders the prompt for tags to add to or remove from cards
+func (m *CardEditorModel) viewTagPrompt() string {
+	action := "Add Tags"
+	if m.removingTags {
+		action = "Remove Tags"
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render(action)
+
+	count := len(m.tagTargets())
+	target := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render(fmt.Sprintf("%d selected cards and their siblings", count))
+
+	input := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(secondaryColor).
+		Padding(0, 1).
+		Width(50).
+		Render(m.tagPrompt.View())
+
+	lines := []string{title, target, input}
+	if tags := m.deck.Tags(); len(tags) > 0 {
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Width(60).
+			Align(lipgloss.Center).
+			PaddingTop(1).
+			Render("In this deck: "+strings.Join(tags, " ")))
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(2).
+		Render("Enter: apply • Esc: cancel")
+	lines = append(lines, help)
+
+	content := lipgloss.JoinVertical(lipgloss.Center, lines...)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewMove renders the list of decks to move cards to
+func (m *CardEditorModel) viewMove() string {
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render("Move Cards")
+
+	target := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render(fmt.Sprintf("%d selected cards and their siblings, from %s to:", len(m.tagTargets()), m.deck.Name))
+
+	var items []string
+	for i, deck := range m.moveTargets() {
+		style := lipgloss.NewStyle().Foreground(textColor).PaddingLeft(2)
+		prefix := "  "
+		if i == m.moveTarget {
+			style = style.Foreground(secondaryColor).Bold(true)
+			prefix = "▶ "
+		}
+		items = append(items, style.Render(prefix+deck.Name))
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(2).
+		Render("↑/↓: choose deck • Enter: move • Esc: cancel")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		target,
+		lipgloss.JoinVertical(lipgloss.Left, items...),
+		help,
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
 // wrapText wraps text to the specified width
 func (m *CardEditorModel) wrapText(text string, width int) string {
 	if len(text) <= width {
@@ -540,12 +1007,32 @@ func (m *CardEditorModel) wrapText(text string, width int) string {
 // Message types for card operations
 type CreateCardMsg struct {
 	Deck *models.Deck
-	Card *models.Card
+	Note *models.Note // Note the new cards are generated from
+	Tags []string
 }
 
 type UpdateCardMsg struct {
-	Deck *models.Deck
-	Card *models.Card
+	Deck  *models.Deck
+	Card  *models.Card // Card that was selected for editing
+	Note  *models.Note // Edited copy of the card's note, nil when the card is edited on its own
+	Front string       // New content of a card edited on its own
+	Back  string
+	Tags  []string
+}
+
+// TagCardsMsg adds and removes tags on cards and their siblings
+type TagCardsMsg struct {
+	Deck    *models.Deck
+	CardIDs []string
+	Add     []string
+	Remove  []string
+}
+
+// MoveCardsMsg moves cards, along with their siblings, to another deck
+type MoveCardsMsg struct {
+	From    *models.Deck
+	To      *models.Deck
+	CardIDs []string
 }
 
 type DeleteCardMsg struct {
@@ -0,0 +1,378 @@
+package ui
+
+import (
+	"anktui/interop"
+	"anktui/models"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// csvPreviewRows is the number of data rows shown in the import preview
+const csvPreviewRows = 5
+
+// csvImportField identifies an option row in the delimited import dialog
+type csvImportField int
+
+const (
+	csvFieldDelimiter csvImportField = iota
+	csvFieldHeader
+	csvFieldFront
+	csvFieldBack
+	csvFieldTags
+	csvFieldInterval
+	csvFieldEase
+	csvFieldDue
+	csvFieldDuplicates
+	csvFieldCount
+)
+
+// CSVImportModel is the dialog for mapping a delimited text file onto a deck
+type CSVImportModel struct {
+	deck *models.Deck
+	path string
+	text string
+
+	delimiterChoice int // 0 auto-detects, otherwise the index into interop.Delimiters plus one
+	hasHeader       bool
+	rows            [][]string
+	parseErr        error
+	mapping         interop.ColumnMapping
+	duplicates      interop.DuplicatePolicy
+	field           csvImportField
+
+	width  int
+	height int
+}
+
+// NewCSVImportModel creates an import dialog for the file contents, targeting deck
+func NewCSVImportModel(deck *models.Deck, path, text string) *CSVImportModel {
+	m := &CSVImportModel{
+		deck:       deck,
+		path:       path,
+		text:       text,
+		duplicates: interop.DuplicateSkip,
+		field:      csvFieldFront,
+	}
+
+	// Extensions are a stronger hint than counting characters
+	if interop.DelimiterForPath(path) == '\t' {
+		m.delimiterChoice = delimiterIndex('\t') + 1
+	}
+	m.reparse()
+	m.resetMapping()
+
+	// Treat the first row as a header when it names the front and back columns
+	if len(m.rows) > 0 {
+		if mapping, ok := interop.MappingFromHeader(m.rows[0]); ok {
+			m.hasHeader = true
+			m.mapping = mapping
+		}
+	}
+
+	return m
+}
+
+// SetSize sets the terminal size
+func (m *CSVImportModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// Options returns the import options chosen in the dialog
+func (m *CSVImportModel) Options() interop.DelimitedOptions {
+	return interop.DelimitedOptions{
+		Delimiter:  m.delimiter(),
+		HasHeader:  m.hasHeader,
+		Mapping:    m.mapping,
+		Duplicates: m.duplicates,
+	}
+}
+
+// Rows returns the parsed rows, including the header row if there is one
+func (m *CSVImportModel) Rows() [][]string {
+	return m.rows
+}
+
+// Deck returns the deck the rows will be imported into
+func (m *CSVImportModel) Deck() *models.Deck {
+	return m.deck
+}
+
+// Ready reports whether the file parsed and has rows to import
+func (m *CSVImportModel) Ready() bool {
+	return m.parseErr == nil && len(m.dataRows()) > 0
+}
+
+// Update handles navigation between options and changing their values
+func (m *CSVImportModel) Update(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k", "shift+tab":
+		if m.field > 0 {
+			m.field--
+		}
+	case "down", "j", "tab":
+		if m.field < csvFieldCount-1 {
+			m.field++
+		}
+	case "left", "h":
+		m.adjust(-1)
+	case "right", "l", " ":
+		m.adjust(1)
+	}
+}
+
+// adjust cycles the value of the focused option by delta
+func (m *CSVImportModel) adjust(delta int) {
+	switch m.field {
+	case csvFieldDelimiter:
+		choices := len(interop.Delimiters) + 1
+		m.delimiterChoice = (m.delimiterChoice + delta + choices) % choices
+		m.reparse()
+		m.resetMapping()
+	case csvFieldHeader:
+		m.hasHeader = !m.hasHeader
+		m.resetMapping()
+	case csvFieldDuplicates:
+		m.duplicates = interop.DuplicatePolicy((int(m.duplicates) + delta + 3) % 3)
+	default:
+		index := m.mappedColumn(m.field)
+		lowest := interop.Unmapped
+		if m.field == csvFieldFront || m.field == csvFieldBack {
+			lowest = 0
+		}
+		span := m.columns() - lowest
+		if span <= 0 {
+			return
+		}
+		*index = (*index-lowest+delta+span)%span + lowest
+	}
+}
+
+// mappedColumn returns the mapping entry edited by a column option
+func (m *CSVImportModel) mappedColumn(field csvImportField) *int {
+	switch field {
+	case csvFieldFront:
+		return &m.mapping.Front
+	case csvFieldBack:
+		return &m.mapping.Back
+	case csvFieldTags:
+		return &m.mapping.Tags
+	case csvFieldInterval:
+		return &m.mapping.Interval
+	case csvFieldEase:
+		return &m.mapping.Ease
+	default:
+		return &m.mapping.Due
+	}
+}
+
+// reparse splits the file with the current delimiter
+func (m *CSVImportModel) reparse() {
+	m.rows, m.parseErr = interop.ReadDelimited(strings.NewReader(m.text), m.delimiter())
+}
+
+// resetMapping guesses a mapping from the header row, or falls back to the first two columns
+func (m *CSVImportModel) resetMapping() {
+	m.mapping = interop.DefaultColumnMapping()
+	if m.hasHeader && len(m.rows) > 0 {
+		m.mapping, _ = interop.MappingFromHeader(m.rows[0])
+	}
+}
+
+// delimiter returns the chosen delimiter, detecting it from the file in auto mode
+func (m *CSVImportModel) delimiter() rune {
+	if m.delimiterChoice == 0 {
+		return interop.DetectDelimiter(m.text)
+	}
+	return interop.Delimiters[m.delimiterChoice-1]
+}
+
+// dataRows returns the rows that will become cards
+func (m *CSVImportModel) dataRows() [][]string {
+	if m.hasHeader && len(m.rows) > 0 {
+		return m.rows[1:]
+	}
+	return m.rows
+}
+
+// columns returns the widest row's column count
+func (m *CSVImportModel) columns() int {
+	columns := 0
+	for _, row := range m.rows {
+		columns = max(columns, len(row))
+	}
+	return columns
+}
+
+// duplicateCount counts data rows whose front matches the first field of a note in the deck
+func (m *CSVImportModel) duplicateCount() int {
+	existing := interop.NoteKeys(m.deck)
+
+	count := 0
+	for _, row := range m.dataRows() {
+		if m.mapping.Front >= len(row) {
+			continue
+		}
+		if _, ok := existing[interop.NormalizeFront(row[m.mapping.Front])]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// columnLabel describes a column by number and header name or first value
+func (m *CSVImportModel) columnLabel(index int) string {
+	if index == interop.Unmapped {
+		return "(none)"
+	}
+
+	label := fmt.Sprintf("Column %d", index+1)
+	sample := ""
+	if m.hasHeader && len(m.rows) > 0 && index < len(m.rows[0]) {
+		sample = m.rows[0][index]
+	} else if rows := m.dataRows(); len(rows) > 0 && index < len(rows[0]) {
+		sample = rows[0][index]
+	}
+	if sample = previewCell(sample, 24); sample != "" {
+		label += fmt.Sprintf(" (%s)", sample)
+	}
+	return label
+}
+
+// View renders the import dialog
+func (m *CSVImportModel) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render(fmt.Sprintf("Import %s into '%s'", filepath.Base(m.path), m.deck.Name))
+
+	// Option rows
+	delimiterValue := interop.DelimiterName(m.delimiter())
+	if m.delimiterChoice == 0 {
+		delimiterValue = fmt.Sprintf("Auto (%s)", delimiterValue)
+	}
+	headerValue := "No"
+	if m.hasHeader {
+		headerValue = "Yes"
+	}
+
+	options := []struct {
+		label string
+		value string
+	}{
+		{"Delimiter", delimiterValue},
+		{"Header row", headerValue},
+		{"Front", m.columnLabel(m.mapping.Front)},
+		{"Back", m.columnLabel(m.mapping.Back)},
+		{"Tags", m.columnLabel(m.mapping.Tags)},
+		{"Interval", m.columnLabel(m.mapping.Interval)},
+		{"Ease", m.columnLabel(m.mapping.Ease)},
+		{"Due date", m.columnLabel(m.mapping.Due)},
+		{"Duplicates", m.duplicates.String()},
+	}
+
+	var optionLines []string
+	for i, option := range options {
+		label := lipgloss.NewStyle().Foreground(mutedColor).Width(14).Render(option.label)
+		value := lipgloss.NewStyle().Foreground(textColor).Render(option.value)
+		cursor := "  "
+		if csvImportField(i) == m.field {
+			cursor = lipgloss.NewStyle().Foreground(primaryColor).Render("▶ ")
+			value = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render("◀ " + option.value + " ▶")
+		}
+		optionLines = append(optionLines, cursor+label+value)
+	}
+
+	optionBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(1, 2).
+		Width(70).
+		Render(lipgloss.JoinVertical(lipgloss.Left, optionLines...))
+
+	content := lipgloss.JoinVertical(lipgloss.Center, title, optionBox, m.viewPreview())
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render("↑/↓: select option • ←/→: change • Enter: import • Esc: cancel")
+
+	content = lipgloss.JoinVertical(lipgloss.Center, content, help)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewPreview renders the first rows as they will be imported
+func (m *CSVImportModel) viewPreview() string {
+	if m.parseErr != nil {
+		return lipgloss.NewStyle().PaddingTop(1).Render(errorStyle.Render(m.parseErr.Error()))
+	}
+
+	rows := m.dataRows()
+	if len(rows) == 0 {
+		return lipgloss.NewStyle().PaddingTop(1).Render(mutedTextStyle.Render("No rows to import"))
+	}
+
+	cell := func(text string, width int, color lipgloss.Color) string {
+		return lipgloss.NewStyle().Foreground(color).Width(width).Render(previewCell(text, width-2))
+	}
+
+	lines := []string{
+		cell("Front", 28, primaryColor) + cell("Back", 28, primaryColor) + cell("Tags", 14, primaryColor),
+	}
+	for _, row := range rows[:min(len(rows), csvPreviewRows)] {
+		lines = append(lines,
+			cell(previewColumn(row, m.mapping.Front), 28, textColor)+
+				cell(previewColumn(row, m.mapping.Back), 28, textColor)+
+				cell(previewColumn(row, m.mapping.Tags), 14, mutedColor))
+	}
+
+	summary := fmt.Sprintf("%d rows", len(rows))
+	if duplicates := m.duplicateCount(); duplicates > 0 {
+		summary += fmt.Sprintf(" • %d already in this deck", duplicates)
+	}
+	lines = append(lines, "", mutedTextStyle.Render(summary))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(0, 1).
+		Width(70).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// previewColumn returns the value of a mapped column, or "" when unmapped or missing
+func previewColumn(row []string, index int) string {
+	if index < 0 || index >= len(row) {
+		return ""
+	}
+	return row[index]
+}
+
+// previewCell flattens multi-line text and truncates it to width runes
+func previewCell(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if width > 0 && len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return text
+}
+
+// delimiterIndex returns the position of delimiter in interop.Delimiters
+func delimiterIndex(delimiter rune) int {
+	for i, d := range interop.Delimiters {
+		if d == delimiter {
+			return i
+		}
+	}
+	return 0
+}
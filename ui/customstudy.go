@@ -0,0 +1,368 @@
+package ui
+
+import (
+	"anktui/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultFilteredDeckName is the filtered deck custom study creates unless named otherwise.
+// Creating it again rebuilds it with the new filter
+const defaultFilteredDeckName = "Custom Study Session"
+
+// Custom study form fields
+const (
+	customStudyKindField = iota
+	customStudyDaysField
+	customStudySearchField
+	customStudyLimitField
+	customStudyRescheduleField
+	customStudyNameField
+	customStudyFieldCount
+)
+
+// customStudyKinds describes each kind of filtered deck in the form
+var customStudyKinds = map[models.FilterKind]string{
+	models.ReviewAheadFilter: "Review cards due in the next few days",
+	models.ForgottenFilter:   "Study cards you rated Again recently",
+	models.CramFilter:        "Study any cards matching the search",
+	models.PreviewFilter:     "Look at new cards before they are scheduled",
+}
+
+// CustomStudyModel represents the form that creates a filtered deck for custom study
+type CustomStudyModel struct {
+	decks      []*models.Deck
+	kind       int // Index into models.FilterKinds
+	reschedule bool
+	focus      int
+	formError  string
+	building   bool // Waiting for the filtered deck to be built
+
+	daysInput   textinput.Model
+	searchInput textinput.Model
+	limitInput  textinput.Model
+	nameInput   textinput.Model
+
+	width  int
+	height int
+}
+
+// NewCustomStudyModel creates a custom study form, searching within the named deck to start with
+func NewCustomStudyModel(decks []*models.Deck, deckName string) *CustomStudyModel {
+	daysInput := textinput.New()
+	daysInput.SetValue("1")
+	daysInput.Width = 10
+
+	searchInput := textinput.New()
+	searchInput.Placeholder = "deck:Spanish tag:verbs"
+	if deckName != "" {
+		searchInput.SetValue(fmt.Sprintf("deck:%q", deckName))
+	}
+	searchInput.Width = 50
+
+	limitInput := textinput.New()
+	limitInput.SetValue("100")
+	limitInput.Width = 10
+
+	nameInput := textinput.New()
+	nameInput.SetValue(defaultFilteredDeckName)
+	nameInput.Width = 50
+
+	m := &CustomStudyModel{
+		decks:       decks,
+		reschedule:  models.FilterKinds[0].Reschedules(),
+		daysInput:   daysInput,
+		searchInput: searchInput,
+		limitInput:  limitInput,
+		nameInput:   nameInput,
+	}
+	m.focusField(customStudyKindField)
+	return m
+}
+
+// SetSize sets the terminal size
+func (m *CustomStudyModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// CapturingText reports whether keystrokes are going into a text field
+func (m *CustomStudyModel) CapturingText() bool {
+	return m.input(m.focus) != nil
+}
+
+// Init implements tea.Model
+func (m *CustomStudyModel) Init() tea.Cmd {
+	return nil
+}
+
+// input returns the text input of a form field, or nil for the kind and reschedule fields
+func (m *CustomStudyModel) input(field int) *textinput.Model {
+	switch field {
+	case customStudyDaysField:
+		return &m.daysInput
+	case customStudySearchField:
+		return &m.searchInput
+	case customStudyLimitField:
+		return &m.limitInput
+	case customStudyNameField:
+		return &m.nameInput
+	}
+	return nil
+}
+
+// focusField moves the focus to a form field
+func (m *CustomStudyModel) focusField(field int) {
+	for f := 0; f < customStudyFieldCount; f++ {
+		if input := m.input(f); input != nil {
+			input.Blur()
+		}
+	}
+	m.focus = field
+	if input := m.input(field); input != nil {
+		input.Focus()
+	}
+}
+
+// moveFocus moves the focus by step, skipping the days field for kinds that do not use it
+func (m *CustomStudyModel) moveFocus(step int) {
+	field := (m.focus + step + customStudyFieldCount) % customStudyFieldCount
+	if field == customStudyDaysField && !models.FilterKinds[m.kind].UsesDays() {
+		field = (field + step + customStudyFieldCount) % customStudyFieldCount
+	}
+	m.focusField(field)
+}
+
+// Update implements tea.Model
+func (m *CustomStudyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case FilteredDeckBuiltMsg:
+		if !m.building {
+			break
+		}
+		m.building = false
+		if msg.Err != nil {
+			m.formError = msg.Err.Error()
+			return m, nil
+		}
+		if msg.Borrowed == 0 {
+			m.formError = "No cards match. Try more days or a wider search"
+			return m, nil
+		}
+		// Start studying the new filtered deck
+		for _, deck := range msg.Decks {
+			if deck.ID == msg.Deck.ID {
+				req := newFilteredStudyRequest(deck, msg.Decks)
+				return m, func() tea.Msg {
+					return NavigateMsg{Screen: StudyScreen, Data: req}
+				}
+			}
+		}
+
+	case tea.KeyMsg:
+		if m.building {
+			return m, nil
+		}
+		m.formError = ""
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return NavigateMsg{Screen: DeckListScreen}
+			}
+		case "enter":
+			return m.create()
+		case "tab", "down":
+			m.moveFocus(1)
+			return m, nil
+		case "shift+tab", "up":
+			m.moveFocus(-1)
+			return m, nil
+		}
+
+		switch m.focus {
+		case customStudyKindField:
+			switch msg.String() {
+			case "left", "h":
+				m.setKind(m.kind - 1)
+			case "right", "l", " ":
+				m.setKind(m.kind + 1)
+			}
+			return m, nil
+		case customStudyRescheduleField:
+			switch msg.String() {
+			case "left", "right", "h", "l", " ":
+				m.reschedule = !m.reschedule
+			}
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		input := m.input(m.focus)
+		*input, cmd = input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// setKind picks the kind of filtered deck, wrapping around, along with whether it reschedules
+func (m *CustomStudyModel) setKind(kind int) {
+	m.kind = (kind + len(models.FilterKinds)) % len(models.FilterKinds)
+	m.reschedule = models.FilterKinds[m.kind].Reschedules()
+}
+
+// create validates the form and asks for the filtered deck to be built
+func (m *CustomStudyModel) create() (tea.Model, tea.Cmd) {
+	filter := models.DeckFilter{
+		Kind:       models.FilterKinds[m.kind],
+		Search:     strings.TrimSpace(m.searchInput.Value()),
+		Reschedule: m.reschedule,
+	}
+	if filter.Kind.UsesDays() {
+		days, err := strconv.Atoi(strings.TrimSpace(m.daysInput.Value()))
+		if err != nil || days < 1 {
+			m.formError = "Days must be a whole number of at least 1"
+			return m, nil
+		}
+		filter.Days = days
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(m.limitInput.Value()))
+	if err != nil || limit < 1 {
+		m.formError = "The card limit must be a whole number of at least 1"
+		return m, nil
+	}
+	filter.Limit = limit
+	if _, err := filter.Query(); err != nil {
+		m.formError = err.Error()
+		return m, nil
+	}
+
+	name := strings.TrimSpace(m.nameInput.Value())
+	if name == "" {
+		m.formError = "The filtered deck needs a name"
+		return m, nil
+	}
+	deck := models.NewFilteredDeck(name, filter)
+	for _, existing := range m.decks {
+		if existing.Name != name {
+			continue
+		}
+		if !existing.IsFiltered() {
+			m.formError = fmt.Sprintf("%s is a regular deck, choose another name", name)
+			return m, nil
+		}
+		// Rebuild the existing filtered deck with the new filter
+		updated := *existing
+		updated.Filter = &filter
+		updated.Description = filter.Describe()
+		deck = &updated
+	}
+
+	m.building = true
+	return m, func() tea.Msg {
+		return BuildFilteredDeckMsg{Deck: deck}
+	}
+}
+
+// newFilteredStudyRequest starts a session over the cards a filtered deck has borrowed
+func newFilteredStudyRequest(deck *models.Deck, decks []*models.Deck) *StudyRequest {
+	return &StudyRequest{
+		Name:     deck.Name,
+		Decks:    deck.BorrowedFrom(decks),
+		Mode:     models.FilteredMode,
+		Filtered: deck,
+	}
+}
+
+// View implements tea.Model
+func (m *CustomStudyModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return "Loading..."
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render("Custom Study")
+
+	label := func(field int, text string) string {
+		style := lipgloss.NewStyle().Bold(true).Foreground(textColor).PaddingTop(1)
+		if field == m.focus {
+			style = style.Foreground(secondaryColor)
+		}
+		return style.Render(text)
+	}
+
+	kind := models.FilterKinds[m.kind]
+	kindText := fmt.Sprintf("◀ %s ▶", kind)
+	rescheduleText := "[ ] Ratings leave the cards' scheduling alone"
+	if m.reschedule {
+		rescheduleText = "[x] Ratings reschedule the cards"
+	}
+
+	fields := []string{
+		label(customStudyKindField, "Kind:"),
+		lipgloss.NewStyle().Foreground(accentColor).Render(kindText),
+		mutedTextStyle.Render(customStudyKinds[kind]),
+	}
+	if kind.UsesDays() {
+		daysLabel := "Days ahead:"
+		if kind == models.ForgottenFilter {
+			daysLabel = "Days back:"
+		}
+		fields = append(fields, label(customStudyDaysField, daysLabel), m.daysInput.View())
+	}
+	fields = append(fields,
+		label(customStudySearchField, "Search (browser syntax, empty for all decks):"), m.searchInput.View(),
+		label(customStudyLimitField, "Card limit:"), m.limitInput.View(),
+		label(customStudyRescheduleField, "Scheduling:"), lipgloss.NewStyle().Foreground(textColor).Render(rescheduleText),
+		label(customStudyNameField, "Filtered deck name:"), m.nameInput.View(),
+	)
+	if m.building {
+		fields = append(fields, mutedTextStyle.PaddingTop(1).Render("Gathering cards..."))
+	}
+	if m.formError != "" {
+		fields = append(fields, errorStyle.PaddingTop(1).Render(m.formError))
+	}
+	form := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(0, 2, 1, 2).
+		Width(60).
+		Render(lipgloss.JoinVertical(lipgloss.Left, fields...))
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render("Tab/↑/↓: switch fields • ←/→: change kind or scheduling • Enter: build and study • Esc: cancel")
+
+	content := lipgloss.JoinVertical(lipgloss.Center, title, form, help)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// BuildFilteredDeckMsg requests (re)building a filtered deck and saving it along with the
+// decks it borrows cards from
+type BuildFilteredDeckMsg struct {
+	Deck *models.Deck
+}
+
+// FilteredDeckBuiltMsg reports how many cards a filtered deck borrowed
+type FilteredDeckBuiltMsg struct {
+	Deck     *models.Deck
+	Borrowed int
+	Decks    []*models.Deck // Reloaded decks, nil when nothing was saved
+	Err      error
+}
+
+// EmptyFilteredDeckMsg requests returning a filtered deck's cards to their home decks
+type EmptyFilteredDeckMsg struct {
+	Deck *models.Deck
+}
@@ -3,7 +3,9 @@ package ui
 import (
 	"anktui/models"
 	"fmt"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -19,21 +21,45 @@ const (
 // DeckListModel represents the deck selection screen
 type DeckListModel struct {
 	decks        []*models.Deck
+	tree         []*models.DeckNode // Decks nested by their Parent::Child names
+	rows         []*models.DeckNode // Visible nodes of the tree
+	collapsed    map[string]bool    // Names of the nodes whose children are hidden
 	selected     int
 	selectedMode int
 	state        DeckListState
-	width        int
-	height       int
+	options      models.SessionOptions // Daily limits shown as the remaining allowance
+
+	// Tag filter for the session
+	tagInput    textinput.Model
+	editingTags bool
+	tagFilter   *models.TagFilter
+	tagError    string
+
+	width  int
+	height int
 }
 
 // NewDeckListModel creates a new deck list model
-func NewDeckListModel(decks []*models.Deck) *DeckListModel {
-	return &DeckListModel{
-		decks:        decks,
+func NewDeckListModel(decks []*models.Deck, options models.SessionOptions) *DeckListModel {
+	tagInput := textinput.New()
+	tagInput.Placeholder = "e.g. graph AND NOT hard"
+	tagInput.Width = 40
+
+	m := &DeckListModel{
+		options:      options,
+		collapsed:    make(map[string]bool),
 		selected:     0,
 		selectedMode: 0, // Default to Review Mode
 		state:        SelectingDeck,
+		tagInput:     tagInput,
 	}
+	m.UpdateDecks(decks)
+	return m
+}
+
+// CapturingText reports whether keystrokes are going into the tag filter
+func (m *DeckListModel) CapturingText() bool {
+	return m.editingTags
 }
 
 // SetSize sets the terminal size
@@ -45,14 +71,42 @@ func (m *DeckListModel) SetSize(width, height int) {
 // UpdateDecks updates the deck list with fresh data
 func (m *DeckListModel) UpdateDecks(decks []*models.Deck) {
 	m.decks = decks
+	m.tree = models.BuildDeckTree(decks)
+	m.refreshRows()
+}
+
+// refreshRows lists the visible tree nodes, keeping the selection in bounds
+func (m *DeckListModel) refreshRows() {
+	m.rows = models.FlattenDeckTree(m.tree, m.collapsed)
 	// Adjust selected index if it's out of bounds
-	if m.selected >= len(m.decks) && len(m.decks) > 0 {
-		m.selected = len(m.decks) - 1
-	} else if len(m.decks) == 0 {
+	if m.selected >= len(m.rows) && len(m.rows) > 0 {
+		m.selected = len(m.rows) - 1
+	} else if len(m.rows) == 0 {
 		m.selected = 0
 	}
 }
 
+// selectNode moves the selection to the row showing the named node
+func (m *DeckListModel) selectNode(name string) {
+	for i, row := range m.rows {
+		if row.Name == name {
+			m.selected = i
+			return
+		}
+	}
+}
+
+// selectedFiltered returns the selected deck if it is a filtered deck
+func (m *DeckListModel) selectedFiltered() *models.Deck {
+	if len(m.rows) == 0 {
+		return nil
+	}
+	if deck := m.rows[m.selected].Deck; deck != nil && deck.IsFiltered() {
+		return deck
+	}
+	return nil
+}
+
 // Init implements tea.Model
 func (m *DeckListModel) Init() tea.Cmd {
 	return nil
@@ -70,14 +124,70 @@ func (m *DeckListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selected--
 				}
 			case "down", "j":
-				if len(m.decks) > 0 && m.selected < len(m.decks)-1 {
+				if len(m.rows) > 0 && m.selected < len(m.rows)-1 {
 					m.selected++
 				}
+			case "left", "h":
+				if len(m.rows) > 0 {
+					// Collapse the selected deck, or move up to its parent
+					node := m.rows[m.selected]
+					if len(node.Children) > 0 && !m.collapsed[node.Name] {
+						m.collapsed[node.Name] = true
+						m.refreshRows()
+					} else if parent := models.ParentName(node.Name); parent != "" {
+						m.selectNode(parent)
+					}
+				}
+			case "right", "l":
+				if len(m.rows) > 0 {
+					// Expand the selected deck
+					node := m.rows[m.selected]
+					if m.collapsed[node.Name] {
+						delete(m.collapsed, node.Name)
+						m.refreshRows()
+					}
+				}
 			case "enter", " ":
-				if len(m.decks) > 0 {
+				if deck := m.selectedFiltered(); deck != nil {
+					// Filtered decks are studied as built
+					req := newFilteredStudyRequest(deck, m.decks)
+					if len(req.Decks) == 0 {
+						return m, nil
+					}
+					return m, func() tea.Msg {
+						return NavigateMsg{Screen: StudyScreen, Data: req}
+					}
+				}
+				if len(m.rows) > 0 {
 					// Move to mode selection
 					m.state = SelectingMode
 					m.selectedMode = 0 // Default to Review Mode
+					m.tagFilter = nil
+					m.tagError = ""
+					m.tagInput.SetValue("")
+				}
+			case "c":
+				// Build a filtered deck for custom study, searching the selected deck by default
+				var node *models.DeckNode
+				if len(m.rows) > 0 {
+					node = m.rows[m.selected]
+				}
+				return m, func() tea.Msg {
+					return NavigateMsg{Screen: CustomStudyScreen, Data: node}
+				}
+			case "r":
+				if deck := m.selectedFiltered(); deck != nil {
+					// Rebuild the filtered deck with the cards matching it now
+					return m, func() tea.Msg {
+						return BuildFilteredDeckMsg{Deck: deck}
+					}
+				}
+			case "x":
+				if deck := m.selectedFiltered(); deck != nil {
+					// Give the filtered deck's cards back to their home decks
+					return m, func() tea.Msg {
+						return EmptyFilteredDeckMsg{Deck: deck}
+					}
 				}
 			case "n":
 				// Create new deck
@@ -85,9 +195,9 @@ func (m *DeckListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return NavigateMsg{Screen: DeckManagerScreen}
 				}
 			case "e":
-				if len(m.decks) > 0 {
+				if len(m.rows) > 0 && m.rows[m.selected].Deck != nil {
 					// Edit selected deck
-					selectedDeck := m.decks[m.selected]
+					selectedDeck := m.rows[m.selected].Deck
 					return m, func() tea.Msg {
 						return NavigateMsg{
 							Screen: DeckManagerScreen,
@@ -96,7 +206,7 @@ func (m *DeckListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			case "d":
-				if len(m.decks) > 0 {
+				if len(m.rows) > 0 {
 					// Delete selected deck (TODO: implement confirmation)
 					// For now, just return to menu
 					return m, func() tea.Msg {
@@ -110,31 +220,38 @@ func (m *DeckListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case SelectingMode:
+			if m.editingTags {
+				return m.updateTagFilter(msg)
+			}
 			switch msg.String() {
 			case "up", "k":
 				if m.selectedMode > 0 {
 					m.selectedMode--
 				}
 			case "down", "j":
-				if m.selectedMode < 1 {
+				if m.selectedMode < len(studyModes)-1 {
 					m.selectedMode++
 				}
 			case "enter", " ":
-				// Start studying with selected mode
-				selectedDeck := m.decks[m.selected]
-				mode := models.ReviewMode
-				if m.selectedMode == 1 {
-					mode = models.PracticeMode
-				}
+				// Start studying the selected deck and its descendants with selected mode
+				node := m.rows[m.selected]
+				mode := studyModes[m.selectedMode].mode
+				tags := m.tagFilter
 				return m, func() tea.Msg {
 					return NavigateMsg{
 						Screen: StudyScreen,
 						Data: &StudyRequest{
-							Deck: selectedDeck,
-							Mode: mode,
+							Name:  node.Name,
+							Decks: node.Decks(),
+							Mode:  mode,
+							Tags:  tags,
 						},
 					}
 				}
+			case "/", "t":
+				// Limit the session to cards matching a tag expression
+				m.editingTags = true
+				m.tagInput.Focus()
 			case "esc":
 				// Go back to deck selection
 				m.state = SelectingDeck
@@ -145,6 +262,34 @@ func (m *DeckListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateTagFilter handles typing the tag expression for the session
+func (m *DeckListModel) updateTagFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		filter, err := models.ParseTagFilter(m.tagInput.Value())
+		if err != nil {
+			m.tagError = err.Error()
+			return m, nil
+		}
+		m.tagFilter = filter
+		m.tagError = ""
+		m.editingTags = false
+		m.tagInput.Blur()
+		return m, nil
+	case "esc":
+		// Keep the filter that was already applied
+		m.tagInput.SetValue(m.tagFilter.String())
+		m.tagError = ""
+		m.editingTags = false
+		m.tagInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.tagInput, cmd = m.tagInput.Update(msg)
+	return m, cmd
+}
+
 // View implements tea.Model
 func (m *DeckListModel) View() string {
 	if m.width == 0 || m.height == 0 {
@@ -174,7 +319,7 @@ func (m *DeckListModel) viewDeckSelection() string {
 	// Create deck list
 	var deckItems []string
 
-	if len(m.decks) == 0 {
+	if len(m.rows) == 0 {
 		noDeckMsg := lipgloss.NewStyle().
 			Foreground(mutedColor).
 			Italic(true).
@@ -182,18 +327,41 @@ func (m *DeckListModel) viewDeckSelection() string {
 			Render("No decks found. Press 'n' to create a new deck.")
 		deckItems = []string{noDeckMsg}
 	} else {
-		for i, deck := range m.decks {
-			total, new, review := deck.GetCardStats()
-
-			// Create deck info
-			deckName := deck.Name
-			if deck.Description != "" {
-				deckName = fmt.Sprintf("%s - %s", deck.Name, deck.Description)
+		day := models.StudyDay(time.Now(), m.options.RolloverHour)
+		for i, node := range m.rows {
+			total, new, review := node.CardStats()
+			newLeft, reviewsLeft := node.Remaining(m.options.Limits, day)
+
+			// Create deck info, with an arrow on decks that have children
+			marker := "  "
+			if len(node.Children) > 0 {
+				marker = "▾ "
+				if m.collapsed[node.Name] {
+					marker = "▸ "
+				}
+			}
+			deckName := marker + node.Label()
+			if node.Deck != nil && node.Deck.Description != "" {
+				deckName = fmt.Sprintf("%s - %s", deckName, node.Deck.Description)
 			}
 
 			stats := fmt.Sprintf("Total: %d • New: %d • Review: %d", total, new, review)
+			allowance := fmt.Sprintf("Today: %d new • %d reviews left", newLeft, reviewsLeft)
+			if len(node.Children) == 0 {
+				allowance = fmt.Sprintf("Today: %d of %d new • %d of %d reviews left",
+					newLeft, m.options.Limits.NewCards, reviewsLeft, m.options.Limits.Reviews)
+			}
+			if node.Deck != nil && node.Deck.IsFiltered() {
+				// Filtered decks only hold the cards they borrowed
+				stats = fmt.Sprintf("Filtered • %d cards borrowed", len(node.Deck.BorrowedCards(m.decks)))
+				allowance = "Ratings reschedule cards • r: rebuild • x: empty"
+				if !node.Deck.Filter.Reschedule {
+					allowance = "Ratings do not reschedule cards • r: rebuild • x: empty"
+				}
+			}
 
-			// Style the item
+			// Style the item, indented under its parent
+			indent := node.Depth * 4
 			itemStyle := lipgloss.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(mutedColor).
@@ -201,8 +369,8 @@ func (m *DeckListModel) viewDeckSelection() string {
 				PaddingRight(2).
 				PaddingTop(1).
 				PaddingBottom(1).
-				Margin(0, 2, 1, 2).
-				Width(60)
+				Margin(0, 2, 1, 2+indent).
+				Width(60 - indent)
 
 			if i == m.selected {
 				itemStyle = itemStyle.
@@ -213,6 +381,7 @@ func (m *DeckListModel) viewDeckSelection() string {
 				lipgloss.Left,
 				lipgloss.NewStyle().Bold(true).Foreground(textColor).Render(deckName),
 				lipgloss.NewStyle().Foreground(mutedColor).Render(stats),
+				lipgloss.NewStyle().Foreground(mutedColor).Italic(true).Render(allowance),
 			)
 
 			deckItems = append(deckItems, itemStyle.Render(deckContent))
@@ -220,12 +389,12 @@ func (m *DeckListModel) viewDeckSelection() string {
 	}
 
 	// Join deck items
-	deckList := lipgloss.JoinVertical(lipgloss.Center, deckItems...)
+	deckList := lipgloss.JoinVertical(lipgloss.Left, deckItems...)
 
 	// Help text
 	var helpText string
-	if len(m.decks) > 0 {
-		helpText = "↑/↓ or j/k: navigate • Enter: select deck • e: edit • d: delete • n: new deck • Esc: back"
+	if len(m.rows) > 0 {
+		helpText = "↑/↓ or j/k: navigate • ←/→: collapse/expand • Enter: select deck • c: custom study • e: edit • d: delete • n: new deck • Esc: back"
 	} else {
 		helpText = "n: create new deck • Esc: back to menu"
 	}
@@ -249,9 +418,52 @@ func (m *DeckListModel) viewDeckSelection() string {
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
 
+// studyModes lists the study modes offered after choosing a deck
+var studyModes = []struct {
+	mode        models.StudyMode
+	name        string
+	description string
+}{
+	{models.ReviewMode, "📚 Review Mode", "Only cards due for review + new cards"},
+	{models.PracticeMode, "🔄 Practice Mode", "All cards for practice (ignores schedule)"},
+	{models.TypeAnswerMode, "⌨️  Type Answer Mode", "Type each answer and compare it with the card"},
+}
+
+// viewTagFilter renders the tag filter and how many of the decks' cards it matches
+func (m *DeckListModel) viewTagFilter(node *models.DeckNode) string {
+	if m.editingTags {
+		lines := []string{lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(secondaryColor).
+			Padding(0, 1).
+			Width(50).
+			Render("Tags: " + m.tagInput.View())}
+		if m.tagError != "" {
+			lines = append(lines, errorStyle.Render(m.tagError))
+		}
+		return lipgloss.JoinVertical(lipgloss.Center, lines...)
+	}
+
+	if m.tagFilter == nil {
+		return mutedTextStyle.Render("All cards")
+	}
+	matching, total := 0, 0
+	for _, deck := range node.Decks() {
+		for i := range deck.Cards {
+			if m.tagFilter.Matches(&deck.Cards[i]) {
+				matching++
+			}
+		}
+		total += len(deck.Cards)
+	}
+	return lipgloss.NewStyle().
+		Foreground(accentColor).
+		Render(fmt.Sprintf("Tags: %s (%d of %d cards)", m.tagFilter, matching, total))
+}
+
 // viewModeSelection renders the study mode selection screen
 func (m *DeckListModel) viewModeSelection() string {
-	selectedDeck := m.decks[m.selected]
+	node := m.rows[m.selected]
 
 	// Title
 	title := lipgloss.NewStyle().
@@ -259,19 +471,10 @@ func (m *DeckListModel) viewModeSelection() string {
 		Bold(true).
 		Align(lipgloss.Center).
 		PaddingBottom(1).
-		Render(fmt.Sprintf("Study Mode for: %s", selectedDeck.Name))
-
-	// Mode options
-	modes := []struct {
-		name        string
-		description string
-	}{
-		{"📚 Review Mode", "Only cards due for review + new cards"},
-		{"🔄 Practice Mode", "All cards for practice (ignores schedule)"},
-	}
+		Render(fmt.Sprintf("Study Mode for: %s", node.Name))
 
 	var modeItems []string
-	for i, mode := range modes {
+	for i, mode := range studyModes {
 		// Style the item
 		itemStyle := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -301,18 +504,23 @@ func (m *DeckListModel) viewModeSelection() string {
 	modeList := lipgloss.JoinVertical(lipgloss.Center, modeItems...)
 
 	// Help text
+	helpText := "↑/↓ or j/k: navigate • Enter: start study • /: filter by tags • Esc: back to deck list"
+	if m.editingTags {
+		helpText = "Tags joined with AND, OR, NOT and parentheses • Enter: apply • Esc: cancel"
+	}
 	help := lipgloss.NewStyle().
 		Foreground(mutedColor).
 		Italic(true).
 		Align(lipgloss.Center).
 		PaddingTop(2).
-		Render("↑/↓ or j/k: navigate • Enter: start study • Esc: back to deck list")
+		Render(helpText)
 
 	// Combine all elements
 	content := lipgloss.JoinVertical(
 		lipgloss.Center,
 		title,
 		modeList,
+		m.viewTagFilter(node),
 		help,
 	)
 
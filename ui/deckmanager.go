@@ -1,10 +1,14 @@
 package ui
 
 import (
+	"anktui/interop"
 	"anktui/models"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -18,6 +22,18 @@ const (
 	EditingDeck
 	DeletingDeck
 	ManagingCards
+	EnteringPath
+	ConfiguringImport
+	TransferSummary
+)
+
+// pathAction identifies what the file path prompt is collecting a path for
+type pathAction int
+
+const (
+	importPath pathAction = iota
+	exportDeckPath
+	exportAllPath
 )
 
 // DeckManagerModel represents the deck management screen
@@ -36,16 +52,33 @@ type DeckManagerModel struct {
 	// Confirmation
 	confirmingDelete bool
 
+	// Anki package and delimited text import and export
+	pathInput       textinput.Model
+	pathAction      pathAction
+	transferring    bool
+	csvImport       *CSVImportModel
+	importResult    *interop.ImportResult
+	delimitedResult *interop.DelimitedImportResult
+	importDeckName  string
+	exportResult    *interop.ExportResult
+	exportPath      string
+	transferErr     error
+
 	width  int
 	height int
 }
 
 // NewDeckManagerModel creates a new deck manager model
 func NewDeckManagerModel(decks []*models.Deck, editDeck *models.Deck) *DeckManagerModel {
+	pathInput := textinput.New()
+	pathInput.Placeholder = "~/deck.apkg or ~/cards.csv"
+	pathInput.Width = 46
+
 	m := &DeckManagerModel{
 		decks:        decks,
 		selectedDeck: 0,
 		state:        DeckManagerMenu,
+		pathInput:    pathInput,
 	}
 
 	// If a deck is passed for editing, go straight to edit mode
@@ -64,6 +97,9 @@ func NewDeckManagerModel(decks []*models.Deck, editDeck *models.Deck) *DeckManag
 func (m *DeckManagerModel) SetSize(width, height int) {
 	m.width = width
 	m.height = height
+	if m.csvImport != nil {
+		m.csvImport.SetSize(width, height)
+	}
 }
 
 // Init implements tea.Model
@@ -82,7 +118,61 @@ func (m *DeckManagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateForm(msg)
 		case DeletingDeck:
 			return m.updateDelete(msg)
+		case EnteringPath:
+			return m.updatePath(msg)
+		case ConfiguringImport:
+			return m.updateCSVImport(msg)
+		case TransferSummary:
+			// Any key dismisses the summary
+			m.state = DeckManagerMenu
+			m.importResult = nil
+			m.delimitedResult = nil
+			m.exportResult = nil
+			m.transferErr = nil
 		}
+	case DelimitedFileLoadedMsg:
+		m.transferring = false
+		if msg.Err != nil {
+			m.transferErr = msg.Err
+			m.state = TransferSummary
+			return m, nil
+		}
+
+		// Import into the selected deck, or a new deck named after the file
+		var deck *models.Deck
+		if len(m.decks) > 0 {
+			deck = m.decks[m.selectedDeck]
+		} else {
+			name := strings.TrimSuffix(filepath.Base(msg.Path), filepath.Ext(msg.Path))
+			deck = models.NewDeck(name, "Imported from "+filepath.Base(msg.Path))
+		}
+		m.csvImport = NewCSVImportModel(deck, msg.Path, msg.Text)
+		m.csvImport.SetSize(m.width, m.height)
+		m.state = ConfiguringImport
+	case DelimitedImportCompleteMsg:
+		m.transferring = false
+		m.csvImport = nil
+		m.delimitedResult = msg.Result
+		m.importDeckName = msg.DeckName
+		m.transferErr = msg.Err
+		m.state = TransferSummary
+		if msg.Decks != nil {
+			m.decks = msg.Decks
+		}
+	case ImportCompleteMsg:
+		m.transferring = false
+		m.importResult = msg.Result
+		m.transferErr = msg.Err
+		m.state = TransferSummary
+		if msg.Decks != nil {
+			m.decks = msg.Decks
+		}
+	case ExportCompleteMsg:
+		m.transferring = false
+		m.exportResult = msg.Result
+		m.exportPath = msg.Path
+		m.transferErr = msg.Err
+		m.state = TransferSummary
 	case DecksLoadedMsg:
 		// Handle successful deck operations
 		m.decks = msg.Decks
@@ -108,6 +198,11 @@ func (m *DeckManagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.selectedDeck = 0
 			}
 		}
+
+		// An undo or redo may have removed decks from the end of the list
+		if m.selectedDeck >= len(m.decks) {
+			m.selectedDeck = max(len(m.decks)-1, 0)
+		}
 	}
 
 	return m, nil
@@ -150,8 +245,8 @@ func (m *DeckManagerModel) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.confirmingDelete = false
 		}
 	case "c":
-		if len(m.decks) > 0 {
-			// Manage cards in selected deck
+		if len(m.decks) > 0 && !m.decks[m.selectedDeck].IsFiltered() {
+			// Manage cards in selected deck, which filtered decks only borrow
 			selectedDeck := m.decks[m.selectedDeck]
 			return m, func() tea.Msg {
 				return NavigateMsg{
@@ -160,6 +255,30 @@ func (m *DeckManagerModel) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 			}
 		}
+	case "i":
+		// Import an Anki package or delimited text file
+		return m, m.promptPath(importPath, "")
+	case "x":
+		if len(m.decks) > 0 {
+			// Export the selected deck as an Anki package
+			name := strings.NewReplacer("/", "-", "::", "-", " ", "_").Replace(m.decks[m.selectedDeck].Name)
+			return m, m.promptPath(exportDeckPath, "~/"+name+".apkg")
+		}
+	case "X":
+		if len(m.decks) > 0 {
+			// Export every deck as one Anki package
+			return m, m.promptPath(exportAllPath, "~/anktui.apkg")
+		}
+	case "u", "ctrl+z":
+		// Undo the latest edit of decks and cards
+		return m, func() tea.Msg {
+			return UndoEditMsg{}
+		}
+	case "ctrl+r", "ctrl+y":
+		// Redo the latest undone edit
+		return m, func() tea.Msg {
+			return RedoEditMsg{}
+		}
 	case "esc":
 		return m, func() tea.Msg {
 			return NavigateMsg{Screen: MenuScreen}
@@ -169,6 +288,97 @@ func (m *DeckManagerModel) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// promptPath switches to the file path prompt for the given action
+func (m *DeckManagerModel) promptPath(action pathAction, initial string) tea.Cmd {
+	m.state = EnteringPath
+	m.pathAction = action
+	m.pathInput.SetValue(initial)
+	m.pathInput.CursorEnd()
+	m.pathInput.Focus()
+	return textinput.Blink
+}
+
+// updatePath handles the import/export file path prompt
+func (m *DeckManagerModel) updatePath(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.transferring {
+		// Ignore input while the import or export is running
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "enter":
+		path := strings.TrimSpace(m.pathInput.Value())
+		if path == "" {
+			return m, nil
+		}
+		m.transferring = true
+		m.pathInput.Blur()
+
+		// The file extension picks the format
+		delimited := interop.IsDelimitedPath(path)
+
+		switch m.pathAction {
+		case exportDeckPath, exportAllPath:
+			decks := m.decks
+			if m.pathAction == exportDeckPath {
+				decks = []*models.Deck{m.decks[m.selectedDeck]}
+			}
+			return m, func() tea.Msg {
+				if delimited {
+					return ExportDelimitedMsg{Decks: decks, Path: path}
+				}
+				return ExportAPKGMsg{Decks: decks, Path: path}
+			}
+		default:
+			return m, func() tea.Msg {
+				if delimited {
+					return LoadDelimitedMsg{Path: path}
+				}
+				return ImportAPKGMsg{Path: path}
+			}
+		}
+	case "esc":
+		m.state = DeckManagerMenu
+		m.pathInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+// updateCSVImport handles the delimited text import dialog
+func (m *DeckManagerModel) updateCSVImport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.transferring {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "enter":
+		if !m.csvImport.Ready() {
+			return m, nil
+		}
+		m.transferring = true
+		deck, rows, options := m.csvImport.Deck(), m.csvImport.Rows(), m.csvImport.Options()
+		return m, func() tea.Msg {
+			return ImportDelimitedMsg{Deck: deck, Rows: rows, Options: options}
+		}
+	case "esc":
+		m.state = DeckManagerMenu
+		m.csvImport = nil
+		return m, nil
+	}
+
+	m.csvImport.Update(msg)
+	return m, nil
+}
+
+// CapturingText reports whether keystrokes are going into a text field
+func (m *DeckManagerModel) CapturingText() bool {
+	return m.state == CreatingDeck || m.state == EditingDeck || m.state == EnteringPath
+}
+
 // updateForm handles deck creation/editing form
 func (m *DeckManagerModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -186,18 +396,20 @@ func (m *DeckManagerModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil // Don't save without name
 		}
 
-		m.editingDeck.Name = m.nameInput
-		m.editingDeck.Description = m.descriptionInput
+		// Edit a copy, so the deck as it was can still be restored by undo
+		deck := *m.editingDeck
+		deck.Name = m.nameInput
+		deck.Description = m.descriptionInput
 
 		if m.isNewDeck {
 			// Create new deck
 			return m, func() tea.Msg {
-				return CreateDeckMsg{Deck: m.editingDeck}
+				return CreateDeckMsg{Deck: &deck}
 			}
 		} else {
 			// Update existing deck
 			return m, func() tea.Msg {
-				return UpdateDeckMsg{Deck: m.editingDeck}
+				return UpdateDeckMsg{Deck: &deck}
 			}
 		}
 	case "esc":
@@ -261,6 +473,12 @@ func (m *DeckManagerModel) View() string {
 		return m.viewForm("Edit Deck")
 	case DeletingDeck:
 		return m.viewDelete()
+	case EnteringPath:
+		return m.viewPath()
+	case ConfiguringImport:
+		return m.csvImport.View()
+	case TransferSummary:
+		return m.viewTransferSummary()
 	default:
 		return "Unknown state"
 	}
@@ -330,9 +548,9 @@ func (m *DeckManagerModel) viewMenu() string {
 	// Help text
 	var helpText string
 	if len(m.decks) > 0 {
-		helpText = "↑/↓: navigate • Enter/e: edit • c: manage cards • d: delete • n: new deck • Esc: back"
+		helpText = "↑/↓: navigate • Enter/e: edit • c: manage cards • d: delete • n: new deck • i: import • x/X: export deck/all • u/Ctrl+R: undo/redo • Esc: back"
 	} else {
-		helpText = "n: create new deck • Esc: back to menu"
+		helpText = "n: create new deck • i: import • u/Ctrl+R: undo/redo • Esc: back to menu"
 	}
 
 	help := lipgloss.NewStyle().
@@ -490,6 +708,148 @@ func (m *DeckManagerModel) viewDelete() string {
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
 
+// viewPath renders the import/export file path prompt
+func (m *DeckManagerModel) viewPath() string {
+	titleText, labelText, helpText, busyText := "Import Cards", "Path to .apkg, .csv or .tsv file:", "Enter: import • Esc: cancel", "Importing..."
+	if m.pathAction == importPath && len(m.decks) > 0 {
+		titleText = fmt.Sprintf("Import Cards (text files go into '%s')", m.decks[m.selectedDeck].Name)
+	}
+	switch m.pathAction {
+	case exportDeckPath:
+		titleText = fmt.Sprintf("Export '%s'", m.decks[m.selectedDeck].Name)
+		labelText, helpText, busyText = "Save .apkg, .csv or .tsv to:", "Enter: export • Esc: cancel", "Exporting..."
+	case exportAllPath:
+		titleText = fmt.Sprintf("Export All %d Decks", len(m.decks))
+		labelText, helpText, busyText = "Save .apkg, .csv or .tsv to:", "Enter: export • Esc: cancel", "Exporting..."
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render(titleText)
+
+	label := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(textColor).
+		PaddingBottom(1).
+		Render(labelText)
+
+	field := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		PaddingLeft(1).
+		PaddingRight(1).
+		Width(50).
+		Render(m.pathInput.View())
+
+	if m.transferring {
+		helpText = busyText
+	}
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(2).
+		Render(helpText)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		lipgloss.JoinVertical(lipgloss.Left, label, field),
+		help,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewTransferSummary renders the outcome of an import or export
+func (m *DeckManagerModel) viewTransferSummary() string {
+	var title string
+	var lines []string
+
+	if m.transferErr != nil {
+		title = errorStyle.Render("Failed")
+		lines = append(lines, textStyle.Render(m.transferErr.Error()))
+	} else if m.exportResult != nil {
+		result := m.exportResult
+		title = successStyle.Render("Export Complete")
+		lines = append(lines,
+			textStyle.Render(fmt.Sprintf("Exported %d cards from %d decks to %s", result.Cards, result.Decks, m.exportPath)))
+		if !interop.IsDelimitedPath(m.exportPath) {
+			lines = append(lines,
+				mutedTextStyle.Render(fmt.Sprintf("Included %d reviews and %d media files", result.Reviews, result.MediaFiles)))
+		}
+	} else if m.delimitedResult != nil {
+		result := m.delimitedResult
+		title = successStyle.Render("Import Complete")
+		lines = append(lines, textStyle.Render(fmt.Sprintf(
+			"Added %d cards to '%s'", result.Added, m.importDeckName)))
+		if result.Updated > 0 {
+			lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("Updated %d existing cards", result.Updated)))
+		}
+		if result.Skipped > 0 {
+			lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("Skipped %d duplicates", result.Skipped)))
+		}
+
+		// Show the first few problem rows
+		if len(result.Errors) > 0 {
+			lines = append(lines, "", emphasisStyle.Render(fmt.Sprintf("Could not import %d rows:", len(result.Errors))))
+			for _, rowErr := range result.Errors[:min(len(result.Errors), 5)] {
+				lines = append(lines, mutedTextStyle.Render("  "+rowErr))
+			}
+			if len(result.Errors) > 5 {
+				lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("  ...and %d more", len(result.Errors)-5)))
+			}
+		}
+	} else if m.importResult != nil {
+		result := m.importResult
+		title = successStyle.Render("Import Complete")
+		lines = append(lines, textStyle.Render(fmt.Sprintf(
+			"Imported %d cards from %d notes into %d decks",
+			result.CardsImported, result.NotesImported, len(result.Decks))))
+		if result.MediaFiles > 0 {
+			lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("Copied %d media files", result.MediaFiles)))
+		}
+
+		if skipped := result.SkippedNotes(); skipped > 0 {
+			lines = append(lines, "", emphasisStyle.Render(fmt.Sprintf("Skipped %d notes with unsupported note types:", skipped)))
+			var names []string
+			for name := range result.SkippedNoteTypes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("  %s: %d", name, result.SkippedNoteTypes[name])))
+			}
+		}
+		if result.SkippedCards > 0 {
+			lines = append(lines, "", emphasisStyle.Render(fmt.Sprintf("Skipped %d cards whose question was empty", result.SkippedCards)))
+		}
+
+		for _, warning := range result.Warnings {
+			lines = append(lines, emphasisStyle.Render("Warning: "+warning))
+		}
+	}
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(2).
+		Render("Press any key to continue")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		lipgloss.NewStyle().PaddingBottom(2).Render(title),
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
+		help,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
 // wrapText wraps text to the specified width
 func (m *DeckManagerModel) wrapText(text string, width int) string {
 	if len(text) <= width {
@@ -534,3 +894,61 @@ type UpdateDeckMsg struct {
 type DeleteDeckMsg struct {
 	Deck *models.Deck
 }
+
+// ImportAPKGMsg requests an import of the Anki package at Path
+type ImportAPKGMsg struct {
+	Path string
+}
+
+// ExportAPKGMsg requests an export of Decks to an Anki package at Path
+type ExportAPKGMsg struct {
+	Decks []*models.Deck
+	Path  string
+}
+
+// ExportCompleteMsg reports the result of an export
+type ExportCompleteMsg struct {
+	Result *interop.ExportResult
+	Path   string
+	Err    error
+}
+
+// ImportCompleteMsg reports the result of an import along with the refreshed decks
+type ImportCompleteMsg struct {
+	Result *interop.ImportResult
+	Decks  []*models.Deck
+	Err    error
+}
+
+// LoadDelimitedMsg requests the contents of the delimited text file at Path
+type LoadDelimitedMsg struct {
+	Path string
+}
+
+// DelimitedFileLoadedMsg carries a delimited text file's contents for the import dialog
+type DelimitedFileLoadedMsg struct {
+	Path string
+	Text string
+	Err  error
+}
+
+// ImportDelimitedMsg requests an import of parsed rows into Deck
+type ImportDelimitedMsg struct {
+	Deck    *models.Deck
+	Rows    [][]string
+	Options interop.DelimitedOptions
+}
+
+// DelimitedImportCompleteMsg reports the result of a delimited text import along with the refreshed decks
+type DelimitedImportCompleteMsg struct {
+	Result   *interop.DelimitedImportResult
+	DeckName string
+	Decks    []*models.Deck
+	Err      error
+}
+
+// ExportDelimitedMsg requests an export of Decks to a delimited text file at Path
+type ExportDelimitedMsg struct {
+	Decks []*models.Deck
+	Path  string
+}
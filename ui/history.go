@@ -0,0 +1,245 @@
+package ui
+
+import (
+	"anktui/models"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxEditHistory is how many edits can be undone
+const maxEditHistory = 100
+
+// toastDuration is how long a toast stays at the bottom of the screen
+const toastDuration = 3 * time.Second
+
+// deckChange is a deck as it was before and after an edit. Before is nil for a deck the
+// edit created and after is nil for one it deleted
+type deckChange struct {
+	id     string
+	before *models.Deck
+	after  *models.Deck
+}
+
+// states returns the state the deck should be in now and the one to save, which are after
+// and before the edit when undoing it and the other way around when redoing it
+func (c deckChange) states(undo bool) (current, target *models.Deck) {
+	if undo {
+		return c.after, c.before
+	}
+	return c.before, c.after
+}
+
+// editOperation is an edit of decks and cards, undone and redone by saving the decks it
+// changed as they were on either side of it
+type editOperation struct {
+	description string // What the edit did, as in "deleting deck Spanish"
+	changes     []deckChange
+}
+
+// check returns an error when a deck the edit changed has been changed again since, as
+// undoing or redoing the edit would silently throw that change away
+func (op *editOperation) check(decks []*models.Deck, undo bool) error {
+	for _, change := range op.changes {
+		expected, _ := change.states(undo)
+		current := deckWithID(decks, change.id)
+		switch {
+		case expected == nil && current != nil:
+			return fmt.Errorf("%s exists again", current.Name)
+		case expected != nil && current == nil:
+			return fmt.Errorf("%s has been deleted since", expected.Name)
+		case expected != nil && !current.Modified.Equal(expected.Modified):
+			return fmt.Errorf("%s has changed since", expected.Name)
+		}
+	}
+	return nil
+}
+
+// editHistory keeps the edits that can be undone, latest last, and the undone edits that
+// can be redone
+type editHistory struct {
+	undo      []*editOperation
+	redo      []*editOperation
+	replaying bool // An undo or redo is being saved
+}
+
+// record adds a new edit, which makes the undone edits impossible to redo
+func (h *editHistory) record(op *editOperation) {
+	h.undo = append(h.undo, op)
+	if len(h.undo) > maxEditHistory {
+		h.undo = h.undo[len(h.undo)-maxEditHistory:]
+	}
+	h.redo = nil
+}
+
+// pop takes the latest edit to undo or redo, or returns nil when there is none
+func (h *editHistory) pop(undo bool) *editOperation {
+	stack := &h.redo
+	if undo {
+		stack = &h.undo
+	}
+	if len(*stack) == 0 {
+		return nil
+	}
+	op := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+	return op
+}
+
+// push puts back an edit that was undone, so it can be redone, or redone, so it can be
+// undone again
+func (h *editHistory) push(op *editOperation, undone bool) {
+	if undone {
+		h.redo = append(h.redo, op)
+	} else {
+		h.undo = append(h.undo, op)
+	}
+}
+
+// recordEdit makes an edit of decks and cards and records it so it can be undone. touched
+// are the decks the edit changes, as they are before it; edit changes and saves them and
+// returns the IDs of any decks it creates
+func (a *App) recordEdit(description string, touched []*models.Deck, edit func() ([]string, error)) tea.Cmd {
+	op := &editOperation{description: description}
+	for _, deck := range touched {
+		op.changes = append(op.changes, deckChange{id: deck.ID, before: deck.Clone()})
+	}
+	return a.write(func() tea.Msg {
+		created, err := edit()
+		if err != nil {
+			return ErrorMsg{err}
+		}
+		for _, id := range created {
+			op.changes = append(op.changes, deckChange{id: id})
+		}
+		// Reload decks to refresh the data, keeping their new state for redoing the edit
+		decks, err := a.storage.LoadAllDecks()
+		if err != nil {
+			return ErrorMsg{err}
+		}
+		for i := range op.changes {
+			if deck := deckWithID(decks, op.changes[i].id); deck != nil {
+				op.changes[i].after = deck.Clone()
+			}
+		}
+		return EditRecordedMsg{Edit: op, Decks: decks}
+	})
+}
+
+// replayEdit undoes the latest edit, or redoes the latest undone one
+func (a *App) replayEdit(undo bool) tea.Cmd {
+	if a.history.replaying {
+		return nil
+	}
+	op := a.history.pop(undo)
+	if op == nil {
+		if undo {
+			return a.showToast("Nothing to undo")
+		}
+		return a.showToast("Nothing to redo")
+	}
+	if err := op.check(a.decks, undo); err != nil {
+		// The edit can't be replayed any more, so it is dropped
+		return a.showToast(fmt.Sprintf("Can't %s %s: %s", replayVerb(undo), op.description, err))
+	}
+
+	a.history.replaying = true
+	return a.write(func() tea.Msg {
+		for _, change := range op.changes {
+			_, target := change.states(undo)
+			var err error
+			if target == nil {
+				err = a.storage.DeleteDeck(change.id)
+			} else {
+				err = a.storage.SaveDeck(target.Clone())
+			}
+			if err != nil {
+				return EditReplayedMsg{Edit: op, Undo: undo, Err: err}
+			}
+		}
+		decks, err := a.storage.LoadAllDecks()
+		return EditReplayedMsg{Edit: op, Undo: undo, Decks: decks, Err: err}
+	})
+}
+
+// showToast shows a short notice at the bottom of the screen until it expires
+func (a *App) showToast(text string) tea.Cmd {
+	a.toastID++
+	a.toast = text
+	id := a.toastID
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return ToastExpiredMsg{ID: id}
+	})
+}
+
+// replayVerb names undoing or redoing
+func replayVerb(undo bool) string {
+	if undo {
+		return "undo"
+	}
+	return "redo"
+}
+
+// deckWithID returns the deck with the given ID, or nil
+func deckWithID(decks []*models.Deck, id string) *models.Deck {
+	for _, deck := range decks {
+		if deck.ID == id {
+			return deck
+		}
+	}
+	return nil
+}
+
+// cardCount formats a number of cards
+func cardCount(n int) string {
+	if n == 1 {
+		return "1 card"
+	}
+	return fmt.Sprintf("%d cards", n)
+}
+
+// describeBrowserAction says what a browser action does, for the edit history
+func describeBrowserAction(msg BrowserActionMsg) string {
+	cards := cardCount(len(msg.Cards))
+	switch msg.Action {
+	case BrowserMove:
+		return fmt.Sprintf("moving %s to %s", cards, msg.To.Name)
+	case BrowserTag:
+		return "tagging " + cards
+	case BrowserSuspend:
+		if msg.Suspend {
+			return "suspending " + cards
+		}
+		return "unsuspending " + cards
+	case BrowserReset:
+		return "resetting " + cards
+	default:
+		return "deleting " + cards
+	}
+}
+
+// UndoEditMsg requests undoing the latest edit of decks and cards
+type UndoEditMsg struct{}
+
+// RedoEditMsg requests redoing the latest undone edit
+type RedoEditMsg struct{}
+
+// EditRecordedMsg reports an edit that was saved and can now be undone
+type EditRecordedMsg struct {
+	Edit  *editOperation
+	Decks []*models.Deck // Reloaded decks
+}
+
+// EditReplayedMsg reports an edit that was undone or redone
+type EditReplayedMsg struct {
+	Edit  *editOperation
+	Undo  bool
+	Decks []*models.Deck // Reloaded decks, nil when saving failed
+	Err   error
+}
+
+// ToastExpiredMsg removes a toast once it has been shown long enough
+type ToastExpiredMsg struct {
+	ID int
+}
@@ -1,6 +1,11 @@
 package ui
 
 import (
+	"anktui/models"
+	"anktui/storage"
+	"fmt"
+	"path/filepath"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -16,6 +21,7 @@ type MenuOption struct {
 type MenuModel struct {
 	options  []MenuOption
 	selected int
+	warnings []storage.LoadWarning // Decks that could not be loaded
 	width    int
 	height   int
 }
@@ -31,6 +37,16 @@ func NewMenuModel() *MenuModel {
 					return NavigateMsg{Screen: DeckListScreen}
 				},
 			},
+			{
+				Label:       "Study All Due",
+				Description: "Review the due cards of every deck in one session",
+				Action: func() tea.Msg {
+					return NavigateMsg{
+						Screen: StudyScreen,
+						Data:   &StudyRequest{Name: "All Decks", Mode: models.ReviewMode, All: true},
+					}
+				},
+			},
 			{
 				Label:       "Manage Decks",
 				Description: "Create, edit, and organize your decks",
@@ -38,12 +54,32 @@ func NewMenuModel() *MenuModel {
 					return NavigateMsg{Screen: DeckManagerScreen}
 				},
 			},
+			{
+				Label:       "Browse Cards",
+				Description: "Search, sort and edit cards across all decks",
+				Action: func() tea.Msg {
+					return NavigateMsg{Screen: BrowserScreen}
+				},
+			},
+			{
+				Label:       "Note Types",
+				Description: "Define fields and card templates for your notes",
+				Action: func() tea.Msg {
+					return NavigateMsg{Screen: NoteTypesScreen}
+				},
+			},
 			{
 				Label:       "Statistics",
 				Description: "View your learning progress",
 				Action: func() tea.Msg {
-					// TODO: Implement statistics screen
-					return NavigateMsg{Screen: MenuScreen}
+					return NavigateMsg{Screen: StatsScreen}
+				},
+			},
+			{
+				Label:       "Backups",
+				Description: "Create and restore snapshots of your data",
+				Action: func() tea.Msg {
+					return NavigateMsg{Screen: BackupScreen}
 				},
 			},
 			{
@@ -64,6 +100,11 @@ func (m *MenuModel) SetSize(width, height int) {
 	m.height = height
 }
 
+// SetWarnings sets the decks that could not be loaded
+func (m *MenuModel) SetWarnings(warnings []storage.LoadWarning) {
+	m.warnings = warnings
+}
+
 // Init implements tea.Model
 func (m *MenuModel) Init() tea.Cmd {
 	return nil
@@ -84,6 +125,16 @@ func (m *MenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "enter", " ":
 			return m, m.options[m.selected].Action
+		case "r":
+			if len(m.warnings) > 0 {
+				// Try loading again, e.g. after fixing or restoring the files
+				return m, func() tea.Msg {
+					return ReloadDecksMsg{}
+				}
+			}
+		case "x":
+			// Dismiss the warnings until the next load
+			m.warnings = nil
 		}
 	}
 
@@ -133,22 +184,48 @@ func (m *MenuModel) View() string {
 		Render(m.options[m.selected].Description)
 
 	// Add help text
+	helpText := "Use ↑/↓ arrows or j/k to navigate • Enter to select • q to quit"
+	if len(m.warnings) > 0 {
+		helpText += " • r: retry loading • x: dismiss"
+	}
 	help := lipgloss.NewStyle().
 		Foreground(mutedColor).
 		Italic(true).
 		Align(lipgloss.Center).
 		PaddingTop(4).
-		Render("Use ↑/↓ arrows or j/k to navigate • Enter to select • q to quit")
+		Render(helpText)
 
 	// Combine all elements
-	content := lipgloss.JoinVertical(
-		lipgloss.Center,
-		asciiArt,
-		menu,
-		description,
-		help,
-	)
+	sections := []string{asciiArt, menu, description}
+	if len(m.warnings) > 0 {
+		sections = append(sections, m.viewWarnings())
+	}
+	sections = append(sections, help)
+	content := lipgloss.JoinVertical(lipgloss.Center, sections...)
 
 	// Center everything in the terminal
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
+
+// viewWarnings renders the list of decks that could not be loaded
+func (m *MenuModel) viewWarnings() string {
+	heading := fmt.Sprintf("⚠ %d decks could not be loaded and were skipped:", len(m.warnings))
+	if len(m.warnings) == 1 {
+		heading = "⚠ 1 deck could not be loaded and was skipped:"
+	}
+	lines := []string{emphasisStyle.Render(heading)}
+	for _, warning := range m.warnings {
+		lines = append(lines, mutedTextStyle.Render(fmt.Sprintf("  %s: %v", filepath.Base(warning.Path), warning.Err)))
+	}
+	lines = append(lines, mutedTextStyle.Render("They were left untouched. Repair or restore them, then press r."))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(0, 2).
+		MarginTop(2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// ReloadDecksMsg asks the app to load every deck from storage again
+type ReloadDecksMsg struct{}
@@ -0,0 +1,552 @@
+package ui
+
+import (
+	"anktui/models"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// NoteTypesState represents the current state of the note types screen
+type NoteTypesState int
+
+const (
+	NoteTypeList NoteTypesState = iota
+	NoteTypeForm
+	NoteTypeDeleteConfirm
+)
+
+// Note type form fields, followed by the current template's name, front, back and answer field
+const (
+	noteTypeNameField = iota
+	noteTypeFieldsField
+	noteTypeTemplateNameField
+	noteTypeFrontField
+	noteTypeBackField
+	noteTypeAnswerField
+)
+
+// NoteTypesModel represents the screen for creating and editing custom note types
+type NoteTypesModel struct {
+	noteTypes []*models.CustomNoteType
+	decks     []*models.Deck // To count the notes using each type
+	selected  int
+	state     NoteTypesState
+	editing   *models.CustomNoteType // nil while creating a note type
+	formError string
+	listError string
+
+	// Form fields
+	nameInput     textinput.Model
+	fieldsInput   textinput.Model
+	templates     []models.CardTemplate
+	template      int // Template shown in the form
+	templateInput textinput.Model
+	frontArea     textarea.Model
+	backArea      textarea.Model
+	answerInput   textinput.Model
+	currentField  int
+
+	width  int
+	height int
+}
+
+// NewNoteTypesModel creates a new note types model
+func NewNoteTypesModel(noteTypes []*models.CustomNoteType, decks []*models.Deck) *NoteTypesModel {
+	nameInput := textinput.New()
+	nameInput.Placeholder = "Vocabulary"
+	nameInput.Width = 56
+
+	fieldsInput := textinput.New()
+	fieldsInput.Placeholder = "Word, Reading, Meaning, Example"
+	fieldsInput.Width = 56
+
+	templateInput := textinput.New()
+	templateInput.Placeholder = "Recognition"
+	templateInput.Width = 56
+
+	frontArea := textarea.New()
+	frontArea.Placeholder = "{{Word}}"
+	frontArea.SetWidth(60)
+	frontArea.SetHeight(3)
+
+	backArea := textarea.New()
+	backArea.Placeholder = "{{FrontSide}}\n\n{{Meaning}}{{#Example}}\n\n_{{Example}}_{{/Example}}"
+	backArea.SetWidth(60)
+	backArea.SetHeight(4)
+
+	answerInput := textinput.New()
+	answerInput.Placeholder = "Meaning"
+	answerInput.Width = 56
+
+	return &NoteTypesModel{
+		noteTypes:     noteTypes,
+		decks:         decks,
+		state:         NoteTypeList,
+		nameInput:     nameInput,
+		fieldsInput:   fieldsInput,
+		templateInput: templateInput,
+		frontArea:     frontArea,
+		backArea:      backArea,
+		answerInput:   answerInput,
+	}
+}
+
+// SetSize sets the terminal size
+func (m *NoteTypesModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// UpdateNoteTypes replaces the listed note types after they were saved or deleted
+func (m *NoteTypesModel) UpdateNoteTypes(noteTypes []*models.CustomNoteType) {
+	m.noteTypes = noteTypes
+	if m.selected >= len(m.noteTypes) {
+		m.selected = max(len(m.noteTypes)-1, 0)
+	}
+}
+
+// Init implements tea.Model
+func (m *NoteTypesModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *NoteTypesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.state {
+		case NoteTypeList:
+			return m.updateList(msg)
+		case NoteTypeForm:
+			return m.updateForm(msg)
+		case NoteTypeDeleteConfirm:
+			return m.updateDelete(msg)
+		}
+	case DecksLoadedMsg:
+		// The note type was saved or deleted and the decks using it updated
+		m.decks = msg.Decks
+		m.state = NoteTypeList
+	}
+	return m, nil
+}
+
+// CapturingText reports whether keystrokes are going into a text field
+func (m *NoteTypesModel) CapturingText() bool {
+	return m.state == NoteTypeForm
+}
+
+// noteCount returns how many notes across all decks use a note type
+func (m *NoteTypesModel) noteCount(id models.NoteType) int {
+	count := 0
+	for _, deck := range m.decks {
+		count += deck.CountNotesOfType(id)
+	}
+	return count
+}
+
+// updateList handles the list of note types
+func (m *NoteTypesModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.listError = ""
+	switch msg.String() {
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j":
+		if m.selected < len(m.noteTypes)-1 {
+			m.selected++
+		}
+	case "n":
+		// Start from a front and back type with one card
+		m.editing = nil
+		m.openForm("", []string{"Front", "Back"}, []models.CardTemplate{
+			{Ord: 1, Name: "Card 1", Front: "{{Front}}", Back: "{{FrontSide}}\n\n---\n\n{{Back}}"},
+		})
+	case "e", "enter":
+		if len(m.noteTypes) > 0 {
+			noteType := m.noteTypes[m.selected]
+			m.editing = noteType
+			m.openForm(noteType.Name, noteType.Fields, noteType.Templates)
+		}
+	case "d":
+		if len(m.noteTypes) > 0 {
+			noteType := m.noteTypes[m.selected]
+			if count := m.noteCount(noteType.ID); count > 0 {
+				m.listError = fmt.Sprintf("%s is used by %d notes and cannot be deleted", noteType.Name, count)
+				return m, nil
+			}
+			m.state = NoteTypeDeleteConfirm
+		}
+	case "esc":
+		return m, func() tea.Msg {
+			return NavigateMsg{Screen: MenuScreen}
+		}
+	}
+	return m, nil
+}
+
+// openForm shows the form filled with a note type's settings
+func (m *NoteTypesModel) openForm(name string, fields []string, templates []models.CardTemplate) {
+	m.state = NoteTypeForm
+	m.formError = ""
+	m.nameInput.SetValue(name)
+	m.fieldsInput.SetValue(strings.Join(fields, ", "))
+	m.templates = slices.Clone(templates)
+	m.loadTemplate(0)
+	m.focusField(noteTypeNameField)
+}
+
+// showTemplate keeps the edits to the current template and shows another one
+func (m *NoteTypesModel) showTemplate(index int) {
+	m.storeTemplate()
+	m.loadTemplate(index)
+}
+
+// loadTemplate fills the form's template fields from a template
+func (m *NoteTypesModel) loadTemplate(index int) {
+	m.template = index
+	template := m.templates[index]
+	m.templateInput.SetValue(template.Name)
+	m.frontArea.SetValue(template.Front)
+	m.backArea.SetValue(template.Back)
+	m.answerInput.SetValue(template.AnswerField)
+}
+
+// storeTemplate copies the form's template fields into the template being edited
+func (m *NoteTypesModel) storeTemplate() {
+	m.templates[m.template] = models.CardTemplate{
+		Ord:         m.templates[m.template].Ord,
+		Name:        strings.TrimSpace(m.templateInput.Value()),
+		Front:       m.frontArea.Value(),
+		Back:        m.backArea.Value(),
+		AnswerField: strings.TrimSpace(m.answerInput.Value()),
+	}
+}
+
+// focusField moves the cursor to a form field
+func (m *NoteTypesModel) focusField(field int) {
+	m.currentField = field
+	m.nameInput.Blur()
+	m.fieldsInput.Blur()
+	m.templateInput.Blur()
+	m.frontArea.Blur()
+	m.backArea.Blur()
+	m.answerInput.Blur()
+	switch field {
+	case noteTypeNameField:
+		m.nameInput.Focus()
+	case noteTypeFieldsField:
+		m.fieldsInput.Focus()
+	case noteTypeTemplateNameField:
+		m.templateInput.Focus()
+	case noteTypeFrontField:
+		m.frontArea.Focus()
+	case noteTypeBackField:
+		m.backArea.Focus()
+	case noteTypeAnswerField:
+		m.answerInput.Focus()
+	}
+}
+
+// updateForm handles creating and editing a note type
+func (m *NoteTypesModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab":
+		if m.currentField < noteTypeAnswerField {
+			m.focusField(m.currentField + 1)
+		}
+		return m, nil
+	case "shift+tab":
+		if m.currentField > noteTypeNameField {
+			m.focusField(m.currentField - 1)
+		}
+		return m, nil
+	case "pgdown":
+		if m.template < len(m.templates)-1 {
+			m.showTemplate(m.template + 1)
+		}
+		return m, nil
+	case "pgup":
+		if m.template > 0 {
+			m.showTemplate(m.template - 1)
+		}
+		return m, nil
+	case "ctrl+n":
+		// Add a card template, starting from the first field
+		m.storeTemplate()
+		fields, _ := parseFieldNames(m.fieldsInput.Value())
+		template := models.CardTemplate{Ord: models.NextTemplateOrd(m.templates), Name: fmt.Sprintf("Card %d", len(m.templates)+1)}
+		if len(fields) > 0 {
+			template.Front = "{{" + fields[0] + "}}"
+		}
+		m.templates = append(m.templates, template)
+		m.loadTemplate(len(m.templates) - 1)
+		m.focusField(noteTypeFrontField)
+		return m, nil
+	case "ctrl+x":
+		// Remove the current card template, keeping at least one
+		if len(m.templates) > 1 {
+			m.templates = slices.Delete(m.templates, m.template, m.template+1)
+			m.loadTemplate(min(m.template, len(m.templates)-1))
+		}
+		return m, nil
+	case "ctrl+s":
+		return m.save()
+	case "esc":
+		m.state = NoteTypeList
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch m.currentField {
+	case noteTypeNameField:
+		m.nameInput, cmd = m.nameInput.Update(msg)
+	case noteTypeFieldsField:
+		m.fieldsInput, cmd = m.fieldsInput.Update(msg)
+	case noteTypeTemplateNameField:
+		m.templateInput, cmd = m.templateInput.Update(msg)
+	case noteTypeFrontField:
+		m.frontArea, cmd = m.frontArea.Update(msg)
+	case noteTypeBackField:
+		m.backArea, cmd = m.backArea.Update(msg)
+	case noteTypeAnswerField:
+		m.answerInput, cmd = m.answerInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// save validates the form and asks for the note type to be saved
+func (m *NoteTypesModel) save() (tea.Model, tea.Cmd) {
+	m.storeTemplate()
+
+	fields, renames := parseFieldNames(m.fieldsInput.Value())
+	for field, from := range renames {
+		if m.editing == nil || !slices.Contains(m.editing.Fields, from) {
+			m.formError = fmt.Sprintf("%s -> %s: %s is not a field of the note type", from, field, from)
+			return m, nil
+		}
+	}
+	noteType := models.NewCustomNoteType(strings.TrimSpace(m.nameInput.Value()), fields, slices.Clone(m.templates))
+	if m.editing != nil {
+		noteType.ID = m.editing.ID
+		noteType.Created = m.editing.Created
+	}
+	noteType.Modified = time.Now()
+	if err := noteType.Validate(); err != nil {
+		m.formError = err.Error()
+		return m, nil
+	}
+	for _, other := range m.noteTypes {
+		if other.ID != noteType.ID && strings.EqualFold(other.Name, noteType.Name) {
+			m.formError = fmt.Sprintf("there is already a note type called %s", other.Name)
+			return m, nil
+		}
+	}
+
+	return m, func() tea.Msg {
+		return SaveNoteTypeMsg{NoteType: noteType, Renames: renames}
+	}
+}
+
+// parseFieldNames splits a comma separated list of field names. A field written as
+// "Old -> New" renames Old, and the renames are returned keyed by the new name
+func parseFieldNames(text string) ([]string, map[string]string) {
+	var fields []string
+	renames := make(map[string]string)
+	for _, field := range strings.Split(text, ",") {
+		if from, to, ok := strings.Cut(field, "->"); ok {
+			from, field = strings.TrimSpace(from), strings.TrimSpace(to)
+			if from != "" && field != "" && from != field {
+				renames[field] = from
+			}
+		}
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields, renames
+}
+
+// updateDelete handles note type deletion confirmation
+func (m *NoteTypesModel) updateDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		id := m.noteTypes[m.selected].ID
+		return m, func() tea.Msg {
+			return DeleteNoteTypeMsg{ID: id}
+		}
+	case "n", "N", "esc":
+		m.state = NoteTypeList
+	}
+	return m, nil
+}
+
+// View implements tea.Model
+func (m *NoteTypesModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return "Loading..."
+	}
+
+	switch m.state {
+	case NoteTypeForm:
+		return m.viewForm()
+	case NoteTypeDeleteConfirm:
+		return m.viewDelete()
+	default:
+		return m.viewList()
+	}
+}
+
+// viewList renders the note types with their fields and cards
+func (m *NoteTypesModel) viewList() string {
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render("Note Types")
+
+	var items []string
+	if len(m.noteTypes) == 0 {
+		items = append(items, lipgloss.NewStyle().
+			Foreground(mutedColor).
+			Italic(true).
+			Align(lipgloss.Center).
+			Render("No custom note types yet. Press 'n' to create one.\nBasic, reversed and cloze notes are always available."))
+	}
+	for i, noteType := range m.noteTypes {
+		var templates []string
+		for _, template := range noteType.Templates {
+			templates = append(templates, template.Name)
+		}
+		details := fmt.Sprintf("Fields: %s\nCards: %s • %d notes",
+			strings.Join(noteType.Fields, ", "), strings.Join(templates, ", "), m.noteCount(noteType.ID))
+
+		itemStyle := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(mutedColor).
+			PaddingLeft(2).
+			PaddingRight(2).
+			Margin(0, 2, 1, 2).
+			Width(60)
+		if i == m.selected {
+			itemStyle = itemStyle.BorderForeground(primaryColor)
+		}
+
+		items = append(items, itemStyle.Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			lipgloss.NewStyle().Bold(true).Foreground(textColor).Render(noteType.Name),
+			lipgloss.NewStyle().Foreground(mutedColor).Render(details),
+		)))
+	}
+
+	sections := []string{title, lipgloss.JoinVertical(lipgloss.Center, items...)}
+	if m.listError != "" {
+		sections = append(sections, errorStyle.Render(m.listError))
+	}
+
+	helpText := "n: new note type • Esc: back to menu"
+	if len(m.noteTypes) > 0 {
+		helpText = "↑/↓: navigate • Enter/e: edit • n: new • d: delete • Esc: back to menu"
+	}
+	sections = append(sections, lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render(helpText))
+
+	content := lipgloss.JoinVertical(lipgloss.Center, sections...)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewForm renders the note type creation/editing form
+func (m *NoteTypesModel) viewForm() string {
+	titleText := "Create Note Type"
+	if m.editing != nil {
+		titleText = "Edit Note Type"
+	}
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render(titleText)
+
+	label := func(text string) string {
+		return lipgloss.NewStyle().Bold(true).Foreground(textColor).PaddingTop(1).Render(text)
+	}
+	templateTitle := lipgloss.NewStyle().
+		Foreground(secondaryColor).
+		Bold(true).
+		PaddingTop(1).
+		Render(fmt.Sprintf("Card template %d of %d", m.template+1, len(m.templates)))
+
+	fields := []string{
+		label("Name:"), m.nameInput.View(),
+		label("Fields (comma separated, Old -> New renames a field):"), m.fieldsInput.View(),
+		templateTitle,
+		label("Template name:"), m.templateInput.View(),
+		label("Front template:"), m.frontArea.View(),
+		label("Back template ({{FrontSide}} shows the front):"), m.backArea.View(),
+		label("Answer field (typed answers are compared with it, empty for the back):"), m.answerInput.View(),
+	}
+	if m.formError != "" {
+		fields = append(fields, errorStyle.PaddingTop(1).Render(m.formError))
+	}
+	form := lipgloss.JoinVertical(lipgloss.Left, fields...)
+
+	syntax := mutedTextStyle.Render("{{Field}} • {{#Field}}shown if not empty{{/Field}} • {{^Field}}shown if empty{{/Field}}")
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render("Tab: switch fields • PgUp/PgDn: switch template • Ctrl+N: add template • Ctrl+X: remove template • Ctrl+S: save • Esc: cancel")
+
+	content := lipgloss.JoinVertical(lipgloss.Center, title, form, syntax, help)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewDelete renders the deletion confirmation
+func (m *NoteTypesModel) viewDelete() string {
+	title := lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render("⚠️  Delete Note Type")
+
+	warning := lipgloss.NewStyle().
+		Foreground(textColor).
+		Align(lipgloss.Center).
+		PaddingBottom(3).
+		Render(fmt.Sprintf("Are you sure you want to delete '%s'?", m.noteTypes[m.selected].Name))
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		Render("Y: confirm deletion • N/Esc: cancel")
+
+	content := lipgloss.JoinVertical(lipgloss.Center, title, warning, help)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// SaveNoteTypeMsg requests saving a new or edited note type and re-rendering its notes
+type SaveNoteTypeMsg struct {
+	NoteType *models.CustomNoteType
+	Renames  map[string]string // Renamed fields, keyed by their new name
+}
+
+// DeleteNoteTypeMsg requests deleting a note type no note uses
+type DeleteNoteTypeMsg struct {
+	ID models.NoteType
+}
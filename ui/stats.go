@@ -0,0 +1,429 @@
+package ui
+
+import (
+	"anktui/algorithms"
+	"anktui/models"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statsChartDays is the number of days shown in the history and forecast charts
+const statsChartDays = 30
+
+// StatsModel represents the statistics screen
+type StatsModel struct {
+	decks  []*models.Deck
+	logs   []*models.ReviewLog
+	loaded bool
+	scope  int // 0 for all decks, otherwise the index of the deck plus one
+	width  int
+	height int
+
+	rolloverHour int // Hour study days start at
+
+	// Leeches view
+	showLeeches    bool
+	leechThreshold int
+	selectedLeech  int
+}
+
+// NewStatsModel creates a new statistics model. Cards with at least leechThreshold
+// lapses are listed in the leeches view, and reviews are counted by study days starting
+// at rolloverHour
+func NewStatsModel(decks []*models.Deck, leechThreshold, rolloverHour int) *StatsModel {
+	return &StatsModel{
+		decks:          decks,
+		scope:          0,
+		leechThreshold: leechThreshold,
+		rolloverHour:   rolloverHour,
+	}
+}
+
+// SetSize sets the terminal size
+func (m *StatsModel) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// UpdateDecks updates the statistics with fresh deck data
+func (m *StatsModel) UpdateDecks(decks []*models.Deck) {
+	m.decks = decks
+	if m.scope > len(m.decks) {
+		m.scope = 0
+	}
+	m.selectedLeech = 0
+}
+
+// Init implements tea.Model
+func (m *StatsModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m *StatsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case StatsLoadedMsg:
+		m.logs = msg.Logs
+		m.loaded = true
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "left", "h":
+			// Previous scope, wrapping around to the last deck
+			if m.scope > 0 {
+				m.scope--
+			} else {
+				m.scope = len(m.decks)
+			}
+			m.selectedLeech = 0
+		case "right", "l", "tab":
+			// Next scope, wrapping around to all decks
+			if m.scope < len(m.decks) {
+				m.scope++
+			} else {
+				m.scope = 0
+			}
+			m.selectedLeech = 0
+		case "v":
+			// Switch between the charts and the leeches
+			m.showLeeches = !m.showLeeches
+			m.selectedLeech = 0
+		case "up", "k":
+			if m.showLeeches && m.selectedLeech > 0 {
+				m.selectedLeech--
+			}
+		case "down", "j":
+			if m.showLeeches && m.selectedLeech < len(m.leeches())-1 {
+				m.selectedLeech++
+			}
+		case "enter", "e":
+			// Open the selected leech in the card editor to rewrite it
+			leeches := m.leeches()
+			if m.showLeeches && m.selectedLeech < len(leeches) {
+				leech := leeches[m.selectedLeech]
+				return m, func() tea.Msg {
+					return NavigateMsg{
+						Screen: CardEditorScreen,
+						Data:   &CardEditRequest{Deck: leech.Deck, CardID: leech.Card.ID},
+					}
+				}
+			}
+		case "esc":
+			if m.showLeeches {
+				m.showLeeches = false
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				return NavigateMsg{Screen: MenuScreen}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// scopeData returns the name, cards and review logs of the selected scope
+func (m *StatsModel) scopeData() (string, []models.Card, []*models.ReviewLog) {
+	if m.scope == 0 || m.scope > len(m.decks) {
+		var cards []models.Card
+		for _, deck := range m.decks {
+			cards = append(cards, deck.Cards...)
+		}
+		return "All Decks", cards, m.logs
+	}
+
+	deck := m.decks[m.scope-1]
+	var logs []*models.ReviewLog
+	for _, log := range m.logs {
+		if log.DeckID == deck.ID {
+			logs = append(logs, log)
+		}
+	}
+	return deck.Name, deck.Cards, logs
+}
+
+// scopeDecks returns the decks of the selected scope
+func (m *StatsModel) scopeDecks() []*models.Deck {
+	if m.scope == 0 || m.scope > len(m.decks) {
+		return m.decks
+	}
+	return []*models.Deck{m.decks[m.scope-1]}
+}
+
+// leeches returns the leeches of the selected scope, most lapses first
+func (m *StatsModel) leeches() []models.SearchResult {
+	return models.Leeches(m.scopeDecks(), m.leechThreshold)
+}
+
+// View implements tea.Model
+func (m *StatsModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return "Loading..."
+	}
+
+	name, cards, logs := m.scopeData()
+	now := time.Now()
+
+	// Title
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		Render("Statistics")
+
+	scope := lipgloss.NewStyle().
+		Foreground(textColor).
+		Align(lipgloss.Center).
+		PaddingBottom(1).
+		Render(fmt.Sprintf("◀  %s  ▶", name))
+
+	if !m.loaded {
+		loading := mutedTextStyle.Render("Loading review history...")
+		content := lipgloss.JoinVertical(lipgloss.Center, title, scope, loading)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+	}
+
+	if m.showLeeches {
+		content := lipgloss.JoinVertical(lipgloss.Center, title, scope, m.viewLeeches())
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+	}
+
+	topRow := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		m.viewCardCounts(cards),
+		m.viewReviewSummary(logs, now),
+	)
+	bottomRow := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		statsPanel("Reviews per day (last 30 days)",
+			renderColumnChart(algorithms.ReviewsPerDay(logs, now, statsChartDays, m.rolloverHour), 6, secondaryColor, "-29d", "today")),
+		statsPanel("Due forecast (next 30 days)",
+			renderColumnChart(algorithms.DueForecast(cards, now, statsChartDays, m.rolloverHour), 6, accentColor, "today", "+29d")),
+	)
+
+	// Help text
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render("←/→ or h/l: switch deck • v: leeches • Esc: back to menu")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		scope,
+		topRow,
+		bottomRow,
+		help,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// viewLeeches renders the cards that keep being forgotten, so they can be rewritten
+func (m *StatsModel) viewLeeches() string {
+	leeches := m.leeches()
+
+	var rows []string
+	if len(leeches) == 0 {
+		rows = append(rows, mutedTextStyle.Render("No leeches - no card has been forgotten often enough to be one."))
+	} else {
+		rows = append(rows, lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Render(
+			browserCell("Lapses", 7)+" "+browserCell("Deck", 18)+" "+browserCell("Question", 40)+" "+browserCell("State", 9)))
+
+		// Scroll to keep the selection in view
+		visible := max(m.height-14, 5)
+		start := 0
+		if m.selectedLeech >= visible {
+			start = m.selectedLeech - visible + 1
+		}
+		for i := start; i < min(start+visible, len(leeches)); i++ {
+			leech := leeches[i]
+			state := "active"
+			if leech.Card.Suspended {
+				state = "suspended"
+			}
+			row := browserCell(fmt.Sprint(leech.Card.Lapses), 7) + " " +
+				browserCell(leech.Deck.Name, 18) + " " +
+				browserCell(leech.Card.Question(), 40) + " " +
+				browserCell(state, 9)
+
+			style := lipgloss.NewStyle().Foreground(textColor)
+			if i == m.selectedLeech {
+				style = style.Foreground(backgroundColor).Background(secondaryColor)
+			}
+			rows = append(rows, style.Render(row))
+		}
+	}
+
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(0, 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.NewStyle().Foreground(primaryColor).Bold(true).PaddingBottom(1).
+				Render(fmt.Sprintf("Leeches (%d)", len(leeches))),
+			strings.Join(rows, "\n")))
+
+	help := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		PaddingTop(1).
+		Render("↑/↓: select • Enter: edit card • ←/→: switch deck • v or Esc: back to charts")
+
+	return lipgloss.JoinVertical(lipgloss.Center, panel, help)
+}
+
+// viewCardCounts renders the new/young/mature breakdown of the cards
+func (m *StatsModel) viewCardCounts(cards []models.Card) string {
+	total, mature, young, newCards := algorithms.CalculateRetentionStats(cards)
+
+	rows := []string{
+		statsBarRow("New", newCards, total, primaryColor),
+		statsBarRow("Young", young, total, accentColor),
+		statsBarRow("Mature", mature, total, secondaryColor),
+		"",
+		fmt.Sprintf("%-8s %d", "Total", total),
+	}
+
+	if ease := algorithms.AverageEase(cards); ease > 0 {
+		rows = append(rows, fmt.Sprintf("%-8s %.0f%%", "Avg ease", ease*100))
+	} else {
+		rows = append(rows, fmt.Sprintf("%-8s -", "Avg ease"))
+	}
+
+	return statsPanel("Cards", strings.Join(rows, "\n"))
+}
+
+// viewReviewSummary renders review totals, true retention and the answer button distribution
+func (m *StatsModel) viewReviewSummary(logs []*models.ReviewLog, now time.Time) string {
+	perDay := algorithms.ReviewsPerDay(logs, now, statsChartDays, m.rolloverHour)
+	today := perDay[len(perDay)-1]
+	var lastWeek, lastMonth int
+	for i, count := range perDay {
+		lastMonth += count
+		if i >= len(perDay)-7 {
+			lastWeek += count
+		}
+	}
+
+	retention := "-"
+	if passed, total := algorithms.TrueRetention(logs); total > 0 {
+		retention = fmt.Sprintf("%.1f%% (%d/%d)", float64(passed)/float64(total)*100, passed, total)
+	}
+
+	distribution := algorithms.AnswerDistribution(logs)
+	answered := distribution[0] + distribution[1] + distribution[2] + distribution[3]
+
+	rows := []string{
+		fmt.Sprintf("%-10s %d", "Today", today),
+		fmt.Sprintf("%-10s %d", "7 days", lastWeek),
+		fmt.Sprintf("%-10s %d", "30 days", lastMonth),
+		fmt.Sprintf("%-10s %s", "Retention", retention),
+		"",
+		statsBarRow("Again", distribution[models.Again], answered, errorColor),
+		statsBarRow("Hard", distribution[models.Hard], answered, accentColor),
+		statsBarRow("Good", distribution[models.Good], answered, secondaryColor),
+		statsBarRow("Easy", distribution[models.Easy], answered, primaryColor),
+	}
+
+	return statsPanel("Reviews", strings.Join(rows, "\n"))
+}
+
+// statsPanel wraps content in a titled bordered panel
+func statsPanel(title, body string) string {
+	header := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		PaddingBottom(1).
+		Render(title)
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		PaddingLeft(2).
+		PaddingRight(2).
+		Margin(0, 1).
+		Width(44).
+		Render(lipgloss.JoinVertical(lipgloss.Left, header, body))
+}
+
+// statsBarRow renders a labelled horizontal bar showing value as a share of total
+func statsBarRow(label string, value, total int, color lipgloss.Color) string {
+	const barWidth = 18
+
+	filled := 0
+	percent := 0.0
+	if total > 0 {
+		percent = float64(value) / float64(total) * 100
+		filled = value * barWidth / total
+	}
+
+	bar := lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("█", filled)) +
+		mutedTextStyle.Render(strings.Repeat("░", barWidth-filled))
+
+	return fmt.Sprintf("%-7s %s %5d %3.0f%%", label, bar, value, percent)
+}
+
+// renderColumnChart renders values as vertical bars height rows tall with eighth-block precision
+func renderColumnChart(values []int, height int, color lipgloss.Color, leftLabel, rightLabel string) string {
+	levels := []rune(" ▁▂▃▄▅▆▇█")
+
+	maxValue := 0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	axisWidth := len(fmt.Sprintf("%d", maxValue))
+	barStyle := lipgloss.NewStyle().Foreground(color)
+
+	var lines []string
+	for row := height - 1; row >= 0; row-- {
+		var bar strings.Builder
+		for _, v := range values {
+			// Eighths of a row filled by this column at this row
+			eighths := 0
+			if maxValue > 0 {
+				eighths = v*height*8/maxValue - row*8
+			}
+			if eighths < 0 {
+				eighths = 0
+			}
+			if eighths > 8 {
+				eighths = 8
+			}
+			bar.WriteRune(levels[eighths])
+		}
+
+		axis := strings.Repeat(" ", axisWidth)
+		if row == height-1 {
+			axis = fmt.Sprintf("%*d", axisWidth, maxValue)
+		} else if row == 0 {
+			axis = fmt.Sprintf("%*d", axisWidth, 0)
+		}
+		lines = append(lines, mutedTextStyle.Render(axis+" │")+barStyle.Render(bar.String()))
+	}
+
+	// Axis labels under the first and last columns
+	padding := len(values) - len(leftLabel) - len(rightLabel)
+	if padding < 1 {
+		padding = 1
+	}
+	labels := strings.Repeat(" ", axisWidth+2) + leftLabel + strings.Repeat(" ", padding) + rightLabel
+	lines = append(lines, mutedTextStyle.Render(labels))
+
+	return strings.Join(lines, "\n")
+}
+
+// StatsLoadedMsg carries the review history used by the statistics screen
+type StatsLoadedMsg struct {
+	Logs []*models.ReviewLog
+}
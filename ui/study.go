@@ -5,7 +5,9 @@ import (
 	"anktui/models"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
@@ -17,30 +19,67 @@ type StudyState int
 const (
 	ShowingQuestion StudyState = iota
 	ShowingAnswer
+	WaitingForLearning // Only learning cards that are not due yet are left
 	SessionComplete
 )
 
+// previewAgainDelay is how long a card rated Again waits in a filtered deck that does not
+// reschedule cards
+const previewAgainDelay = time.Minute
+
 // StudyModel represents the study session screen
 type StudyModel struct {
 	session        *models.StudySession
-	deck           *models.Deck
+	decks          []*models.Deck                           // Decks the session's cards come from
+	noteTypes      []*models.CustomNoteType                 // For the answer field of custom notes
+	schedulerFor   func(deckID string) algorithms.Scheduler // Spaced repetition algorithm of each deck
 	state          StudyState
 	selectedRating int
-	width          int
-	height         int
+	cardShownAt    time.Time    // When the current question was displayed, for answer timing
+	notice         string       // Shown with the next card after suspending, burying or a leech
+	undo           []ratingUndo // Ratings of this session that can be taken back, latest last
+
+	// Type-in-the-answer mode
+	answerInput textinput.Model
+	diff        []algorithms.DiffSegment // Typed answer compared with the expected one
+	similarity  float64
+
+	width  int
+	height int
 }
 
-// NewStudyModel creates a new study model
-func NewStudyModel(deck *models.Deck, maxCards int, mode models.StudyMode) *StudyModel {
-	session := models.NewStudySession(deck, maxCards, mode)
+// NewStudyModel creates a new study model for a deck, or for a parent deck and its descendants
+func NewStudyModel(decks []*models.Deck, name string, opts models.SessionOptions, noteTypes []*models.CustomNoteType, schedulerFor func(deckID string) algorithms.Scheduler) *StudyModel {
+	session := models.NewStudySessionForDecks(decks, name, opts)
+
+	answerInput := textinput.New()
+	answerInput.Placeholder = "Type the answer..."
+	answerInput.Width = 50
+	answerInput.Focus()
+
+	state := ShowingQuestion
+	if session.IsFinished() {
+		// Nothing is due, so there is nothing to show
+		state = SessionComplete
+	}
+
 	return &StudyModel{
 		session:        session,
-		deck:           deck,
-		state:          ShowingQuestion,
+		decks:          decks,
+		noteTypes:      noteTypes,
+		schedulerFor:   schedulerFor,
+		state:          state,
 		selectedRating: 2, // Default to "Good"
+		cardShownAt:    time.Now(),
+		answerInput:    answerInput,
 	}
 }
 
+// CapturingText reports whether keystrokes are going into the answer field
+func (m *StudyModel) CapturingText() bool {
+	return m.session.Mode == models.TypeAnswerMode && m.state == ShowingQuestion
+}
+
 // SetSize sets the terminal size
 func (m *StudyModel) SetSize(width, height int) {
 	m.width = width
@@ -55,9 +94,27 @@ func (m *StudyModel) Init() tea.Cmd {
 // Update implements tea.Model
 func (m *StudyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case learningDueMsg:
+		// A waiting learning card is due now
+		if m.state == WaitingForLearning && msg.session == m.session {
+			return m, m.nextCard()
+		}
+
 	case tea.KeyMsg:
+		if key := msg.String(); key == "ctrl+z" || key == "u" && !m.CapturingText() {
+			if cmd, ok := m.undoRating(); ok {
+				return m, cmd
+			}
+		}
+
 		switch m.state {
 		case ShowingQuestion:
+			if m.session.Mode == models.TypeAnswerMode {
+				return m.updateTypedAnswer(msg)
+			}
+			if cmd, handled := m.updateCardState(msg.String()); handled {
+				return m, cmd
+			}
 			switch msg.String() {
 			case " ", "enter", "f":
 				// Flip card to show answer
@@ -72,6 +129,9 @@ func (m *StudyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case ShowingAnswer:
+			if cmd, handled := m.updateCardState(msg.String()); handled {
+				return m, cmd
+			}
 			if m.session.Mode == models.PracticeMode {
 				// In practice mode, any key (except esc) advances to next card without rating
 				switch msg.String() {
@@ -113,6 +173,18 @@ func (m *StudyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case WaitingForLearning:
+			switch msg.String() {
+			case "enter", " ":
+				// Study the next learning card early
+				m.session.StudyAhead()
+				m.showQuestion()
+			case "esc":
+				// End the session, the learning cards stay due for later
+				m.session.Learning = nil
+				m.state = SessionComplete
+			}
+
 		case SessionComplete:
 			switch msg.String() {
 			case "enter", "space", "esc":
@@ -122,9 +194,10 @@ func (m *StudyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "r":
 				// Restart session
-				m.session = models.NewStudySession(m.deck, 20, m.session.Mode)
-				m.state = ShowingQuestion
-				return m, nil
+				m.session = models.NewStudySessionForDecks(m.decks, m.session.DeckName, m.session.Options)
+				forget := m.clearUndo()
+				m.showQuestion()
+				return m, forget
 			}
 		}
 	}
@@ -132,6 +205,93 @@ func (m *StudyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateTypedAnswer handles typing the answer, comparing it with the card on Enter
+func (m *StudyModel) updateTypedAnswer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		currentCard := m.session.GetCurrentCard()
+		if currentCard == nil {
+			return m, nil
+		}
+		// Suggest a rating from how close the answer was; it can still be changed
+		expected := currentCard.ExpectedAnswer()
+		if deck := m.deckFor(currentCard); deck != nil {
+			expected = deck.ExpectedAnswer(currentCard, m.noteTypes)
+		}
+		m.diff, m.similarity = algorithms.DiffAnswer(m.answerInput.Value(), expected)
+		m.selectedRating = int(algorithms.SuggestRating(m.similarity))
+		m.session.ShowAnswer()
+		m.state = ShowingAnswer
+		return m, nil
+	case "esc":
+		// Return to deck list
+		return m, func() tea.Msg {
+			return NavigateMsg{Screen: DeckListScreen}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.answerInput, cmd = m.answerInput.Update(msg)
+	return m, cmd
+}
+
+// updateCardState handles suspending (@), burying (-) and flagging (!) the current card,
+// reporting whether key was one of them
+func (m *StudyModel) updateCardState(key string) (tea.Cmd, bool) {
+	if key != "@" && key != "-" && key != "!" {
+		return nil, false
+	}
+	currentCard := m.session.GetCurrentCard()
+	if currentCard == nil {
+		return nil, true
+	}
+	deck := m.deckFor(currentCard)
+	if deck == nil {
+		return nil, true
+	}
+	save := func() tea.Msg {
+		return SaveDeckMsg{Deck: deck}
+	}
+
+	if key == "!" {
+		// Cycle through the flag colors, staying on the card
+		currentCard.Flag = currentCard.Flag.Next()
+		deck.SetFlag(currentCard.ID, currentCard.Flag)
+		return save, true
+	}
+
+	// Take the card out of the session and move on. Ratings before it can no longer be undone,
+	// as the session they would go back to still holds the card
+	forget := m.clearUndo()
+	notice := "Suspended: " + currentCard.Question()
+	if key == "@" {
+		deck.SetSuspended([]string{currentCard.ID}, true)
+	} else {
+		deck.BuryCard(currentCard.ID, time.Now(), m.session.Options.RolloverHour)
+		notice = "Buried until tomorrow: " + currentCard.Question()
+	}
+	m.session.RemoveCurrent()
+	return tea.Batch(m.nextCardWithNotice(notice), save, forget), true
+}
+
+// clearUndo empties the undo stack, returning a command that tells the app the ratings on
+// it can no longer be taken back
+func (m *StudyModel) clearUndo() tea.Cmd {
+	var logIDs []string
+	for _, undo := range m.undo {
+		if undo.logID != "" {
+			logIDs = append(logIDs, undo.logID)
+		}
+	}
+	m.undo = nil
+	if len(logIDs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return ForgetReviewsMsg{LogIDs: logIDs}
+	}
+}
+
 // rateCardAndContinue rates the current card and moves to the next one
 func (m *StudyModel) rateCardAndContinue(rating models.Rating) (tea.Model, tea.Cmd) {
 	currentCard := m.session.GetCurrentCard()
@@ -139,42 +299,273 @@ func (m *StudyModel) rateCardAndContinue(rating models.Rating) (tea.Model, tea.C
 		return m, nil
 	}
 
-	// Update the card with spaced repetition algorithm
-	algorithms.UpdateCardReview(currentCard, rating)
+	deck := m.deckFor(currentCard)
+	if deck == nil {
+		return m, nil
+	}
+	undo := m.newRatingUndo(deck, currentCard)
+	if m.previewing() {
+		m.undo = append(m.undo, undo)
+		return m.previewCardAndContinue(deck, rating)
+	}
 
-	// Update the card in the deck
-	deckCard := m.deck.GetCard(currentCard.ID)
+	// Update the card with the deck's spaced repetition algorithm
+	now := time.Now()
+	before := *currentCard
+	m.schedulerFor(deck.ID).Schedule(currentCard, rating, now)
+	reviewLog := models.NewReviewLog(deck.ID, &before, currentCard, rating, time.Since(m.cardShownAt), m.session.Mode)
+	undo.logID = reviewLog.ID
+	m.undo = append(m.undo, undo)
+
+	// Update the card in the deck and count it towards today's limits; repeated
+	// learning steps are not counted
+	notice := ""
+	leech := false
+	deckCard := deck.GetCard(currentCard.ID)
 	if deckCard != nil {
 		*deckCard = *currentCard
-		m.deck.MarkModified()
+		if !before.InLearning() {
+			deck.RecordStudied(models.StudyDay(now, m.session.Options.RolloverHour), before.IsNew())
+		}
+		deck.MarkModified()
+
+		// Cards that keep being forgotten are tagged, and possibly suspended, as leeches
+		if currentCard.Lapses > before.Lapses && deck.HandleLeech(currentCard.ID, m.session.Options.Leech) {
+			*currentCard = *deckCard
+			leech = true
+			notice = fmt.Sprintf("Leech (%d lapses), tagged %q: %s", currentCard.Lapses, models.LeechTag, currentCard.Question())
+			if currentCard.Suspended {
+				notice = fmt.Sprintf("Leech (%d lapses), suspended: %s", currentCard.Lapses, currentCard.Question())
+			}
+		}
 	}
 
-	// Move to next card
-	if m.session.NextCard() {
-		m.state = ShowingQuestion
-		m.selectedRating = 2 // Reset to "Good"
+	if m.session.Mode == models.FilteredMode {
+		// Cards go back to their home deck once they leave learning
+		if !currentCard.InLearning() {
+			currentCard.BorrowedBy = ""
+			deck.ReturnCards([]string{currentCard.ID})
+		}
 	} else {
-		m.state = SessionComplete
+		// Siblings wait until the next day
+		m.session.BurySiblings(currentCard)
+	}
+
+	// Cards still in learning come back in this session after their step
+	if currentCard.InLearning() && !currentCard.Suspended {
+		m.session.Requeue(*currentCard)
 	}
 
-	// Save the deck after each card (simple approach for now)
-	return m, func() tea.Msg {
-		return SaveDeckMsg{m.deck}
+	// Move to next card
+	waitCmd := m.nextCardWithNotice(notice)
+
+	// Save the deck and record the rating after each card
+	cmds := []tea.Cmd{waitCmd, func() tea.Msg {
+		return ReviewCardMsg{Deck: deck, Log: reviewLog}
+	}}
+	if leech {
+		// The leech tag went on the card's siblings too, so save the whole deck
+		cmds = append(cmds, func() tea.Msg {
+			return SaveDeckMsg{Deck: deck}
+		})
 	}
+	return m, tea.Batch(cmds...)
 }
 
-// continueWithoutRating moves to the next card without rating (for practice mode)
-func (m *StudyModel) continueWithoutRating() (tea.Model, tea.Cmd) {
-	// Move to next card without updating spaced repetition data
+// ratingUndo holds everything a rating changes, so it can be taken back
+type ratingUndo struct {
+	deck    *models.Deck
+	cards   []models.Card      // The rated card and its siblings as they were in the deck
+	today   models.DailyCounts // The deck's counts towards today's limits
+	logID   string             // Review log entry of the rating, empty when none was recorded
+	session models.StudySession
+
+	selectedRating int
+	cardShownAt    time.Time
+	diff           []algorithms.DiffSegment
+	similarity     float64
+}
+
+// newRatingUndo records the state a rating of card is about to change
+func (m *StudyModel) newRatingUndo(deck *models.Deck, card *models.Card) ratingUndo {
+	undo := ratingUndo{
+		deck:           deck,
+		today:          deck.Today,
+		session:        m.session.Snapshot(),
+		selectedRating: m.selectedRating,
+		cardShownAt:    m.cardShownAt,
+		diff:           m.diff,
+		similarity:     m.similarity,
+	}
+	// Leeches tag every card of their note
+	for _, deckCard := range deck.Cards {
+		if deckCard.ID == card.ID || card.NoteID != "" && deckCard.NoteID == card.NoteID {
+			undo.cards = append(undo.cards, deckCard)
+		}
+	}
+	return undo
+}
+
+// undoRating takes back the latest rating, putting its card back in the deck as it was and
+// showing its answer again. It reports whether there was a rating to undo
+func (m *StudyModel) undoRating() (tea.Cmd, bool) {
+	if len(m.undo) == 0 {
+		return nil, false
+	}
+	undo := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+
+	for _, card := range undo.cards {
+		if deckCard := undo.deck.GetCard(card.ID); deckCard != nil {
+			*deckCard = card
+		}
+	}
+	undo.deck.Today = undo.today
+	undo.deck.MarkModified()
+
+	m.session.Restore(undo.session)
+	m.state = ShowingAnswer
+	m.selectedRating = undo.selectedRating
+	m.cardShownAt = undo.cardShownAt
+	m.diff, m.similarity = undo.diff, undo.similarity
+	m.notice = "Rating undone"
+
+	deck := undo.deck
+	if undo.logID == "" {
+		return func() tea.Msg {
+			return SaveDeckMsg{Deck: deck}
+		}, true
+	}
+	logID := undo.logID
+	return func() tea.Msg {
+		return UndoReviewMsg{Deck: deck, LogID: logID}
+	}, true
+}
+
+// previewCardAndContinue handles a rating in a filtered deck that does not reschedule cards.
+// Again shows the card again shortly, and other ratings return it to its home deck unchanged
+func (m *StudyModel) previewCardAndContinue(deck *models.Deck, rating models.Rating) (tea.Model, tea.Cmd) {
+	currentCard := m.session.GetCurrentCard()
+	if rating == models.Again {
+		again := *currentCard
+		again.NextReview = time.Now().Add(previewAgainDelay)
+		m.session.Requeue(again)
+		return m, m.nextCard()
+	}
+
+	deck.ReturnCards([]string{currentCard.ID})
+	waitCmd := m.nextCard()
+	return m, tea.Batch(waitCmd, func() tea.Msg {
+		return SaveDeckMsg{Deck: deck}
+	})
+}
+
+// previewing reports whether the session studies a filtered deck whose ratings leave the
+// cards' scheduling alone
+func (m *StudyModel) previewing() bool {
+	filtered := m.session.Options.Filtered
+	return filtered != nil && !filtered.Filter.Reschedule
+}
+
+// deckFor returns the deck a session card belongs to
+func (m *StudyModel) deckFor(card *models.Card) *models.Deck {
+	deckID := m.session.DeckIDFor(card)
+	for _, deck := range m.decks {
+		if deck.ID == deckID {
+			return deck
+		}
+	}
+	return nil
+}
+
+// nextCardWithNotice moves to the next card, showing notice along with it
+func (m *StudyModel) nextCardWithNotice(notice string) tea.Cmd {
+	cmd := m.nextCard()
+	m.notice = notice
+	return cmd
+}
+
+// nextCard moves to the next card, waiting if only learning cards that are not due yet are left
+func (m *StudyModel) nextCard() tea.Cmd {
 	if m.session.NextCard() {
-		m.state = ShowingQuestion
-		m.selectedRating = 2 // Reset to default (though not used in practice mode)
-	} else {
+		m.showQuestion()
+		return nil
+	}
+
+	due, ok := m.session.NextLearningDue()
+	if !ok {
 		m.state = SessionComplete
+		return nil
 	}
 
-	// No need to save deck since no SRS data changed in practice mode
-	return m, nil
+	// Wake up when the card comes within the learn ahead window
+	m.state = WaitingForLearning
+	session := m.session
+	return tea.Tick(time.Until(due.Add(-session.Options.LearnAhead)), func(time.Time) tea.Msg {
+		return learningDueMsg{session: session}
+	})
+}
+
+// deckTitle returns the deck name along with the session's tag filter
+func (m *StudyModel) deckTitle() string {
+	if tags := m.session.Options.Tags; tags != nil {
+		return fmt.Sprintf("%s • tags: %s", m.session.DeckName, tags)
+	}
+	if m.previewing() {
+		return m.session.DeckName + " • ratings do not reschedule"
+	}
+	return m.session.DeckName
+}
+
+// progressLine renders the card count along with the card's learning state, flag and any notice
+func (m *StudyModel) progressLine(card *models.Card) string {
+	current, total := m.session.GetProgress()
+	progressText := fmt.Sprintf("Card %d of %d", current, total)
+	if card.InLearning() {
+		progressText += " • Learning"
+	}
+	lines := []string{lipgloss.NewStyle().Foreground(mutedColor).Render(progressText)}
+
+	if card.Flag != models.NoFlag {
+		lines[0] += lipgloss.NewStyle().Foreground(flagColor(card.Flag)).Render(" • ⚑ " + card.Flag.String())
+	}
+	if m.notice != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(accentColor).Italic(true).Render(truncateText(m.notice, 60)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Center, lines...)
+}
+
+// flagColor returns the color a flag is shown in
+func flagColor(flag models.Flag) lipgloss.Color {
+	switch flag {
+	case models.RedFlag:
+		return errorColor
+	case models.OrangeFlag:
+		return accentColor
+	case models.GreenFlag:
+		return secondaryColor
+	case models.BlueFlag:
+		return lipgloss.Color("#3B82F6")
+	default:
+		return mutedColor
+	}
+}
+
+// showQuestion resets the view for a new card
+func (m *StudyModel) showQuestion() {
+	m.state = ShowingQuestion
+	m.selectedRating = 2 // Reset to "Good"
+	m.cardShownAt = time.Now()
+	m.answerInput.SetValue("")
+	m.diff = nil
+	m.notice = ""
+}
+
+// continueWithoutRating moves to the next card without rating (for practice mode)
+func (m *StudyModel) continueWithoutRating() (tea.Model, tea.Cmd) {
+	// Move to next card without updating spaced repetition data. No need to save the deck
+	// since no SRS data changed in practice mode
+	return m, m.nextCard()
 }
 
 // View implements tea.Model
@@ -188,6 +579,8 @@ func (m *StudyModel) View() string {
 		return m.viewQuestion()
 	case ShowingAnswer:
 		return m.viewAnswer()
+	case WaitingForLearning:
+		return m.viewWaiting()
 	case SessionComplete:
 		return m.viewSessionComplete()
 	default:
@@ -203,12 +596,7 @@ func (m *StudyModel) viewQuestion() string {
 	}
 
 	// Progress indicator
-	current, total := m.session.GetProgress()
-	progressText := fmt.Sprintf("Card %d of %d", current, total)
-	progress := lipgloss.NewStyle().
-		Foreground(mutedColor).
-		Align(lipgloss.Center).
-		Render(progressText)
+	progress := m.progressLine(currentCard)
 
 	// Deck name
 	deckName := lipgloss.NewStyle().
@@ -216,7 +604,7 @@ func (m *StudyModel) viewQuestion() string {
 		Bold(true).
 		Align(lipgloss.Center).
 		PaddingBottom(1).
-		Render(m.session.DeckName)
+		Render(m.deckTitle())
 
 	// Card content (question)
 	cardContent := lipgloss.NewStyle().
@@ -230,24 +618,30 @@ func (m *StudyModel) viewQuestion() string {
 		Height(8).
 		Align(lipgloss.Center).
 		Foreground(textColor).
-		Render(m.wrapText(currentCard.Front, 50))
+		Render(m.questionContent(currentCard))
 
 	// Instructions
+	instructionText := "Press Space or F to flip card • @: suspend • -: bury • !: flag • u: undo • Esc to exit"
+	sections := []string{progress, deckName, cardContent}
+	if m.session.Mode == models.TypeAnswerMode {
+		instructionText = "Type the answer • Enter: check • Ctrl+Z: undo • Esc: exit"
+		sections = append(sections, lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(secondaryColor).
+			Padding(0, 1).
+			MarginTop(1).
+			Width(56).
+			Render(m.answerInput.View()))
+	}
 	instructions := lipgloss.NewStyle().
 		Foreground(mutedColor).
 		Italic(true).
 		Align(lipgloss.Center).
 		PaddingTop(2).
-		Render("Press Space or F to flip card • Esc to exit")
+		Render(instructionText)
 
 	// Combine elements
-	content := lipgloss.JoinVertical(
-		lipgloss.Center,
-		progress,
-		deckName,
-		cardContent,
-		instructions,
-	)
+	content := lipgloss.JoinVertical(lipgloss.Center, append(sections, instructions)...)
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
@@ -268,12 +662,7 @@ func (m *StudyModel) viewAnswerReview() string {
 	}
 
 	// Progress indicator
-	current, total := m.session.GetProgress()
-	progressText := fmt.Sprintf("Card %d of %d", current, total)
-	progress := lipgloss.NewStyle().
-		Foreground(mutedColor).
-		Align(lipgloss.Center).
-		Render(progressText)
+	progress := m.progressLine(currentCard)
 
 	// Deck name
 	deckName := lipgloss.NewStyle().
@@ -281,22 +670,9 @@ func (m *StudyModel) viewAnswerReview() string {
 		Bold(true).
 		Align(lipgloss.Center).
 		PaddingBottom(1).
-		Render(m.session.DeckName)
+		Render(m.deckTitle())
 
 	// Card content (question and answer)
-	questionText := lipgloss.NewStyle().
-		Foreground(mutedColor).
-		Bold(true).
-		Render("Q: " + currentCard.Front)
-
-	// Render the answer as markdown
-	renderedAnswer := m.renderMarkdown(currentCard.Back, 50)
-	answerText := lipgloss.NewStyle().
-		Foreground(textColor).
-		Bold(true).
-		PaddingTop(1).
-		Render("A: " + renderedAnswer)
-
 	cardContent := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(secondaryColor).
@@ -307,14 +683,25 @@ func (m *StudyModel) viewAnswerReview() string {
 		Width(60).
 		Height(8).
 		Align(lipgloss.Center).
-		Render(lipgloss.JoinVertical(lipgloss.Left, questionText, answerText))
+		Render(m.answerContent(currentCard))
 
-	// Rating buttons
+	// Rating buttons with the interval each rating would schedule
 	ratingOptions := []string{"1 Again", "2 Hard", "3 Good", "4 Easy"}
 	ratingColors := []lipgloss.Color{errorColor, accentColor, secondaryColor, primaryColor}
+	previews := m.schedulerFor(m.session.DeckIDFor(currentCard)).Preview(currentCard, time.Now())
 
 	var ratings []string
 	for i, option := range ratingOptions {
+		next := algorithms.FormatInterval(previews[models.Rating(i)])
+		if m.previewing() {
+			// Only Again shows the card again, the others return it unchanged
+			next = "done"
+			if models.Rating(i) == models.Again {
+				next = algorithms.FormatInterval(previewAgainDelay)
+			}
+		}
+		option = option + "\n" + next
+
 		style := lipgloss.NewStyle().
 			PaddingLeft(2).
 			PaddingRight(2).
@@ -340,22 +727,21 @@ func (m *StudyModel) viewAnswerReview() string {
 	ratingRow := lipgloss.JoinHorizontal(lipgloss.Center, ratings...)
 
 	// Instructions
+	instructionText := "Use 1-4 keys or ←/→ arrows + Enter to rate • @: suspend • -: bury • !: flag • u: undo • Esc to exit"
+	sections := []string{progress, deckName, cardContent}
+	if m.session.Mode == models.TypeAnswerMode {
+		instructionText = "Enter: accept the suggested rating • 1-4 or ←/→: choose another • Esc: exit"
+		sections = append(sections, m.viewTypedAnswer())
+	}
 	instructions := lipgloss.NewStyle().
 		Foreground(mutedColor).
 		Italic(true).
 		Align(lipgloss.Center).
 		PaddingTop(2).
-		Render("Use 1-4 keys or ←/→ arrows + Enter to rate • Esc to exit")
+		Render(instructionText)
 
 	// Combine elements
-	content := lipgloss.JoinVertical(
-		lipgloss.Center,
-		progress,
-		deckName,
-		cardContent,
-		ratingRow,
-		instructions,
-	)
+	content := lipgloss.JoinVertical(lipgloss.Center, append(sections, ratingRow, instructions)...)
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
@@ -368,12 +754,7 @@ func (m *StudyModel) viewAnswerPractice() string {
 	}
 
 	// Progress indicator
-	current, total := m.session.GetProgress()
-	progressText := fmt.Sprintf("Card %d of %d", current, total)
-	progress := lipgloss.NewStyle().
-		Foreground(mutedColor).
-		Align(lipgloss.Center).
-		Render(progressText)
+	progress := m.progressLine(currentCard)
 
 	// Deck name
 	deckName := lipgloss.NewStyle().
@@ -381,22 +762,9 @@ func (m *StudyModel) viewAnswerPractice() string {
 		Bold(true).
 		Align(lipgloss.Center).
 		PaddingBottom(1).
-		Render(m.session.DeckName + " - Practice Mode")
+		Render(m.deckTitle() + " - Practice Mode")
 
 	// Card content (question and answer)
-	questionText := lipgloss.NewStyle().
-		Foreground(mutedColor).
-		Bold(true).
-		Render("Q: " + currentCard.Front)
-
-	// Render the answer as markdown
-	renderedAnswer := m.renderMarkdown(currentCard.Back, 50)
-	answerText := lipgloss.NewStyle().
-		Foreground(textColor).
-		Bold(true).
-		PaddingTop(1).
-		Render("A: " + renderedAnswer)
-
 	cardContent := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(secondaryColor).
@@ -407,7 +775,7 @@ func (m *StudyModel) viewAnswerPractice() string {
 		Width(60).
 		Height(8).
 		Align(lipgloss.Center).
-		Render(lipgloss.JoinVertical(lipgloss.Left, questionText, answerText))
+		Render(m.answerContent(currentCard))
 
 	// Simple continue instruction (no rating buttons)
 	continueButton := lipgloss.NewStyle().
@@ -427,7 +795,7 @@ func (m *StudyModel) viewAnswerPractice() string {
 		Italic(true).
 		Align(lipgloss.Center).
 		PaddingTop(1).
-		Render("Any key: next card • Esc: exit")
+		Render("Any key: next card • @: suspend • -: bury • !: flag • Esc: exit")
 
 	// Combine elements
 	content := lipgloss.JoinVertical(
@@ -442,6 +810,116 @@ func (m *StudyModel) viewAnswerPractice() string {
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
 
+// viewTypedAnswer renders the typed answer compared with the expected one and the suggested rating
+func (m *StudyModel) viewTypedAnswer() string {
+	var diff strings.Builder
+	for _, segment := range m.diff {
+		switch segment.Op {
+		case algorithms.DiffEqual:
+			diff.WriteString(lipgloss.NewStyle().Foreground(secondaryColor).Render(segment.Text))
+		case algorithms.DiffExtra:
+			diff.WriteString(lipgloss.NewStyle().Foreground(errorColor).Strikethrough(true).Render(segment.Text))
+		case algorithms.DiffMissing:
+			diff.WriteString(lipgloss.NewStyle().Foreground(accentColor).Underline(true).Render(segment.Text))
+		}
+	}
+
+	suggestion := fmt.Sprintf("%.0f%% match • suggested: %s", m.similarity*100, algorithms.SuggestRating(m.similarity))
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(mutedColor).
+		Padding(0, 1).
+		MarginTop(1).
+		Width(56).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			mutedTextStyle.Render("Your answer (red: extra, underlined: missing):"),
+			diff.String(),
+			mutedTextStyle.Render(suggestion),
+		))
+}
+
+// questionContent renders the question side of a card. Cards of custom note types are
+// rendered from their templates as markdown
+func (m *StudyModel) questionContent(card *models.Card) string {
+	if card.Type == models.TemplateCard {
+		return m.renderMarkdown(card.Front, 50)
+	}
+	return m.wrapText(card.Question(), 50)
+}
+
+// answerContent renders the question and answer of a card for the answer side
+func (m *StudyModel) answerContent(card *models.Card) string {
+	if card.Type == models.TemplateCard {
+		// The back template decides what to show, usually including {{FrontSide}}
+		return lipgloss.NewStyle().Foreground(textColor).Render(m.renderMarkdown(card.Back, 50))
+	}
+	if card.Type == models.ClozeCard {
+		// Reveal the text with this card's deletions highlighted, followed by any extra text
+		highlight := lipgloss.NewStyle().Foreground(secondaryColor).Bold(true).Underline(true)
+		revealed := models.ClozeAnswer(m.wrapText(card.Front, 50), card.Ord, func(answer string) string {
+			return highlight.Render(answer)
+		})
+		sections := []string{lipgloss.NewStyle().Foreground(textColor).Render(revealed)}
+		if strings.TrimSpace(card.Back) != "" {
+			sections = append(sections, lipgloss.NewStyle().
+				Foreground(mutedColor).
+				PaddingTop(1).
+				Render(m.renderMarkdown(card.Back, 50)))
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, sections...)
+	}
+
+	questionText := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Bold(true).
+		Render("Q: " + card.Front)
+
+	// Render the answer as markdown
+	renderedAnswer := m.renderMarkdown(card.Back, 50)
+	answerText := lipgloss.NewStyle().
+		Foreground(textColor).
+		Bold(true).
+		PaddingTop(1).
+		Render("A: " + renderedAnswer)
+
+	return lipgloss.JoinVertical(lipgloss.Left, questionText, answerText)
+}
+
+// viewWaiting renders the countdown to the next learning card
+func (m *StudyModel) viewWaiting() string {
+	title := lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render(m.deckTitle())
+
+	due, _ := m.session.NextLearningDue()
+	waitText := fmt.Sprintf("%d learning cards left. The next one is due in %s.",
+		len(m.session.Learning), algorithms.FormatInterval(time.Until(due)))
+	wait := lipgloss.NewStyle().
+		Foreground(textColor).
+		Align(lipgloss.Center).
+		PaddingBottom(2).
+		Render(waitText)
+
+	instructions := lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		Render("Enter: study it now • Esc: end session")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		wait,
+		instructions,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
 // viewSessionComplete renders the session completion screen
 func (m *StudyModel) viewSessionComplete() string {
 	// Session stats
@@ -449,6 +927,7 @@ func (m *StudyModel) viewSessionComplete() string {
 	if !m.session.IsFinished() {
 		completedCards++
 	}
+	completedCards = max(completedCards, 0) // Every card may have been suspended or buried
 
 	title := lipgloss.NewStyle().
 		Foreground(secondaryColor).
@@ -458,6 +937,9 @@ func (m *StudyModel) viewSessionComplete() string {
 		Render("🎉 Session Complete!")
 
 	statsText := fmt.Sprintf("You studied %d cards from %s", completedCards, m.session.DeckName)
+	if len(m.session.Cards) == 0 {
+		statsText = fmt.Sprintf("No cards are due in %s right now", m.session.DeckName)
+	}
 	stats := lipgloss.NewStyle().
 		Foreground(textColor).
 		Align(lipgloss.Center).
@@ -469,7 +951,7 @@ func (m *StudyModel) viewSessionComplete() string {
 		Foreground(mutedColor).
 		Italic(true).
 		Align(lipgloss.Center).
-		Render("Press Enter to return to deck list • R to restart session")
+		Render("Press Enter to return to deck list • R to restart session • U to undo the last rating")
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -532,7 +1014,35 @@ func (m *StudyModel) wrapText(text string, width int) string {
 	return strings.Join(lines, "\n")
 }
 
+// learningDueMsg reports that the next learning card of session is due
+type learningDueMsg struct {
+	session *models.StudySession
+}
+
 // SaveDeckMsg is a message to save a deck
 type SaveDeckMsg struct {
 	Deck *models.Deck
 }
+
+// ReviewCardMsg is a message to persist a rated card and its review log entry
+type ReviewCardMsg struct {
+	Deck *models.Deck
+	Log  *models.ReviewLog
+}
+
+// UndoReviewMsg is a message to persist a card whose rating was undone and remove the
+// rating's review log entry
+type UndoReviewMsg struct {
+	Deck  *models.Deck
+	LogID string
+}
+
+// ReviewSavedMsg reports that the rating with review log entry LogID has been saved
+type ReviewSavedMsg struct {
+	LogID string
+}
+
+// ForgetReviewsMsg reports ratings that left the undo stack without being undone
+type ForgetReviewsMsg struct {
+	LogIDs []string
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"anktui/algorithms"
+	"anktui/models"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUndoRating(t *testing.T) {
+	tests := []struct {
+		name        string
+		ratings     []models.Rating
+		undos       int
+		wantIndex   int  // Session card shown after the undos
+		wantRemains bool // Whether a rating is left to undo
+	}{
+		{"undo the only rating", []models.Rating{models.Good}, 1, 0, false},
+		{"undo the latest of two", []models.Rating{models.Good, models.Easy}, 1, 1, true},
+		{"undo both ratings", []models.Rating{models.Good, models.Again}, 2, 0, false},
+		{"undo a leech", []models.Rating{models.Again}, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := models.NewDeck("Spanish", "")
+			for _, front := range []string{"perro", "gato", "pez"} {
+				card := models.NewCard(front, front)
+				card.Repetition = 2
+				card.Interval = 6
+				card.LastReview = time.Now().AddDate(0, 0, -7)
+				card.NextReview = time.Now().Add(-time.Hour)
+				deck.Cards = append(deck.Cards, *card)
+			}
+			original := make(map[string]models.Card)
+			for _, card := range deck.Cards {
+				original[card.ID] = card
+			}
+			opts := models.SessionOptions{
+				MaxCards: 10,
+				Mode:     models.ReviewMode,
+				Limits:   models.DailyLimits{NewCards: 10, Reviews: 10},
+				Leech:    models.LeechSettings{Threshold: 1, Action: models.LeechSuspend},
+			}
+			m := NewStudyModel([]*models.Deck{deck}, deck.Name, opts, nil, func(string) algorithms.Scheduler {
+				return &algorithms.SM2Scheduler{}
+			})
+
+			var logIDs []string
+			for _, rating := range tt.ratings {
+				_, cmd := m.rateCardAndContinue(rating)
+				for _, msg := range runCmd(cmd) {
+					if review, ok := msg.(ReviewCardMsg); ok {
+						logIDs = append(logIDs, review.Log.ID)
+					}
+				}
+			}
+
+			for i := 0; i < tt.undos; i++ {
+				cmd, ok := m.undoRating()
+				if !ok {
+					t.Fatalf("undo %d found nothing to undo", i+1)
+				}
+				msgs := runCmd(cmd)
+				want := logIDs[len(logIDs)-1-i]
+				if len(msgs) != 1 || msgs[0].(UndoReviewMsg).LogID != want {
+					t.Errorf("undo %d sent %v, want the undo of %s", i+1, msgs, want)
+				}
+			}
+
+			if m.state != ShowingAnswer || m.session.CurrentIndex != tt.wantIndex {
+				t.Errorf("showing card %d in state %v, want the answer of card %d", m.session.CurrentIndex, m.state, tt.wantIndex)
+			}
+			if remains := len(m.undo) > 0; remains != tt.wantRemains {
+				t.Errorf("rating left to undo = %v, want %v", remains, tt.wantRemains)
+			}
+
+			// Whatever was undone is back as it was, in the deck and its daily counts
+			rated := len(tt.ratings) - tt.undos
+			if studied := deck.CountsFor(deck.Today.Day).Reviews; studied != rated {
+				t.Errorf("deck counts %d reviews, want %d", studied, rated)
+			}
+			for _, card := range deck.Cards[rated:] {
+				want := original[card.ID]
+				if card.Interval != want.Interval || card.Lapses != want.Lapses || card.Suspended || len(card.Tags) > 0 || !card.NextReview.Equal(want.NextReview) {
+					t.Errorf("card %s is %+v after the undo, want %+v", card.Front, card, want)
+				}
+			}
+		})
+	}
+}
+
+// runCmd runs a command and the commands it batches, returning the messages they send
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, cmd := range batch {
+			msgs = append(msgs, runCmd(cmd)...)
+		}
+		return msgs
+	}
+	if msg == nil {
+		return nil
+	}
+	return []tea.Msg{msg}
+}